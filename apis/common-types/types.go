@@ -160,3 +160,75 @@ type ManagedServiceOutput struct {
 	// refers to a k8s secret that exists in the same namespace as managed service
 	CredentialsRef LocalObjectReference `json:"credentialsRef"`
 }
+
+// S3Target is an s3 compatible bucket, that backups are streamed to
+type S3Target struct {
+	// Endpoint of an s3 compatible storage, other than aws s3, e.g. minio
+	Endpoint string `json:"endpoint,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Bucket   string `json:"bucket"`
+	// Prefix is the path in the bucket, that backups are stored under
+	Prefix string `json:"prefix,omitempty"`
+	// CredentialsRef refers to a secret, with keys AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY. Its namespace defaults to the one of the resource referring to it,
+	// and any other namespace must be allowed by the operator
+	CredentialsRef SecretRef `json:"credentialsRef"`
+}
+
+type BackupSpec struct {
+	// DatabaseName is the Database, in the same namespace, that is backed up
+	DatabaseName string   `json:"databaseName"`
+	Target       S3Target `json:"target"`
+}
+
+// +kubebuilder:object:generate=true
+type BackupOutput struct {
+	// ArtifactURL is where the backup is stored
+	ArtifactURL string `json:"artifactURL,omitempty"`
+	// DbName is the backed up database, as named on its managed service
+	DbName string `json:"dbName,omitempty"`
+	// MsvcRef is the managed service, that the backed up database lives on
+	MsvcRef     *MsvcRef     `json:"msvcRef,omitempty"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+type BackupRetention struct {
+	// KeepLast is the number of most recent, completed backups, that are kept. Older ones are deleted, along with their artifacts
+	KeepLast int `json:"keepLast,omitempty"`
+}
+
+type BackupScheduleSpec struct {
+	// DatabaseName is the Database, in the same namespace, that is backed up
+	DatabaseName string `json:"databaseName"`
+	// Schedule is a cron expression, e.g. "0 2 * * *"
+	Schedule  string          `json:"schedule"`
+	Suspend   bool            `json:"suspend,omitempty"`
+	Target    S3Target        `json:"target"`
+	Retention BackupRetention `json:"retention,omitempty"`
+}
+
+// BackupSpec is the spec of backups, that the schedule creates
+func (s BackupScheduleSpec) BackupSpec() BackupSpec {
+	return BackupSpec{DatabaseName: s.DatabaseName, Target: s.Target}
+}
+
+// +kubebuilder:object:generate=true
+type RestoreSpec struct {
+	// BackupName is the Backup, in the same namespace, that is restored
+	BackupName string `json:"backupName,omitempty"`
+
+	// BackupScheduleName, along with RestoreBefore, restores the latest backup of the schedule, that completed at, or before RestoreBefore.
+	// Only the snapshot of that backup is restored, changes made after it are lost
+	BackupScheduleName string       `json:"backupScheduleName,omitempty"`
+	RestoreBefore      *metav1.Time `json:"restoreBefore,omitempty"`
+
+	// DatabaseName is the Database, that the backup is restored into. It is created on the backup's managed service, when it does not exist
+	DatabaseName string `json:"databaseName"`
+}
+
+// CredentialRotation rotates credentials of a managed resource, periodically, and on demand, with annotation kloudlite.io/rotate-credentials: "true"
+type CredentialRotation struct {
+	// Interval between rotations, defaults to 90 days
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// GracePeriod is how long the previous credential keeps working, after a rotation, so that consumers get to restart. It defaults to 1 hour
+	GracePeriod metav1.Duration `json:"gracePeriod,omitempty"`
+}
@@ -9,6 +9,30 @@ import (
 	"k8s.io/api/core/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupOutput) DeepCopyInto(out *BackupOutput) {
+	*out = *in
+	if in.MsvcRef != nil {
+		in, out := &in.MsvcRef, &out.MsvcRef
+		*out = new(MsvcRef)
+		**out = **in
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupOutput.
+func (in *BackupOutput) DeepCopy() *BackupOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CpuT) DeepCopyInto(out *CpuT) {
 	*out = *in
@@ -99,3 +123,22 @@ func (in *Resources) DeepCopy() *Resources {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
+	*out = *in
+	if in.RestoreBefore != nil {
+		in, out := &in.RestoreBefore, &out.RestoreBefore
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreSpec.
+func (in *RestoreSpec) DeepCopy() *RestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
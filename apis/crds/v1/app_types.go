@@ -2,10 +2,13 @@ package v1
 
 import (
 	"fmt"
+	"time"
 
+	ct "github.com/kloudlite/operator/apis/common-types"
 	"github.com/kloudlite/operator/pkg/constants"
 	jsonPatch "github.com/kloudlite/operator/pkg/json-patch"
 	rApi "github.com/kloudlite/operator/pkg/operator"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -70,9 +73,19 @@ type Probe struct {
 	Interval         uint `json:"interval,omitempty"`
 }
 
+type AppContainerRole string
+
+const (
+	AppContainerRoleMain AppContainerRole = "main"
+	// sidecars run as native sidecars, i.e. init containers that keep running alongside the main containers
+	AppContainerRoleSidecar AppContainerRole = "sidecar"
+)
+
 type AppContainer struct {
 	Name  string `json:"name"`
 	Image string `json:"image"`
+	// +kubebuilder:validation:Enum=main;sidecar
+	Role AppContainerRole `json:"role,omitempty"`
 	// +kubebuilder:default=IfNotPresent
 	ImagePullPolicy string            `json:"imagePullPolicy,omitempty"`
 	Command         []string          `json:"command,omitempty"`
@@ -86,6 +99,10 @@ type AppContainer struct {
 	ReadinessProbe  *Probe            `json:"readinessProbe,omitempty"`
 }
 
+func (ac AppContainer) IsSidecar() bool {
+	return ac.Role == AppContainerRoleSidecar
+}
+
 // func (ac AppContainer) ToYAML() []byte {
 // 	b, err := templates.ParseBytes([]byte(`
 // - name: {{.Name}}
@@ -202,6 +219,126 @@ type HPA struct {
 	ThresholdCpu int `json:"thresholdCpu,omitempty"`
 	// +kubebuilder:default=75
 	ThresholdMemory int `json:"thresholdMemory,omitempty"`
+
+	// metrics, app is scaled on, in addition to cpu and memory
+	Metrics []HPAMetric `json:"metrics,omitempty"`
+	// scale up, and scale down policies, as in autoscaling/v2 HorizontalPodAutoscaler
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+type HPAMetricType string
+
+const (
+	// per pod metrics, served by custom metrics API
+	HPAMetricTypePods HPAMetricType = "pods"
+	// metrics describing app's router ingress, served by custom metrics API
+	HPAMetricTypeObject HPAMetricType = "object"
+	// metrics, not related to any kubernetes object (e.g. queue lag), served by external metrics API
+	HPAMetricTypeExternal HPAMetricType = "external"
+)
+
+type HPAMetric struct {
+	// +kubebuilder:validation:Enum=pods;object;external
+	Type HPAMetricType `json:"type"`
+	Name string        `json:"name"`
+	// selects series of the metric, by their labels
+	Selector map[string]string `json:"selector,omitempty"`
+
+	// router, whose ingress routing to this app describes the metric, when type is object
+	Router string `json:"router,omitempty"`
+
+	// target value of the metric, averaged across pods. It is the only target allowed for pods metrics
+	AverageValue string `json:"averageValue,omitempty"`
+	// target value of the metric, for object and external metrics
+	Value string `json:"value,omitempty"`
+}
+
+type AppRolloutStrategy string
+
+const (
+	AppRolloutStrategyCanary    AppRolloutStrategy = "canary"
+	AppRolloutStrategyBlueGreen AppRolloutStrategy = "blueGreen"
+)
+
+type CanaryStep struct {
+	// percentage of traffic, that is routed to the new revision at this step
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int `json:"weight"`
+	// how long to stay at this step, before moving to the next one. If not set, rollout stays paused until promoted
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+type CanaryRollout struct {
+	// +kubebuilder:validation:MinItems=1
+	Steps []CanaryStep `json:"steps"`
+}
+
+type BlueGreenRollout struct {
+	// switches traffic over to the preview, as soon as it is ready, without waiting to be promoted
+	AutoPromote bool `json:"autoPromote,omitempty"`
+}
+
+// AppRollout controls how a new pod template revision replaces the running one.
+// Rollout can be promoted, or aborted with annotations `kloudlite.io/rollout.promote` and `kloudlite.io/rollout.abort`
+type AppRollout struct {
+	// +kubebuilder:validation:Enum=canary;blueGreen
+	Strategy  AppRolloutStrategy `json:"strategy"`
+	Canary    *CanaryRollout     `json:"canary,omitempty"`
+	BlueGreen *BlueGreenRollout  `json:"blueGreen,omitempty"`
+}
+
+type AppVolumeReclaimPolicy string
+
+const (
+	AppVolumeReclaimPolicyRetain AppVolumeReclaimPolicy = "Retain"
+	AppVolumeReclaimPolicyDelete AppVolumeReclaimPolicy = "Delete"
+)
+
+// AppVolume is a persistent volume, provisioned for the app. Containers mount it with a volume of type `pvc`, and its name as `refName`
+type AppVolume struct {
+	Name       string `json:"name"`
+	ct.Storage `json:",inline"`
+
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadWriteMany;ReadOnlyMany
+	// +kubebuilder:default=ReadWriteOnce
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+
+	// provisions a volume per replica, by running the app as a StatefulSet. Size, storage class, and access mode of such volumes can not
+	// be changed afterwards
+	PerReplica bool `json:"perReplica,omitempty"`
+
+	// whether volume is kept, or deleted along with the app
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default=Delete
+	ReclaimPolicy AppVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
+const (
+	DefaultAppProgressDeadline     = 10 * time.Minute
+	DefaultAppRevisionHistoryLimit = 5
+	DefaultAppIdleAfter            = 15 * time.Minute
+)
+
+// AppScaleToZero scales app down to zero replicas, once it has not received any request for IdleAfter.
+// While the app is idle, its requests reach the app activator, which scales it back up on the first request, and holds requests until the app is ready.
+// A running app is served directly, so IdleAfter counts from the request, that last woke the app up
+type AppScaleToZero struct {
+	Enabled bool `json:"enabled"`
+	// +kubebuilder:default="15m"
+	IdleAfter *metav1.Duration `json:"idleAfter,omitempty"`
+}
+
+// AppAutoRollback rolls app's deployment back to the pod template of its last ready generation,
+// when pods of a new generation keep failing for longer than ProgressDeadline
+type AppAutoRollback struct {
+	Enabled bool `json:"enabled"`
+	// +kubebuilder:default="10m"
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+	// number of ready revisions, kept in status to roll back to
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	RevisionHistoryLimit int `json:"revisionHistoryLimit,omitempty"`
 }
 
 // AppSpec defines the desired state of App
@@ -220,15 +357,120 @@ type AppSpec struct {
 	Replicas   int            `json:"replicas,omitempty"`
 	Services   []AppSvc       `json:"services,omitempty"`
 	Containers []AppContainer `json:"containers"`
+	// init containers run to completion, in order, before containers of the app are started. Ones with role sidecar keep running alongside them
+	InitContainers []AppContainer `json:"initContainers,omitempty"`
 
 	Hpa *HPA `json:"hpa,omitempty"`
 
+	Rollout      *AppRollout      `json:"rollout,omitempty"`
+	AutoRollback *AppAutoRollback `json:"autoRollback,omitempty"`
+	ScaleToZero  *AppScaleToZero  `json:"scaleToZero,omitempty"`
+
+	Volumes []AppVolume `json:"volumes,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
 	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
 	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
 }
 
+type AppRolloutPhase string
+
+const (
+	AppRolloutPhaseProgressing AppRolloutPhase = "Progressing"
+	AppRolloutPhasePaused      AppRolloutPhase = "Paused"
+	AppRolloutPhasePromoting   AppRolloutPhase = "Promoting"
+	AppRolloutPhaseCompleted   AppRolloutPhase = "Completed"
+	AppRolloutPhaseAborted     AppRolloutPhase = "Aborted"
+)
+
+type AppRolloutStatus struct {
+	Strategy AppRolloutStrategy `json:"strategy"`
+	// pod template revision, that is being rolled out
+	Revision string `json:"revision"`
+	// pod template revision, that was serving traffic when this rollout started
+	StableRevision string          `json:"stableRevision,omitempty"`
+	Phase          AppRolloutPhase `json:"phase"`
+
+	// index of the current canary step
+	Step          int          `json:"step,omitempty"`
+	StepStartedAt *metav1.Time `json:"stepStartedAt,omitempty"`
+	// percentage of traffic, currently routed to the new revision
+	Weight int `json:"weight,omitempty"`
+
+	Message string `json:"message,omitempty"`
+}
+
+func (rs *AppRolloutStatus) IsActive() bool {
+	if rs == nil {
+		return false
+	}
+	return rs.Phase == AppRolloutPhaseProgressing || rs.Phase == AppRolloutPhasePaused || rs.Phase == AppRolloutPhasePromoting
+}
+
+// AppPodTemplate holds the fields of AppSpec, that end up in the pod template of app's deployment
+type AppPodTemplate struct {
+	ServiceAccount            string                            `json:"serviceAccount,omitempty"`
+	Containers                []AppContainer                    `json:"containers"`
+	InitContainers            []AppContainer                    `json:"initContainers,omitempty"`
+	Region                    string                            `json:"region,omitempty"`
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+type AppRevision struct {
+	Generation  int64          `json:"generation"`
+	Revision    string         `json:"revision"`
+	PodTemplate AppPodTemplate `json:"podTemplate"`
+}
+
+type AppRollbackStatus struct {
+	// generation, whose pods kept failing
+	FailedGeneration int64  `json:"failedGeneration"`
+	FailedRevision   string `json:"failedRevision"`
+	// generation, whose pod template is running now
+	ToGeneration int64       `json:"toGeneration"`
+	ToRevision   string      `json:"toRevision"`
+	Reason       string      `json:"reason"`
+	RolledBackAt metav1.Time `json:"rolledBackAt"`
+}
+
+type AppStatus struct {
+	rApi.Status `json:",inline"`
+	Rollout     *AppRolloutStatus `json:"rollout,omitempty"`
+
+	// ready revisions, oldest first, bounded by spec.autoRollback.revisionHistoryLimit
+	Revisions []AppRevision `json:"revisions,omitempty"`
+	// since when pods of FailingGeneration have been failing
+	FailingSince      *metav1.Time       `json:"failingSince,omitempty"`
+	FailingGeneration int64              `json:"failingGeneration,omitempty"`
+	Rollback          *AppRollbackStatus `json:"rollback,omitempty"`
+
+	// since when app has been scaled to zero, for not receiving any requests
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+}
+
+// VolumeClaimName is the name of PVC, provisioned for a shared volume of the app
+func (app *App) VolumeClaimName(volume string) string {
+	return fmt.Sprintf("%s-%s", app.Name, volume)
+}
+
+// HasPerReplicaVolumes tells whether app needs to run as a StatefulSet
+func (app *App) HasPerReplicaVolumes() bool {
+	for i := range app.Spec.Volumes {
+		if app.Spec.Volumes[i].PerReplica {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRolledBack tells whether current generation of the app has been rolled back, and is running an older pod template
+func (app *App) IsRolledBack() bool {
+	return app.Status.Rollback != nil && app.Status.Rollback.FailedGeneration == app.Generation
+}
+
 type Intercept struct {
 	Enabled bool `json:"enabled"`
 	// +kubebuilder:validation:MinLength=1
@@ -247,6 +489,7 @@ type JsonPatch struct {
 // +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/resource\\.ready",name=Ready,type=string
 // +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/intercept\\.toDevice",name=Intercepted,type=string
 // +kubebuilder:printcolumn:JSONPath=".status.displayVars.frozen",name=Frozen,type=boolean
+// +kubebuilder:printcolumn:JSONPath=".status.rollout.phase",name=Rollout,type=string
 // +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
 
 // App is the Schema for the apps API
@@ -258,7 +501,7 @@ type App struct {
 	// +kubebuilder:default=true
 	Enabled *bool `json:"enabled,omitempty"`
 
-	Status rApi.Status `json:"status,omitempty" graphql:"noinput"`
+	Status AppStatus `json:"status,omitempty" graphql:"noinput"`
 }
 
 func (app *App) EnsureGVK() {
@@ -267,8 +510,71 @@ func (app *App) EnsureGVK() {
 	}
 }
 
+func (app *App) PodTemplate() AppPodTemplate {
+	return AppPodTemplate{
+		ServiceAccount:            app.Spec.ServiceAccount,
+		Containers:                app.Spec.Containers,
+		InitContainers:            app.Spec.InitContainers,
+		Region:                    app.Spec.Region,
+		NodeSelector:              app.Spec.NodeSelector,
+		Tolerations:               app.Spec.Tolerations,
+		TopologySpreadConstraints: app.Spec.TopologySpreadConstraints,
+	}
+}
+
+// GetProgressDeadline falls back to defaults, for apps that were created without the defaulting webhook
+func (rb *AppAutoRollback) GetProgressDeadline() time.Duration {
+	if rb.ProgressDeadline == nil || rb.ProgressDeadline.Duration <= 0 {
+		return DefaultAppProgressDeadline
+	}
+	return rb.ProgressDeadline.Duration
+}
+
+func (rb *AppAutoRollback) GetRevisionHistoryLimit() int {
+	if rb.RevisionHistoryLimit <= 0 {
+		return DefaultAppRevisionHistoryLimit
+	}
+	return rb.RevisionHistoryLimit
+}
+
+// GetIdleAfter falls back to defaults, for apps that were created without the defaulting webhook
+func (s *AppScaleToZero) GetIdleAfter() time.Duration {
+	if s.IdleAfter == nil || s.IdleAfter.Duration <= 0 {
+		return DefaultAppIdleAfter
+	}
+	return s.IdleAfter.Duration
+}
+
+// WithPodTemplate returns a copy of the app, running the given pod template
+func (app *App) WithPodTemplate(tpl AppPodTemplate) *App {
+	out := app.DeepCopy()
+	tpl = *tpl.DeepCopy()
+	out.Spec.ServiceAccount = tpl.ServiceAccount
+	out.Spec.Containers = tpl.Containers
+	out.Spec.InitContainers = tpl.InitContainers
+	out.Spec.Region = tpl.Region
+	out.Spec.NodeSelector = tpl.NodeSelector
+	out.Spec.Tolerations = tpl.Tolerations
+	out.Spec.TopologySpreadConstraints = tpl.TopologySpreadConstraints
+	return out
+}
+
+// PodContainers splits containers of the app, as they are run in its pods. Sidecars, declared among containers, run as init containers ahead of spec.initContainers,
+// so that init containers can already rely on them
+func (app *App) PodContainers() (initContainers []AppContainer, containers []AppContainer) {
+	for i := range app.Spec.Containers {
+		if app.Spec.Containers[i].IsSidecar() {
+			initContainers = append(initContainers, app.Spec.Containers[i])
+			continue
+		}
+		containers = append(containers, app.Spec.Containers[i])
+	}
+	initContainers = append(initContainers, app.Spec.InitContainers...)
+	return initContainers, containers
+}
+
 func (app *App) GetStatus() *rApi.Status {
-	return &app.Status
+	return &app.Status.Status
 }
 
 func (app *App) GetEnsuredLabels() map[string]string {
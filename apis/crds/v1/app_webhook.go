@@ -0,0 +1,425 @@
+package v1
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (app *App) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(app).Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-crds-kloudlite-io-v1-app,mutating=true,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=apps,verbs=create;update,versions=v1,name=mapp.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &App{}
+
+// Default implements webhook.Defaulter
+func (app *App) Default() {
+	if app.Spec.ServiceAccount == "" {
+		app.Spec.ServiceAccount = "kloudlite-svc-account"
+	}
+
+	for i := range app.Spec.Containers {
+		if app.Spec.Containers[i].ImagePullPolicy == "" {
+			app.Spec.Containers[i].ImagePullPolicy = "IfNotPresent"
+		}
+	}
+
+	for i := range app.Spec.InitContainers {
+		if app.Spec.InitContainers[i].ImagePullPolicy == "" {
+			app.Spec.InitContainers[i].ImagePullPolicy = "IfNotPresent"
+		}
+	}
+
+	for i := range app.Spec.Services {
+		if app.Spec.Services[i].TargetPort == 0 {
+			app.Spec.Services[i].TargetPort = app.Spec.Services[i].Port
+		}
+	}
+
+	if hpa := app.Spec.Hpa; hpa != nil && hpa.Enabled {
+		if hpa.MinReplicas == 0 {
+			hpa.MinReplicas = 1
+		}
+		if hpa.MaxReplicas == 0 {
+			hpa.MaxReplicas = 5
+		}
+		if hpa.ThresholdCpu == 0 {
+			hpa.ThresholdCpu = 90
+		}
+		if hpa.ThresholdMemory == 0 {
+			hpa.ThresholdMemory = 75
+		}
+	}
+
+	for i := range app.Spec.Volumes {
+		if app.Spec.Volumes[i].AccessMode == "" {
+			app.Spec.Volumes[i].AccessMode = corev1.ReadWriteOnce
+		}
+		if app.Spec.Volumes[i].ReclaimPolicy == "" {
+			app.Spec.Volumes[i].ReclaimPolicy = AppVolumeReclaimPolicyDelete
+		}
+	}
+
+	if s := app.Spec.ScaleToZero; s != nil && s.Enabled && s.IdleAfter == nil {
+		s.IdleAfter = &metav1.Duration{Duration: DefaultAppIdleAfter}
+	}
+
+	if rb := app.Spec.AutoRollback; rb != nil && rb.Enabled {
+		if rb.ProgressDeadline == nil {
+			rb.ProgressDeadline = &metav1.Duration{Duration: DefaultAppProgressDeadline}
+		}
+		if rb.RevisionHistoryLimit == 0 {
+			rb.RevisionHistoryLimit = DefaultAppRevisionHistoryLimit
+		}
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-crds-kloudlite-io-v1-app,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=apps,verbs=create;update,versions=v1,name=vapp.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &App{}
+
+func validateProbe(probe *Probe, fldPath *field.Path) field.ErrorList {
+	if probe == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	switch probe.Type {
+	case "shell":
+		if probe.Shell == nil || len(probe.Shell.Command) == 0 {
+			errs = append(errs, field.Required(fldPath.Child("shell", "command"), "must be set, when probe type is shell"))
+		}
+	case "httpGet":
+		if probe.HttpGet == nil {
+			errs = append(errs, field.Required(fldPath.Child("httpGet"), "must be set, when probe type is httpGet"))
+			break
+		}
+		if probe.HttpGet.Port == 0 {
+			errs = append(errs, field.Required(fldPath.Child("httpGet", "port"), ""))
+		}
+	case "tcp":
+		if probe.Tcp == nil || probe.Tcp.Port == 0 {
+			errs = append(errs, field.Required(fldPath.Child("tcp", "port"), "must be set, when probe type is tcp"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("type"), probe.Type, []string{"shell", "httpGet", "tcp"}))
+	}
+	return errs
+}
+
+// validateContainers validates containers of a list. names is shared across containers, and init containers of a pod, as they must be unique within it
+func validateContainers(containers []AppContainer, isInit bool, names map[string]struct{}, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	for i := range containers {
+		c := containers[i]
+		cPath := fldPath.Index(i)
+
+		if c.Name == "" {
+			errs = append(errs, field.Required(cPath.Child("name"), ""))
+		}
+		if _, ok := names[c.Name]; ok {
+			errs = append(errs, field.Duplicate(cPath.Child("name"), c.Name))
+		}
+		names[c.Name] = struct{}{}
+
+		if c.Image == "" {
+			errs = append(errs, field.Required(cPath.Child("image"), ""))
+		}
+
+		for j, e := range c.Env {
+			if e.Type != "" && (e.RefName == "" || e.RefKey == "") {
+				errs = append(errs, field.Required(cPath.Child("env").Index(j), "refName and refKey must be set, when env type is config or secret"))
+			}
+		}
+
+		switch c.Role {
+		case "", AppContainerRoleMain, AppContainerRoleSidecar:
+		default:
+			errs = append(errs, field.NotSupported(cPath.Child("role"), c.Role, []string{string(AppContainerRoleMain), string(AppContainerRoleSidecar)}))
+		}
+
+		// init containers, unlike sidecars, run to completion, and so can not be probed
+		if isInit && !c.IsSidecar() {
+			if c.LivenessProbe != nil {
+				errs = append(errs, field.Forbidden(cPath.Child("livenessProbe"), "is not supported, for init containers"))
+			}
+			if c.ReadinessProbe != nil {
+				errs = append(errs, field.Forbidden(cPath.Child("readinessProbe"), "is not supported, for init containers"))
+			}
+		}
+
+		errs = append(errs, validateProbe(c.LivenessProbe, cPath.Child("livenessProbe"))...)
+		errs = append(errs, validateProbe(c.ReadinessProbe, cPath.Child("readinessProbe"))...)
+	}
+
+	return errs
+}
+
+func (app *App) validate() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if _, containers := app.PodContainers(); len(containers) == 0 {
+		errs = append(errs, field.Required(specPath.Child("containers"), "at least one container, that is not a sidecar, is required"))
+	}
+	containerNames := make(map[string]struct{}, len(app.Spec.Containers)+len(app.Spec.InitContainers))
+	errs = append(errs, validateContainers(app.Spec.Containers, false, containerNames, specPath.Child("containers"))...)
+	errs = append(errs, validateContainers(app.Spec.InitContainers, true, containerNames, specPath.Child("initContainers"))...)
+
+	ports := make(map[uint16]struct{}, len(app.Spec.Services))
+	for i, svc := range app.Spec.Services {
+		if svc.Port == 0 {
+			errs = append(errs, field.Required(specPath.Child("services").Index(i).Child("port"), ""))
+		}
+		if _, ok := ports[svc.Port]; ok {
+			errs = append(errs, field.Duplicate(specPath.Child("services").Index(i).Child("port"), svc.Port))
+		}
+		ports[svc.Port] = struct{}{}
+	}
+
+	if hpa := app.Spec.Hpa; hpa != nil && hpa.Enabled {
+		hpaPath := specPath.Child("hpa")
+		if hpa.MinReplicas < 1 {
+			errs = append(errs, field.Invalid(hpaPath.Child("minReplicas"), hpa.MinReplicas, "must be greater than or equal to 1"))
+		}
+		if hpa.MinReplicas > hpa.MaxReplicas {
+			errs = append(errs, field.Invalid(hpaPath.Child("maxReplicas"), hpa.MaxReplicas, "must be greater than or equal to minReplicas"))
+		}
+		errs = append(errs, validateHPAMetrics(hpa.Metrics, hpaPath.Child("metrics"))...)
+	}
+
+	if app.Spec.Intercept != nil && app.Spec.Intercept.Enabled && app.Spec.Intercept.ToDevice == "" {
+		errs = append(errs, field.Required(specPath.Child("intercept", "toDevice"), "must be set, when intercept is enabled"))
+	}
+
+	errs = append(errs, validateRollout(app.Spec.Rollout, specPath.Child("rollout"))...)
+	errs = append(errs, validateVolumes(app.Spec.Volumes, specPath.Child("volumes"))...)
+
+	if app.Spec.Rollout != nil && app.HasPerReplicaVolumes() {
+		errs = append(errs, field.Forbidden(specPath.Child("rollout"), "is not supported, for apps with per replica volumes"))
+	}
+
+	if rb := app.Spec.AutoRollback; rb != nil && rb.Enabled {
+		if rb.ProgressDeadline != nil && rb.ProgressDeadline.Duration <= 0 {
+			errs = append(errs, field.Invalid(specPath.Child("autoRollback", "progressDeadline"), rb.ProgressDeadline.Duration.String(), "must be greater than 0"))
+		}
+		if rb.RevisionHistoryLimit < 0 {
+			errs = append(errs, field.Invalid(specPath.Child("autoRollback", "revisionHistoryLimit"), rb.RevisionHistoryLimit, "must be greater than or equal to 1"))
+		}
+	}
+
+	if s := app.Spec.ScaleToZero; s != nil && s.Enabled && s.IdleAfter != nil && s.IdleAfter.Duration < time.Minute {
+		errs = append(errs, field.Invalid(specPath.Child("scaleToZero", "idleAfter"), s.IdleAfter.Duration.String(), "must be at least 1m"))
+	}
+
+	return errs
+}
+
+func validateHPAMetrics(metrics []HPAMetric, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	for i, m := range metrics {
+		mPath := fldPath.Index(i)
+
+		if m.Name == "" {
+			errs = append(errs, field.Required(mPath.Child("name"), ""))
+		}
+
+		switch m.Type {
+		case HPAMetricTypePods:
+			if m.Value != "" {
+				errs = append(errs, field.Forbidden(mPath.Child("value"), "pods metrics only support averageValue"))
+			}
+		case HPAMetricTypeObject:
+			if m.Router == "" {
+				errs = append(errs, field.Required(mPath.Child("router"), "must be set, when metric type is object"))
+			}
+		case HPAMetricTypeExternal:
+		default:
+			errs = append(errs, field.NotSupported(mPath.Child("type"), m.Type, []string{string(HPAMetricTypePods), string(HPAMetricTypeObject), string(HPAMetricTypeExternal)}))
+		}
+
+		if (m.Value == "") == (m.AverageValue == "") {
+			errs = append(errs, field.Invalid(mPath, m.Name, "exactly one of value, or averageValue must be set"))
+		}
+
+		if m.Value != "" {
+			if _, err := resource.ParseQuantity(m.Value); err != nil {
+				errs = append(errs, field.Invalid(mPath.Child("value"), m.Value, err.Error()))
+			}
+		}
+		if m.AverageValue != "" {
+			if _, err := resource.ParseQuantity(m.AverageValue); err != nil {
+				errs = append(errs, field.Invalid(mPath.Child("averageValue"), m.AverageValue, err.Error()))
+			}
+		}
+	}
+
+	return errs
+}
+
+func validateVolumes(volumes []AppVolume, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	names := make(map[string]struct{}, len(volumes))
+	for i, v := range volumes {
+		vPath := fldPath.Index(i)
+
+		for _, msg := range validation.IsDNS1123Label(v.Name) {
+			errs = append(errs, field.Invalid(vPath.Child("name"), v.Name, msg))
+		}
+		if _, ok := names[v.Name]; ok {
+			errs = append(errs, field.Duplicate(vPath.Child("name"), v.Name))
+		}
+		names[v.Name] = struct{}{}
+
+		if _, err := resource.ParseQuantity(string(v.Size)); err != nil {
+			errs = append(errs, field.Invalid(vPath.Child("size"), v.Size, err.Error()))
+		}
+
+		switch v.ReclaimPolicy {
+		case "", AppVolumeReclaimPolicyRetain, AppVolumeReclaimPolicyDelete:
+		default:
+			errs = append(errs, field.NotSupported(vPath.Child("reclaimPolicy"), v.ReclaimPolicy, []string{string(AppVolumeReclaimPolicyRetain), string(AppVolumeReclaimPolicyDelete)}))
+		}
+	}
+
+	return errs
+}
+
+// validatePerReplicaVolumesUpdate rejects changes to per replica volumes of an app, that already runs as a StatefulSet, as they are its
+// volume claim templates, which can not be changed
+func validatePerReplicaVolumesUpdate(oldVolumes []AppVolume, volumes []AppVolume, fldPath *field.Path) field.ErrorList {
+	old := make(map[string]AppVolume, len(oldVolumes))
+	for _, v := range oldVolumes {
+		if v.PerReplica {
+			old[v.Name] = v
+		}
+	}
+	if len(old) == 0 {
+		return nil
+	}
+
+	var errs field.ErrorList
+	const reason = "as volume claim templates of app's StatefulSet can not be changed"
+
+	perReplica := 0
+	for i, v := range volumes {
+		if !v.PerReplica {
+			continue
+		}
+		perReplica++
+
+		vPath := fldPath.Index(i)
+		ov, ok := old[v.Name]
+		if !ok {
+			errs = append(errs, field.Forbidden(vPath.Child("perReplica"), "per replica volumes can not be added, "+reason))
+			continue
+		}
+
+		oldSize, oerr := resource.ParseQuantity(string(ov.Size))
+		size, err := resource.ParseQuantity(string(v.Size))
+		if oerr != nil || err != nil || oldSize.Cmp(size) != 0 {
+			errs = append(errs, field.Forbidden(vPath.Child("size"), "can not be changed, "+reason))
+		}
+		if v.StorageClass != ov.StorageClass {
+			errs = append(errs, field.Forbidden(vPath.Child("storageClass"), "can not be changed, "+reason))
+		}
+		if v.AccessMode != ov.AccessMode {
+			errs = append(errs, field.Forbidden(vPath.Child("accessMode"), "can not be changed, "+reason))
+		}
+	}
+
+	// removing some, but not all of them, changes volume claim templates too, while removing all of them runs app as a Deployment again
+	if perReplica > 0 && perReplica < len(old) {
+		errs = append(errs, field.Forbidden(fldPath, "per replica volumes can not be removed, unless all of them are, "+reason))
+	}
+
+	return errs
+}
+
+func validateRollout(rollout *AppRollout, fldPath *field.Path) field.ErrorList {
+	if rollout == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	switch rollout.Strategy {
+	case AppRolloutStrategyCanary:
+		if rollout.Canary == nil || len(rollout.Canary.Steps) == 0 {
+			errs = append(errs, field.Required(fldPath.Child("canary", "steps"), "at least one step is required, when strategy is canary"))
+			break
+		}
+
+		prevWeight := 0
+		for i, step := range rollout.Canary.Steps {
+			stepPath := fldPath.Child("canary", "steps").Index(i)
+			if step.Weight < 1 || step.Weight > 100 {
+				errs = append(errs, field.Invalid(stepPath.Child("weight"), step.Weight, "must be between 1 and 100"))
+			}
+			if step.Weight <= prevWeight {
+				errs = append(errs, field.Invalid(stepPath.Child("weight"), step.Weight, "must be greater than weight of the previous step"))
+			}
+			if step.Pause != nil && step.Pause.Duration < 0 {
+				errs = append(errs, field.Invalid(stepPath.Child("pause"), step.Pause.Duration.String(), "must not be negative"))
+			}
+			prevWeight = step.Weight
+		}
+	case AppRolloutStrategyBlueGreen:
+		if rollout.Canary != nil {
+			errs = append(errs, field.Forbidden(fldPath.Child("canary"), "must not be set, when strategy is blueGreen"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("strategy"), rollout.Strategy, []string{string(AppRolloutStrategyCanary), string(AppRolloutStrategyBlueGreen)}))
+	}
+
+	return errs
+}
+
+func (app *App) toInvalidErr(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.NewInvalid(GroupVersion.WithKind("App").GroupKind(), app.Name, errs)
+}
+
+// ValidateCreate implements webhook.Validator
+func (app *App) ValidateCreate() (admission.Warnings, error) {
+	return nil, app.toInvalidErr(app.validate())
+}
+
+// ValidateUpdate implements webhook.Validator
+func (app *App) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	// apps being deleted, or updated only in their metadata, and status, must not get stuck on their spec
+	if app.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	oldApp, ok := old.(*App)
+	if ok && equality.Semantic.DeepEqual(oldApp.Spec, app.Spec) {
+		return nil, nil
+	}
+
+	errs := app.validate()
+	if ok {
+		errs = append(errs, validatePerReplicaVolumesUpdate(oldApp.Spec.Volumes, app.Spec.Volumes, field.NewPath("spec", "volumes"))...)
+	}
+	return nil, app.toInvalidErr(errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (app *App) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
@@ -11,6 +11,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 type Bool bool
@@ -49,6 +50,13 @@ const (
 	PVCType    ConfigOrSecret = "pvc"
 )
 
+func pvcVolumeName(claimName string) string {
+	if len(validation.IsDNS1123Label(claimName)) == 0 && len(claimName) <= 50 {
+		return claimName
+	}
+	return fn.Md5([]byte(claimName))
+}
+
 func ParseVolumes(containers []AppContainer) (volumes []corev1.Volume, volumeMounts [][]corev1.VolumeMount) {
 	m := map[string][]ContainerVolume{}
 
@@ -57,6 +65,10 @@ func ParseVolumes(containers []AppContainer) (volumes []corev1.Volume, volumeMou
 
 		for _, volume := range container.Volumes {
 			volName := fn.Md5([]byte(volume.MountPath))
+			if volume.Type == PVCType {
+				// a claim is mounted once per pod, even when containers mount it at different paths
+				volName = pvcVolumeName(volume.RefName)
+			}
 			if len(volName) > 50 {
 				volName = volName[:50]
 			}
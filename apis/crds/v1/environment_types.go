@@ -21,12 +21,41 @@ const (
 	EnvironmentRoutingModePrivate EnvironmentRoutingMode = "private"
 )
 
+// EnvironmentCloneFrom copies apps, routers, configmaps, secrets and managed resources of a source environment into this one, once, when it gets created
+type EnvironmentCloneFrom struct {
+	// EnvironmentName is the source environment, in the same namespace as this environment
+	EnvironmentName string `json:"environmentName"`
+
+	// DomainTemplate rewrites domains of cloned routers. It is a go template, with .Domain, .Name (first label of the domain), .Parent (rest of the domain),
+	// .Environment and .SourceEnvironment. Wildcard domains keep their wildcard, and have the rest of the domain rewritten
+	DomainTemplate string `json:"domainTemplate,omitempty"`
+
+	// Seeds fill databases of cloned managed resources from snapshots, once those managed resources are ready
+	Seeds []EnvironmentSeed `json:"seeds,omitempty"`
+}
+
+const DefaultEnvironmentDomainTemplate = "{{.Name}}-{{.Environment}}.{{.Parent}}"
+
+// EnvironmentSeed runs a job, that loads a snapshot into a managed resource. The job gets the managed resource's credentials as environment variables,
+// along with the snapshot as env var SNAPSHOT
+type EnvironmentSeed struct {
+	Name                string `json:"name"`
+	ManagedResourceName string `json:"managedResourceName"`
+	// Snapshot is where data is seeded from, e.g. url of a database dump
+	Snapshot string   `json:"snapshot"`
+	Image    string   `json:"image"`
+	Command  []string `json:"command,omitempty"`
+	Args     []string `json:"args,omitempty"`
+}
+
 // EnvironmentSpec defines the desired state of Environment
 type EnvironmentSpec struct {
 	ProjectName     string `json:"projectName"`
 	TargetNamespace string `json:"targetNamespace,omitempty"`
 
 	Routing *EnvironmentRouting `json:"routing,omitempty"`
+
+	CloneFrom *EnvironmentCloneFrom `json:"cloneFrom,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"fmt"
+	"text/template"
+
+	fn "github.com/kloudlite/operator/pkg/functions"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (e *Environment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(e).Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-crds-kloudlite-io-v1-environment,mutating=true,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=environments,verbs=create;update,versions=v1,name=menvironment.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &Environment{}
+
+// Default implements webhook.Defaulter.
+// Public ingress class depends on the cluster, so it is still defaulted by the environment controller
+func (e *Environment) Default() {
+	if e.Spec.Routing == nil {
+		e.Spec.Routing = &EnvironmentRouting{}
+	}
+
+	if e.Spec.Routing.Mode == "" {
+		e.Spec.Routing.Mode = EnvironmentRoutingModePrivate
+	}
+
+	if e.Spec.Routing.PrivateIngressClass == "" {
+		e.Spec.Routing.PrivateIngressClass = fmt.Sprintf("k-%s", fn.Md5([]byte(fmt.Sprintf("%s-env-%s", e.Spec.TargetNamespace, e.Name))))
+	}
+
+	if e.Spec.CloneFrom != nil && e.Spec.CloneFrom.DomainTemplate == "" {
+		e.Spec.CloneFrom.DomainTemplate = DefaultEnvironmentDomainTemplate
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-crds-kloudlite-io-v1-environment,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=environments,verbs=create;update,versions=v1,name=venvironment.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Environment{}
+
+func (e *Environment) validate() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if e.Spec.ProjectName == "" {
+		errs = append(errs, field.Required(specPath.Child("projectName"), ""))
+	}
+
+	if e.Spec.TargetNamespace == "" {
+		errs = append(errs, field.Required(specPath.Child("targetNamespace"), ""))
+	}
+
+	for _, msg := range validation.IsDNS1123Label(e.Spec.TargetNamespace) {
+		errs = append(errs, field.Invalid(specPath.Child("targetNamespace"), e.Spec.TargetNamespace, msg))
+	}
+
+	if e.Spec.Routing != nil && e.Spec.Routing.Mode != "" {
+		switch e.Spec.Routing.Mode {
+		case EnvironmentRoutingModePublic, EnvironmentRoutingModePrivate:
+		default:
+			errs = append(errs, field.NotSupported(specPath.Child("routing", "mode"), e.Spec.Routing.Mode, []string{string(EnvironmentRoutingModePublic), string(EnvironmentRoutingModePrivate)}))
+		}
+	}
+
+	if e.Spec.CloneFrom != nil {
+		errs = append(errs, e.validateCloneFrom(specPath.Child("cloneFrom"))...)
+	}
+
+	return errs
+}
+
+func (e *Environment) validateCloneFrom(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	cf := e.Spec.CloneFrom
+
+	if cf.EnvironmentName == "" {
+		errs = append(errs, field.Required(path.Child("environmentName"), ""))
+	}
+	if cf.EnvironmentName == e.Name {
+		errs = append(errs, field.Invalid(path.Child("environmentName"), cf.EnvironmentName, "environment can not be cloned from itself"))
+	}
+
+	if cf.DomainTemplate != "" {
+		if _, err := template.New("domain").Option("missingkey=error").Parse(cf.DomainTemplate); err != nil {
+			errs = append(errs, field.Invalid(path.Child("domainTemplate"), cf.DomainTemplate, err.Error()))
+		}
+	}
+
+	seedNames := map[string]struct{}{}
+	for i, seed := range cf.Seeds {
+		seedPath := path.Child("seeds").Index(i)
+
+		for _, msg := range validation.IsDNS1123Label(seed.Name) {
+			errs = append(errs, field.Invalid(seedPath.Child("name"), seed.Name, msg))
+		}
+		if _, ok := seedNames[seed.Name]; ok {
+			errs = append(errs, field.Duplicate(seedPath.Child("name"), seed.Name))
+		}
+		seedNames[seed.Name] = struct{}{}
+
+		if seed.ManagedResourceName == "" {
+			errs = append(errs, field.Required(seedPath.Child("managedResourceName"), ""))
+		}
+		if seed.Snapshot == "" {
+			errs = append(errs, field.Required(seedPath.Child("snapshot"), ""))
+		}
+		if seed.Image == "" {
+			errs = append(errs, field.Required(seedPath.Child("image"), ""))
+		}
+	}
+
+	return errs
+}
+
+func (e *Environment) toInvalidErr(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.NewInvalid(GroupVersion.WithKind("Environment").GroupKind(), e.Name, errs)
+}
+
+// ValidateCreate implements webhook.Validator
+func (e *Environment) ValidateCreate() (admission.Warnings, error) {
+	return nil, e.toInvalidErr(e.validate())
+}
+
+// ValidateUpdate implements webhook.Validator
+func (e *Environment) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	errs := e.validate()
+
+	if oldEnv, ok := old.(*Environment); ok && oldEnv.Spec.TargetNamespace != "" && oldEnv.Spec.TargetNamespace != e.Spec.TargetNamespace {
+		// resources in the old namespace would otherwise be orphaned
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "targetNamespace"), "is immutable"))
+	}
+
+	if oldEnv, ok := old.(*Environment); ok && e.Spec.CloneFrom != nil {
+		// cloning happens only once, when environment is created
+		switch {
+		case oldEnv.Spec.CloneFrom == nil:
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "cloneFrom"), "can only be set, when environment is created"))
+		case oldEnv.Spec.CloneFrom.EnvironmentName != e.Spec.CloneFrom.EnvironmentName:
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "cloneFrom", "environmentName"), "is immutable"))
+		}
+	}
+
+	return nil, e.toInvalidErr(errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (e *Environment) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"net/url"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (h *HelmChart) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(h).Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-crds-kloudlite-io-v1-helmchart,mutating=true,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=helmcharts,verbs=create;update,versions=v1,name=mhelmchart.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &HelmChart{}
+
+// Default implements webhook.Defaulter
+func (h *HelmChart) Default() {
+	if h.Spec.ReleaseName == "" {
+		h.Spec.ReleaseName = h.Name
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-crds-kloudlite-io-v1-helmchart,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=helmcharts,verbs=create;update,versions=v1,name=vhelmchart.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &HelmChart{}
+
+func (h *HelmChart) validate() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if h.Spec.ChartRepoURL == "" {
+		errs = append(errs, field.Required(specPath.Child("chartRepoURL"), ""))
+	} else if u, err := url.Parse(h.Spec.ChartRepoURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, field.Invalid(specPath.Child("chartRepoURL"), h.Spec.ChartRepoURL, "must be a valid url, with scheme and host"))
+	}
+
+	if h.Spec.ChartName == "" {
+		errs = append(errs, field.Required(specPath.Child("chartName"), ""))
+	}
+
+	if h.Spec.ChartVersion == "" {
+		errs = append(errs, field.Required(specPath.Child("chartVersion"), ""))
+	}
+
+	return errs
+}
+
+func (h *HelmChart) toInvalidErr(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.NewInvalid(GroupVersion.WithKind("HelmChart").GroupKind(), h.Name, errs)
+}
+
+// ValidateCreate implements webhook.Validator
+func (h *HelmChart) ValidateCreate() (admission.Warnings, error) {
+	return nil, h.toInvalidErr(h.validate())
+}
+
+// ValidateUpdate implements webhook.Validator
+func (h *HelmChart) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	errs := h.validate()
+
+	if oldChart, ok := old.(*HelmChart); ok && oldChart.Spec.ReleaseName != "" && oldChart.Spec.ReleaseName != h.Spec.ReleaseName {
+		// changing release name would install a second release, instead of upgrading the existing one
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "releaseName"), "is immutable"))
+	}
+
+	return nil, h.toInvalidErr(errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (h *HelmChart) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
@@ -63,6 +63,14 @@ func (m *ManagedResource) NameRef() string {
 	return fmt.Sprintf("%s/%s/%s", m.GroupVersionKind().Group, m.Namespace, m.Name)
 }
 
+// RealResourceName is the name of the underlying resource, that gets created from ResourceTemplate
+func (m *ManagedResource) RealResourceName() string {
+	if m.Spec.ResourceNamePrefix != nil {
+		return fmt.Sprintf("%s-%s", *m.Spec.ResourceNamePrefix, m.Name)
+	}
+	return m.Name
+}
+
 func (m *ManagedResource) GetStatus() *rApi.Status {
 	return &m.Status
 }
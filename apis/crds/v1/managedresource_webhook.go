@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (m *ManagedResource) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(m).Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-crds-kloudlite-io-v1-managedresource,mutating=true,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=managedresources,verbs=create;update,versions=v1,name=mmanagedresource.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &ManagedResource{}
+
+// Default implements webhook.Defaulter
+func (m *ManagedResource) Default() {
+	if m.Output.CredentialsRef.Name == "" {
+		m.Output.CredentialsRef.Name = fmt.Sprintf("mres-%s-creds", m.RealResourceName())
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-crds-kloudlite-io-v1-managedresource,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=managedresources,verbs=create;update,versions=v1,name=vmanagedresource.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ManagedResource{}
+
+func (m *ManagedResource) validate() field.ErrorList {
+	var errs field.ErrorList
+	tplPath := field.NewPath("spec", "resourceTemplate")
+
+	if m.Spec.ResourceTemplate.APIVersion == "" {
+		errs = append(errs, field.Required(tplPath.Child("apiVersion"), ""))
+	}
+
+	if m.Spec.ResourceTemplate.Kind == "" {
+		errs = append(errs, field.Required(tplPath.Child("kind"), ""))
+	}
+
+	msvcRef := m.Spec.ResourceTemplate.MsvcRef
+	if msvcRef.Name == "" {
+		errs = append(errs, field.Required(tplPath.Child("msvcRef", "name"), ""))
+	}
+	if msvcRef.APIVersion == "" {
+		errs = append(errs, field.Required(tplPath.Child("msvcRef", "apiVersion"), ""))
+	}
+	if msvcRef.Kind == "" {
+		errs = append(errs, field.Required(tplPath.Child("msvcRef", "kind"), ""))
+	}
+
+	return errs
+}
+
+func (m *ManagedResource) toInvalidErr(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.NewInvalid(GroupVersion.WithKind("ManagedResource").GroupKind(), m.Name, errs)
+}
+
+// ValidateCreate implements webhook.Validator
+func (m *ManagedResource) ValidateCreate() (admission.Warnings, error) {
+	return nil, m.toInvalidErr(m.validate())
+}
+
+// ValidateUpdate implements webhook.Validator
+func (m *ManagedResource) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	errs := m.validate()
+
+	if oldMres, ok := old.(*ManagedResource); ok && oldMres.RealResourceName() != m.RealResourceName() {
+		// underlying resource would otherwise be orphaned
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "resourceNamePrefix"), "is immutable"))
+	}
+
+	return nil, m.toInvalidErr(errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (m *ManagedResource) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
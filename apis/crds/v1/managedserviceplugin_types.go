@@ -0,0 +1,103 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PluginHelmChart installs the service from a helm chart
+type PluginHelmChart struct {
+	ChartRepoURL string `json:"chartRepoURL"`
+	ChartName    string `json:"chartName"`
+	ChartVersion string `json:"chartVersion"`
+
+	// Values is a go template, rendering into helm values, as yaml
+	Values string `json:"values,omitempty"`
+}
+
+// PluginReadinessProbe waits for a resource, created by the plugin, to be ready. Deployments and StatefulSets are ready, when all
+// their replicas are, Jobs when they succeed, and anything else when its .status.isReady is true
+type PluginReadinessProbe struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Name is a go template
+	Name string `json:"name"`
+}
+
+// PluginTemplate declares how instances are provisioned, through either a helm chart, or manifests, and what goes into their output secret.
+//
+// Templates are rendered with .Name, .Namespace, .Spec (of the service, or resource template), .NodeSelector, .Tolerations and .Output,
+// the output secret's data. Resources also get .Msvc, the same values for the managed service they are created on
+type PluginTemplate struct {
+	HelmChart *PluginHelmChart `json:"helmChart,omitempty"`
+
+	// Manifests is a go template, rendering into kubernetes manifests. Namespaced ones default to the namespace of the instance
+	Manifests string `json:"manifests,omitempty"`
+
+	// Output maps keys of the output secret to go templates of their values. They are rendered only once, when the secret is created, so
+	// that generated passwords stay put
+	Output map[string]string `json:"output,omitempty"`
+
+	ReadinessProbes []PluginReadinessProbe `json:"readinessProbes,omitempty"`
+}
+
+// PluginResource provisions ManagedResources of Kind, on services of the plugin
+type PluginResource struct {
+	Kind           string `json:"kind"`
+	PluginTemplate `json:",inline"`
+}
+
+// ManagedServicePluginSpec defines the desired state of ManagedServicePlugin
+type ManagedServicePluginSpec struct {
+	// APIVersion and Kind are what ManagedService.spec.serviceTemplate refers to, for it to be provisioned by this plugin. They do not need
+	// to be backed by a CRD
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	PluginTemplate `json:",inline"`
+
+	// Resources maps kinds of ManagedResource.spec.resourceTemplate, under the same APIVersion, to how they are provisioned
+	Resources []PluginResource `json:"resources,omitempty"`
+}
+
+// Resource finds how ManagedResources of kind are provisioned
+func (s *ManagedServicePluginSpec) Resource(kind string) *PluginResource {
+	for i := range s.Resources {
+		if s.Resources[i].Kind == kind {
+			return &s.Resources[i]
+		}
+	}
+	return nil
+}
+
+//+kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:JSONPath=".spec.apiVersion",name=Service_APIVersion,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.kind",name=Service_Kind,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// ManagedServicePlugin is the Schema for the managedserviceplugins API
+type ManagedServicePlugin struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ManagedServicePluginSpec `json:"spec"`
+}
+
+func (m *ManagedServicePlugin) EnsureGVK() {
+	if m != nil {
+		m.SetGroupVersionKind(GroupVersion.WithKind("ManagedServicePlugin"))
+	}
+}
+
+//+kubebuilder:object:root=true
+
+// ManagedServicePluginList contains a list of ManagedServicePlugin
+type ManagedServicePluginList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagedServicePlugin `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagedServicePlugin{}, &ManagedServicePluginList{})
+}
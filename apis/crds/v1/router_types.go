@@ -3,6 +3,7 @@ package v1
 import (
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kloudlite/operator/pkg/constants"
@@ -16,6 +17,58 @@ type Route struct {
 	Port uint16 `json:"port"`
 	// +kubebuilder:default=false
 	Rewrite bool `json:"rewrite,omitempty"`
+
+	// Backends receive a share of route's traffic, while the rest of it keeps going to route's app.
+	// ingress-nginx honours only one canary per host and path, so a route may have at most one backend
+	// +kubebuilder:validation:MaxItems=1
+	Backends []RouteBackend `json:"backends,omitempty"`
+
+	// RateLimit, and MaxBodySizeInMB override router's own, for this route
+	RateLimit       *RateLimit     `json:"rateLimit,omitempty"`
+	MaxBodySizeInMB *int           `json:"maxBodySizeInMB,omitempty"`
+	Timeouts        *RouteTimeouts `json:"timeouts,omitempty"`
+	Retry           *RouteRetry    `json:"retry,omitempty"`
+	// IPAllowList, and IPDenyList are IPs, or CIDRs, of clients allowed, or denied to reach this route
+	IPAllowList []string `json:"ipAllowList,omitempty"`
+	IPDenyList  []string `json:"ipDenyList,omitempty"`
+}
+
+// RouteTimeouts are proxy timeouts, in seconds, towards route's backends
+type RouteTimeouts struct {
+	Connect int `json:"connect,omitempty"`
+	Read    int `json:"read,omitempty"`
+	Send    int `json:"send,omitempty"`
+}
+
+// RouteRetry retries a request on the next backend pod, when it fails
+type RouteRetry struct {
+	// On is a list of conditions, as understood by nginx's proxy_next_upstream, like error, timeout, http_502
+	On []string `json:"on,omitempty"`
+	// Attempts, including the first one. 0 means unlimited
+	Attempts int `json:"attempts,omitempty"`
+	// TimeoutInSeconds limits time, spent retrying a request. 0 means unlimited
+	TimeoutInSeconds int `json:"timeoutInSeconds,omitempty"`
+}
+
+// RouteBackend is an app, that receives requests matching its conditions, and a weighted share of the others
+type RouteBackend struct {
+	App  string `json:"app"`
+	Port uint16 `json:"port"`
+	// Weight is the percentage of route's traffic, sent to this backend
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int         `json:"weight,omitempty"`
+	Match  *RouteMatch `json:"match,omitempty"`
+}
+
+// RouteMatch selects requests, that always go to a route backend, regardless of its weight
+type RouteMatch struct {
+	// Header is the request header to match on. Without HeaderValue or HeaderRegex, requests with this header set to "always" are matched
+	Header      string `json:"header,omitempty"`
+	HeaderValue string `json:"headerValue,omitempty"`
+	HeaderRegex string `json:"headerRegex,omitempty"`
+	// Cookie is the name of the cookie, that when set to "always", matches the request
+	Cookie string `json:"cookie,omitempty"`
 }
 
 type RateLimit struct {
@@ -38,6 +91,48 @@ type BasicAuth struct {
 	SecretName string `json:"secretName,omitempty"`
 }
 
+// OIDCAuth signs users in with an OpenID Connect provider, through an oauth2-proxy instance run alongside the router
+type OIDCAuth struct {
+	Enabled bool `json:"enabled"`
+	// SecretName is a secret, in router's namespace, holding oauth2-proxy settings under keys issuer-url, client-id, client-secret and cookie-secret.
+	// Routers sharing a secret, share an oauth2-proxy instance too
+	SecretName          string   `json:"secretName"`
+	AllowedGroups       []string `json:"allowedGroups,omitempty"`
+	AllowedEmailDomains []string `json:"allowedEmailDomains,omitempty"`
+}
+
+// ForwardAuth asks an external service, whether a request is allowed through. Any 2xx response allows it, while 401 and 403 deny it
+type ForwardAuth struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`
+	// SignInURL is where unauthenticated users are redirected to
+	SignInURL string `json:"signInURL,omitempty"`
+	// ResponseHeaders are copied from auth service's response, into the request to backend
+	ResponseHeaders []string `json:"responseHeaders,omitempty"`
+}
+
+// RouterAuth protects routes behind single sign-on
+type RouterAuth struct {
+	OIDC        *OIDCAuth    `json:"oidc,omitempty"`
+	ForwardAuth *ForwardAuth `json:"forwardAuth,omitempty"`
+}
+
+// MTLS requires clients to present a certificate, issued by a trusted CA. Subject of the verified certificate
+// reaches backends in the ssl-client-subject-dn header, and outcome of the verification in the ssl-client-verify header
+type MTLS struct {
+	Enabled bool `json:"enabled"`
+	// CASecretName is a secret, in router's namespace, holding the CA certificate under key ca.crt
+	CASecretName string `json:"caSecretName,omitempty"`
+	// Issuer is a cert-manager CA Issuer, in router's namespace, whose CA certificate client certificates are verified against
+	Issuer string `json:"issuer,omitempty"`
+	// +kubebuilder:default=1
+	VerifyDepth int `json:"verifyDepth,omitempty"`
+	// Optional lets requests without a client certificate through, leaving it to backends to check ssl-client-verify header
+	Optional bool `json:"optional,omitempty"`
+	// ForwardClientCert passes the client certificate, url encoded, to backends in the ssl-client-cert header
+	ForwardClientCert bool `json:"forwardClientCert,omitempty"`
+}
+
 type Cors struct {
 	// +kubebuilder:default=false
 	Enabled          bool     `json:"enabled,omitempty"`
@@ -45,6 +140,31 @@ type Cors struct {
 	AllowCredentials bool     `json:"allowCredentials,omitempty"`
 }
 
+// backend protocols, as understood by ingress-nginx's backend-protocol annotation
+const (
+	BackendProtocolHTTP  string = "HTTP"
+	BackendProtocolHTTPS string = "HTTPS"
+	// BackendProtocolGRPC talks gRPC to backends over cleartext HTTP/2 (h2c)
+	BackendProtocolGRPC  string = "GRPC"
+	BackendProtocolGRPCS string = "GRPCS"
+	// BackendProtocolGRPCWeb proxies gRPC-web requests over HTTP, to backends that serve gRPC-web themselves
+	BackendProtocolGRPCWeb  string = "GRPC_WEB"
+	BackendProtocolAutoHTTP string = "AUTO_HTTP"
+	BackendProtocolFCGI     string = "FCGI"
+)
+
+// RouterPort exposes a raw TCP, or UDP port on router's ingress controller
+type RouterPort struct {
+	// Port, that ingress controller listens on
+	Port uint16 `json:"port"`
+	// +kubebuilder:validation:Enum=TCP;UDP
+	// +kubebuilder:default=TCP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// Service, in router's namespace, that receives the traffic. An app is served by a service named after it
+	Service     string `json:"service"`
+	ServicePort uint16 `json:"servicePort"`
+}
+
 // RouterSpec defines the desired state of Router
 type RouterSpec struct {
 	IngressClass    string  `json:"ingressClass,omitempty"`
@@ -52,12 +172,16 @@ type RouterSpec struct {
 	Https           *Https  `json:"https,omitempty"`
 	// +kubebuilder:validation:Optional
 
-	RateLimit       *RateLimit `json:"rateLimit,omitempty"`
-	MaxBodySizeInMB *int       `json:"maxBodySizeInMB,omitempty"`
-	Domains         []string   `json:"domains"`
-	Routes          []Route    `json:"routes,omitempty"`
-	BasicAuth       *BasicAuth `json:"basicAuth,omitempty"`
-	Cors            *Cors      `json:"cors,omitempty"`
+	RateLimit       *RateLimit  `json:"rateLimit,omitempty"`
+	MaxBodySizeInMB *int        `json:"maxBodySizeInMB,omitempty"`
+	Domains         []string    `json:"domains"`
+	Routes          []Route     `json:"routes,omitempty"`
+	BasicAuth       *BasicAuth  `json:"basicAuth,omitempty"`
+	Auth            *RouterAuth `json:"auth,omitempty"`
+	Cors            *Cors       `json:"cors,omitempty"`
+	MTLS            *MTLS       `json:"mtls,omitempty"`
+	// Ports are exposed as is, through ingress-nginx's tcp-services and udp-services configmaps
+	Ports []RouterPort `json:"ports,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -103,6 +227,14 @@ func (m *Router) GetEnsuredAnnotations() map[string]string {
 	}
 }
 
+func (r *Router) IsOIDCEnabled() bool {
+	return r.Spec.Auth != nil && r.Spec.Auth.OIDC != nil && r.Spec.Auth.OIDC.Enabled
+}
+
+func (r *Router) IsForwardAuthEnabled() bool {
+	return r.Spec.Auth != nil && r.Spec.Auth.ForwardAuth != nil && r.Spec.Auth.ForwardAuth.Enabled
+}
+
 // +kubebuilder:object:root=true
 
 // RouterList contains a list of Router
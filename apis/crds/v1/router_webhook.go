@@ -0,0 +1,414 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (r *Router) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(r).WithValidator(&routerValidator{client: mgr.GetClient()}).Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-crds-kloudlite-io-v1-router,mutating=true,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=routers,verbs=create;update,versions=v1,name=mrouter.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &Router{}
+
+// Default implements webhook.Defaulter
+func (r *Router) Default() {
+	if r.Spec.BasicAuth != nil && r.Spec.BasicAuth.Enabled && r.Spec.BasicAuth.SecretName == "" {
+		r.Spec.BasicAuth.SecretName = r.Name + "-basic-auth"
+	}
+
+	for i := range r.Spec.Routes {
+		if r.Spec.Routes[i].Path == "" {
+			r.Spec.Routes[i].Path = "/"
+		}
+	}
+
+	if r.Spec.MTLS != nil && r.Spec.MTLS.Enabled && r.Spec.MTLS.VerifyDepth == 0 {
+		r.Spec.MTLS.VerifyDepth = 1
+	}
+
+	// ingress-nginx reads backend protocol case insensitively
+	if r.Spec.BackendProtocol != nil {
+		bp := strings.ToUpper(*r.Spec.BackendProtocol)
+		r.Spec.BackendProtocol = &bp
+	}
+
+	for i := range r.Spec.Ports {
+		if r.Spec.Ports[i].Protocol == "" {
+			r.Spec.Ports[i].Protocol = corev1.ProtocolTCP
+		}
+	}
+}
+
+// IsGRPC tells whether router's backends talk gRPC, or gRPC-web
+func (r *Router) IsGRPC() bool {
+	if r.Spec.BackendProtocol == nil {
+		return false
+	}
+	switch strings.ToUpper(*r.Spec.BackendProtocol) {
+	case BackendProtocolGRPC, BackendProtocolGRPCS, BackendProtocolGRPCWeb:
+		return true
+	}
+	return false
+}
+
+//+kubebuilder:webhook:path=/validate-crds-kloudlite-io-v1-router,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.kloudlite.io,resources=routers,verbs=create;update,versions=v1,name=vrouter.kb.io,admissionReviewVersions=v1
+
+// routerValidator needs a client, as routes are validated against services exposed by the apps they point to
+type routerValidator struct {
+	client client.Client
+}
+
+var _ webhook.CustomValidator = &routerValidator{}
+
+func (r *Router) validateSpec() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	// routers, that only expose tcp/udp ports, are not served on any domain
+	if len(r.Spec.Domains) == 0 && (len(r.Spec.Routes) > 0 || len(r.Spec.Ports) == 0) {
+		errs = append(errs, field.Required(specPath.Child("domains"), "at least one domain is required"))
+	}
+
+	for i, domain := range r.Spec.Domains {
+		for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(domain, "*.")) {
+			errs = append(errs, field.Invalid(specPath.Child("domains").Index(i), domain, msg))
+		}
+	}
+
+	paths := make(map[string]struct{}, len(r.Spec.Routes))
+	for i, route := range r.Spec.Routes {
+		routePath := specPath.Child("routes").Index(i)
+		if route.App == "" {
+			errs = append(errs, field.Required(routePath.Child("app"), ""))
+		}
+		if route.Port == 0 {
+			errs = append(errs, field.Required(routePath.Child("port"), ""))
+		}
+		if !strings.HasPrefix(route.Path, "/") {
+			errs = append(errs, field.Invalid(routePath.Child("path"), route.Path, "must start with /"))
+		}
+		if _, ok := paths[route.Path]; ok {
+			errs = append(errs, field.Duplicate(routePath.Child("path"), route.Path))
+		}
+		paths[route.Path] = struct{}{}
+
+		errs = append(errs, validateRouteBackends(route, routePath.Child("backends"))...)
+		errs = append(errs, validateRouteSettings(route, routePath)...)
+	}
+
+	if r.Spec.BasicAuth != nil && r.Spec.BasicAuth.Enabled && r.Spec.BasicAuth.Username == "" {
+		errs = append(errs, field.Required(specPath.Child("basicAuth", "username"), "must be set, when basic auth is enabled"))
+	}
+
+	if rl := r.Spec.RateLimit; rl != nil && rl.Enabled {
+		if rl.Rps < 0 || rl.Rpm < 0 || rl.Connections < 0 {
+			errs = append(errs, field.Invalid(specPath.Child("rateLimit"), rl, "rps, rpm and connections must not be negative"))
+		}
+	}
+
+	if r.Spec.MaxBodySizeInMB != nil && *r.Spec.MaxBodySizeInMB < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("maxBodySizeInMB"), *r.Spec.MaxBodySizeInMB, "must not be negative"))
+	}
+
+	if a := r.Spec.Auth; a != nil {
+		authPath := specPath.Child("auth")
+		if r.IsOIDCEnabled() && r.IsForwardAuthEnabled() {
+			errs = append(errs, field.Forbidden(authPath, "only one of oidc, or forwardAuth can be enabled"))
+		}
+		if (r.IsOIDCEnabled() || r.IsForwardAuthEnabled()) && r.Spec.BasicAuth != nil && r.Spec.BasicAuth.Enabled {
+			errs = append(errs, field.Forbidden(authPath, "must not be enabled, along with basic auth"))
+		}
+		if r.IsOIDCEnabled() && a.OIDC.SecretName == "" {
+			errs = append(errs, field.Required(authPath.Child("oidc", "secretName"), "must be set, when oidc is enabled"))
+		}
+		if r.IsForwardAuthEnabled() {
+			fa := a.ForwardAuth
+			if u, err := url.Parse(fa.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, field.Invalid(authPath.Child("forwardAuth", "url"), fa.URL, "must be an absolute http(s) url"))
+			}
+			if fa.SignInURL != "" {
+				if u, err := url.Parse(fa.SignInURL); err != nil || u.Scheme == "" || u.Host == "" {
+					errs = append(errs, field.Invalid(authPath.Child("forwardAuth", "signInURL"), fa.SignInURL, "must be an absolute url"))
+				}
+			}
+		}
+	}
+
+	if m := r.Spec.MTLS; m != nil && m.Enabled {
+		mPath := specPath.Child("mtls")
+		if (m.CASecretName == "") == (m.Issuer == "") {
+			errs = append(errs, field.Invalid(mPath, m, "exactly one of caSecretName, or issuer must be set"))
+		}
+		if m.VerifyDepth < 0 {
+			errs = append(errs, field.Invalid(mPath.Child("verifyDepth"), m.VerifyDepth, "must not be negative"))
+		}
+		// client certificates are only presented during a TLS handshake
+		if r.Spec.Https == nil || !r.Spec.Https.Enabled {
+			errs = append(errs, field.Forbidden(mPath, "requires https to be enabled"))
+		}
+	}
+
+	if bp := r.Spec.BackendProtocol; bp != nil {
+		supported := []string{BackendProtocolHTTP, BackendProtocolHTTPS, BackendProtocolGRPC, BackendProtocolGRPCS, BackendProtocolGRPCWeb, BackendProtocolAutoHTTP, BackendProtocolFCGI}
+		if !slices.Contains(supported, strings.ToUpper(*bp)) {
+			errs = append(errs, field.NotSupported(specPath.Child("backendProtocol"), *bp, supported))
+		}
+	}
+
+	errs = append(errs, validateRouterPorts(r.Spec.Ports, specPath.Child("ports"))...)
+
+	return errs
+}
+
+func validateRouteBackends(route Route, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if len(route.Backends) > 1 {
+		errs = append(errs, field.TooMany(fldPath, len(route.Backends), 1))
+	}
+
+	for i, b := range route.Backends {
+		bPath := fldPath.Index(i)
+		if b.App == "" {
+			errs = append(errs, field.Required(bPath.Child("app"), ""))
+		}
+		if b.Port == 0 {
+			errs = append(errs, field.Required(bPath.Child("port"), ""))
+		}
+		if b.Weight < 0 || b.Weight > 100 {
+			errs = append(errs, field.Invalid(bPath.Child("weight"), b.Weight, "must be between 0 and 100"))
+		}
+
+		m := b.Match
+		if m == nil {
+			if b.Weight == 0 {
+				errs = append(errs, field.Required(bPath.Child("match"), "backend, without a weight, must have a match condition"))
+			}
+			continue
+		}
+
+		mPath := bPath.Child("match")
+		if m.Header == "" && m.Cookie == "" {
+			errs = append(errs, field.Required(mPath, "either header, or cookie must be set"))
+		}
+		if m.Header == "" && (m.HeaderValue != "" || m.HeaderRegex != "") {
+			errs = append(errs, field.Required(mPath.Child("header"), "must be set, along with headerValue or headerRegex"))
+		}
+		if m.HeaderValue != "" && m.HeaderRegex != "" {
+			errs = append(errs, field.Forbidden(mPath.Child("headerRegex"), "must not be set, along with headerValue"))
+		}
+		if m.HeaderRegex != "" {
+			if _, err := regexp.Compile(m.HeaderRegex); err != nil {
+				errs = append(errs, field.Invalid(mPath.Child("headerRegex"), m.HeaderRegex, err.Error()))
+			}
+		}
+	}
+
+	return errs
+}
+
+// conditions, that nginx's proxy_next_upstream retries a request on
+var retryConditions = []string{"error", "timeout", "invalid_header", "http_500", "http_502", "http_503", "http_504", "http_403", "http_404", "http_429", "non_idempotent", "off"}
+
+func validateRouteSettings(route Route, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if rl := route.RateLimit; rl != nil && rl.Enabled {
+		if rl.Rps < 0 || rl.Rpm < 0 || rl.Connections < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("rateLimit"), rl, "rps, rpm and connections must not be negative"))
+		}
+	}
+
+	if route.MaxBodySizeInMB != nil && *route.MaxBodySizeInMB < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("maxBodySizeInMB"), *route.MaxBodySizeInMB, "must not be negative"))
+	}
+
+	if t := route.Timeouts; t != nil {
+		if t.Connect < 0 || t.Read < 0 || t.Send < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("timeouts"), t, "must not be negative"))
+		}
+	}
+
+	if rt := route.Retry; rt != nil {
+		for i, cond := range rt.On {
+			if !slices.Contains(retryConditions, cond) {
+				errs = append(errs, field.NotSupported(fldPath.Child("retry", "on").Index(i), cond, retryConditions))
+			}
+		}
+		if rt.Attempts < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("retry", "attempts"), rt.Attempts, "must not be negative"))
+		}
+		if rt.TimeoutInSeconds < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("retry", "timeoutInSeconds"), rt.TimeoutInSeconds, "must not be negative"))
+		}
+	}
+
+	validateIPs := func(ips []string, ipsPath *field.Path) {
+		for i, v := range ips {
+			if net.ParseIP(v) != nil {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(v); err != nil {
+				errs = append(errs, field.Invalid(ipsPath.Index(i), v, "must be an IP, or a CIDR"))
+			}
+		}
+	}
+	validateIPs(route.IPAllowList, fldPath.Child("ipAllowList"))
+	validateIPs(route.IPDenyList, fldPath.Child("ipDenyList"))
+
+	return errs
+}
+
+func validateRouterPorts(ports []RouterPort, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	seen := make(map[string]struct{}, len(ports))
+	for i, p := range ports {
+		pPath := fldPath.Index(i)
+		if p.Port == 0 {
+			errs = append(errs, field.Required(pPath.Child("port"), ""))
+		}
+		if p.Protocol != corev1.ProtocolTCP && p.Protocol != corev1.ProtocolUDP {
+			errs = append(errs, field.NotSupported(pPath.Child("protocol"), p.Protocol, []string{string(corev1.ProtocolTCP), string(corev1.ProtocolUDP)}))
+		}
+		if p.Service == "" {
+			errs = append(errs, field.Required(pPath.Child("service"), ""))
+		}
+		if p.ServicePort == 0 {
+			errs = append(errs, field.Required(pPath.Child("servicePort"), ""))
+		}
+
+		key := fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+		if _, ok := seen[key]; ok {
+			errs = append(errs, field.Duplicate(pPath.Child("port"), key))
+		}
+		seen[key] = struct{}{}
+	}
+
+	return errs
+}
+
+// validateRouteTargets ensures that every route points to a port, exposed by its app's services.
+// Apps, that do not exist yet, only result in a warning, as they might be created after the router
+func (v *routerValidator) validateRouteTargets(ctx context.Context, r *Router) (admission.Warnings, field.ErrorList) {
+	var warnings admission.Warnings
+	var errs field.ErrorList
+
+	type routeTarget struct {
+		app     string
+		port    uint16
+		fldPath *field.Path
+	}
+
+	var targets []routeTarget
+	for i, route := range r.Spec.Routes {
+		routePath := field.NewPath("spec", "routes").Index(i)
+		targets = append(targets, routeTarget{app: route.App, port: route.Port, fldPath: routePath.Child("port")})
+		for j, b := range route.Backends {
+			targets = append(targets, routeTarget{app: b.App, port: b.Port, fldPath: routePath.Child("backends").Index(j).Child("port")})
+		}
+	}
+
+	apps := map[string]*App{}
+	for _, t := range targets {
+		if t.app == "" {
+			continue
+		}
+
+		app, ok := apps[t.app]
+		if !ok {
+			app = &App{}
+			if err := v.client.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: t.app}, app); err != nil {
+				if !errors.IsNotFound(err) {
+					warnings = append(warnings, fmt.Sprintf("could not verify route to app %q, as %v", t.app, err))
+					continue
+				}
+				app = nil
+			}
+			apps[t.app] = app
+		}
+
+		if app == nil {
+			warnings = append(warnings, fmt.Sprintf("route points to app %q, which does not exist (yet)", t.app))
+			continue
+		}
+
+		hasPort := false
+		for _, svc := range app.Spec.Services {
+			if svc.Port == t.port {
+				hasPort = true
+				break
+			}
+		}
+
+		if !hasPort {
+			errs = append(errs, field.Invalid(t.fldPath, t.port, fmt.Sprintf("app %q does not expose a service on this port", t.app)))
+		}
+	}
+
+	return warnings, errs
+}
+
+func (v *routerValidator) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*Router)
+	if !ok {
+		return nil, fmt.Errorf("expected a Router, got %T", obj)
+	}
+
+	errs := r.validateSpec()
+	warnings, routeErrs := v.validateRouteTargets(ctx, r)
+	errs = append(errs, routeErrs...)
+
+	if r.IsGRPC() && len(r.Spec.Routes) > 0 && (r.Spec.Https == nil || !r.Spec.Https.Enabled) {
+		warnings = append(warnings, "gRPC clients need https, as ingress-nginx serves HTTP/2 only over TLS")
+	}
+
+	if len(errs) == 0 {
+		return warnings, nil
+	}
+	return warnings, errors.NewInvalid(GroupVersion.WithKind("Router").GroupKind(), r.Name, errs)
+}
+
+// ValidateCreate implements webhook.CustomValidator
+func (v *routerValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, obj)
+}
+
+// ValidateUpdate implements webhook.CustomValidator
+func (v *routerValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	// routers being deleted, or updated only in their metadata, and status, must not get stuck on their spec, or on its targets
+	if r, ok := newObj.(*Router); ok {
+		if r.DeletionTimestamp != nil {
+			return nil, nil
+		}
+		if old, ok := oldObj.(*Router); ok && equality.Semantic.DeepEqual(old.Spec, r.Spec) {
+			return nil, nil
+		}
+	}
+	return v.validate(ctx, newObj)
+}
+
+// ValidateDelete implements webhook.CustomValidator
+func (v *routerValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
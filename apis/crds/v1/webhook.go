@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+type defaultable interface {
+	runtime.Object
+	webhook.Defaulter
+}
+
+// ApplyDefaults sets defaults on obj, as its mutating webhook does, and tells whether that changed obj. Controllers apply them too, for
+// objects admitted while webhooks are not served, i.e. without --enable-webhooks
+func ApplyDefaults(obj defaultable) bool {
+	before := obj.DeepCopyObject()
+	obj.Default()
+	return !equality.Semantic.DeepEqual(before, obj)
+}
@@ -0,0 +1,524 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestAppValidate(t *testing.T) {
+	validContainer := AppContainer{Name: "main", Image: "nginx"}
+
+	tests := []struct {
+		name    string
+		spec    AppSpec
+		wantErr bool
+	}{
+		{
+			name: "valid app",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Services:   []AppSvc{{Port: 80}},
+				Hpa:        &HPA{Enabled: true, MinReplicas: 1, MaxReplicas: 3},
+			},
+		},
+		{
+			name:    "no containers",
+			spec:    AppSpec{},
+			wantErr: true,
+		},
+		{
+			name: "hpa min replicas greater than max replicas",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Hpa:        &HPA{Enabled: true, MinReplicas: 5, MaxReplicas: 3},
+			},
+			wantErr: true,
+		},
+		{
+			name: "disabled hpa is not validated",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Hpa:        &HPA{Enabled: false, MinReplicas: 5, MaxReplicas: 3},
+			},
+		},
+		{
+			name: "hpa with external metric",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Hpa:        &HPA{Enabled: true, MinReplicas: 1, MaxReplicas: 3, Metrics: []HPAMetric{{Type: HPAMetricTypeExternal, Name: "queue_lag", AverageValue: "100"}}},
+			},
+		},
+		{
+			name: "hpa object metric without router",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Hpa:        &HPA{Enabled: true, MinReplicas: 1, MaxReplicas: 3, Metrics: []HPAMetric{{Type: HPAMetricTypeObject, Name: "rps", Value: "100"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "hpa metric with both value and averageValue",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Hpa:        &HPA{Enabled: true, MinReplicas: 1, MaxReplicas: 3, Metrics: []HPAMetric{{Type: HPAMetricTypeExternal, Name: "queue_lag", Value: "10", AverageValue: "100"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "httpGet probe without httpGet",
+			spec: AppSpec{
+				Containers: []AppContainer{{Name: "main", Image: "nginx", ReadinessProbe: &Probe{Type: "httpGet"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate container names",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer, validContainer},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate service ports",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Services:   []AppSvc{{Port: 80}, {Port: 80}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "canary rollout",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Rollout:    &AppRollout{Strategy: AppRolloutStrategyCanary, Canary: &CanaryRollout{Steps: []CanaryStep{{Weight: 20}, {Weight: 100}}}},
+			},
+		},
+		{
+			name: "canary rollout without steps",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Rollout:    &AppRollout{Strategy: AppRolloutStrategyCanary},
+			},
+			wantErr: true,
+		},
+		{
+			name: "canary rollout with decreasing weights",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Rollout:    &AppRollout{Strategy: AppRolloutStrategyCanary, Canary: &CanaryRollout{Steps: []CanaryStep{{Weight: 50}, {Weight: 20}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "auto rollback with negative progress deadline",
+			spec: AppSpec{
+				Containers:   []AppContainer{validContainer},
+				AutoRollback: &AppAutoRollback{Enabled: true, ProgressDeadline: &metav1.Duration{Duration: -time.Minute}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "init containers and sidecars",
+			spec: AppSpec{
+				Containers:     []AppContainer{validContainer, {Name: "proxy", Image: "envoy", Role: AppContainerRoleSidecar}},
+				InitContainers: []AppContainer{{Name: "migrate", Image: "migrate"}},
+			},
+		},
+		{
+			name: "only sidecar containers",
+			spec: AppSpec{
+				Containers: []AppContainer{{Name: "proxy", Image: "envoy", Role: AppContainerRoleSidecar}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "init container named as a container",
+			spec: AppSpec{
+				Containers:     []AppContainer{validContainer},
+				InitContainers: []AppContainer{validContainer},
+			},
+			wantErr: true,
+		},
+		{
+			name: "init container with readiness probe",
+			spec: AppSpec{
+				Containers:     []AppContainer{validContainer},
+				InitContainers: []AppContainer{{Name: "migrate", Image: "migrate", ReadinessProbe: &Probe{Type: "tcp", Tcp: &TcpProbe{Port: 80}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "scale to zero with too short idle period",
+			spec: AppSpec{
+				Containers:  []AppContainer{validContainer},
+				ScaleToZero: &AppScaleToZero{Enabled: true, IdleAfter: &metav1.Duration{Duration: 10 * time.Second}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate volume names",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Volumes:    []AppVolume{{Name: "data", Storage: ct.Storage{Size: "1Gi"}}, {Name: "data", Storage: ct.Storage{Size: "2Gi"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "volume with invalid size",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Volumes:    []AppVolume{{Name: "data", Storage: ct.Storage{Size: "lots"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "rollout with per replica volumes",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Volumes:    []AppVolume{{Name: "data", Storage: ct.Storage{Size: "1Gi"}, PerReplica: true}},
+				Rollout:    &AppRollout{Strategy: AppRolloutStrategyBlueGreen},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown rollout strategy",
+			spec: AppSpec{
+				Containers: []AppContainer{validContainer},
+				Rollout:    &AppRollout{Strategy: "rolling"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{Spec: tt.spec}
+			app.Name = "sample"
+			app.Default()
+			_, err := app.ValidateCreate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouterValidateSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    RouterSpec
+		wantErr bool
+	}{
+		{
+			name: "valid router",
+			spec: RouterSpec{Domains: []string{"*.example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80}}},
+		},
+		{
+			name:    "no domains",
+			spec:    RouterSpec{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid domain",
+			spec:    RouterSpec{Domains: []string{"Example_Com"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate route path",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80}, {App: "api", Path: "/", Port: 80}}},
+			wantErr: true,
+		},
+		{
+			name:    "basic auth without username",
+			spec:    RouterSpec{Domains: []string{"example.com"}, BasicAuth: &BasicAuth{Enabled: true}},
+			wantErr: true,
+		},
+		{
+			name: "weighted backend, with header match",
+			spec: RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80, Backends: []RouteBackend{
+				{App: "web-v2", Port: 80, Weight: 10, Match: &RouteMatch{Header: "x-version", HeaderValue: "v2"}},
+			}}}},
+		},
+		{
+			name: "more than one backend",
+			spec: RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80, Backends: []RouteBackend{
+				{App: "web-v2", Port: 80, Weight: 10},
+				{App: "web-v3", Port: 80, Weight: 10},
+			}}}},
+			wantErr: true,
+		},
+		{
+			name:    "backend without weight, or match",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80, Backends: []RouteBackend{{App: "web-v2", Port: 80}}}}},
+			wantErr: true,
+		},
+		{
+			name:    "backend weight over 100",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80, Backends: []RouteBackend{{App: "web-v2", Port: 80, Weight: 120}}}}},
+			wantErr: true,
+		},
+		{
+			name: "header value, along with header regex",
+			spec: RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80, Backends: []RouteBackend{
+				{App: "web-v2", Port: 80, Match: &RouteMatch{Header: "x-version", HeaderValue: "v2", HeaderRegex: "^v2"}},
+			}}}},
+			wantErr: true,
+		},
+		{
+			name: "tcp ports only, without domains",
+			spec: RouterSpec{Ports: []RouterPort{{Port: 5432, Service: "pg", ServicePort: 5432}, {Port: 5432, Protocol: "UDP", Service: "dns", ServicePort: 53}}},
+		},
+		{
+			name:    "duplicate tcp port",
+			spec:    RouterSpec{Ports: []RouterPort{{Port: 6379, Service: "redis", ServicePort: 6379}, {Port: 6379, Service: "redis-2", ServicePort: 6379}}},
+			wantErr: true,
+		},
+		{
+			name:    "port without service",
+			spec:    RouterSpec{Ports: []RouterPort{{Port: 6379, ServicePort: 6379}}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported port protocol",
+			spec:    RouterSpec{Ports: []RouterPort{{Port: 6379, Protocol: "SCTP", Service: "redis", ServicePort: 6379}}},
+			wantErr: true,
+		},
+		{
+			name: "grpc backend protocol, in lower case",
+			spec: RouterSpec{Domains: []string{"example.com"}, BackendProtocol: fn.New("grpc")},
+		},
+		{
+			name:    "unsupported backend protocol",
+			spec:    RouterSpec{Domains: []string{"example.com"}, BackendProtocol: fn.New("websocket")},
+			wantErr: true,
+		},
+		{
+			name: "mtls with a CA secret",
+			spec: RouterSpec{Domains: []string{"example.com"}, Https: &Https{Enabled: true}, MTLS: &MTLS{Enabled: true, CASecretName: "clients-ca"}},
+		},
+		{
+			name:    "mtls with both CA secret, and issuer",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Https: &Https{Enabled: true}, MTLS: &MTLS{Enabled: true, CASecretName: "clients-ca", Issuer: "clients"}},
+			wantErr: true,
+		},
+		{
+			name:    "mtls without https",
+			spec:    RouterSpec{Domains: []string{"example.com"}, MTLS: &MTLS{Enabled: true, Issuer: "clients"}},
+			wantErr: true,
+		},
+		{
+			name: "oidc auth",
+			spec: RouterSpec{Domains: []string{"example.com"}, Auth: &RouterAuth{OIDC: &OIDCAuth{Enabled: true, SecretName: "sso"}}},
+		},
+		{
+			name:    "oidc auth without secret",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Auth: &RouterAuth{OIDC: &OIDCAuth{Enabled: true}}},
+			wantErr: true,
+		},
+		{
+			name:    "forward auth, along with basic auth",
+			spec:    RouterSpec{Domains: []string{"example.com"}, BasicAuth: &BasicAuth{Enabled: true, Username: "admin"}, Auth: &RouterAuth{ForwardAuth: &ForwardAuth{Enabled: true, URL: "http://auth.svc/verify"}}},
+			wantErr: true,
+		},
+		{
+			name:    "forward auth with relative url",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Auth: &RouterAuth{ForwardAuth: &ForwardAuth{Enabled: true, URL: "/verify"}}},
+			wantErr: true,
+		},
+		{
+			name: "route with its own settings",
+			spec: RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{
+				App: "api", Path: "/upload", Port: 80, MaxBodySizeInMB: fn.New(500),
+				Timeouts:    &RouteTimeouts{Read: 300, Send: 300},
+				Retry:       &RouteRetry{On: []string{"error", "http_502"}, Attempts: 3},
+				IPAllowList: []string{"10.0.0.0/8", "192.168.1.10"},
+			}}},
+		},
+		{
+			name:    "route with unknown retry condition",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "api", Path: "/", Port: 80, Retry: &RouteRetry{On: []string{"http_418"}}}}},
+			wantErr: true,
+		},
+		{
+			name:    "route with invalid ip deny list",
+			spec:    RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "api", Path: "/", Port: 80, IPDenyList: []string{"10.0.0.0/33"}}}},
+			wantErr: true,
+		},
+		{
+			name: "invalid header regex",
+			spec: RouterSpec{Domains: []string{"example.com"}, Routes: []Route{{App: "web", Path: "/", Port: 80, Backends: []RouteBackend{
+				{App: "web-v2", Port: 80, Match: &RouteMatch{Header: "x-version", HeaderRegex: "(v2"}},
+			}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Router{Spec: tt.spec}
+			r.Default()
+			if errs := r.validateSpec(); (len(errs) > 0) != tt.wantErr {
+				t.Errorf("validateSpec() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	// both specs are invalid, so that only updates, that get validated, fail
+	invalidApp := &App{ObjectMeta: metav1.ObjectMeta{Name: "sample"}}
+	invalidRouter := &Router{ObjectMeta: metav1.ObjectMeta{Name: "sample"}}
+
+	deleting := func(obj metav1.Object) {
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		obj.SetFinalizers([]string{"kloudlite.io/finalizer"})
+	}
+
+	tests := []struct {
+		name    string
+		update  func(obj metav1.Object)
+		wantErr bool
+	}{
+		{
+			name:   "metadata only update",
+			update: func(obj metav1.Object) { obj.SetLabels(map[string]string{"team": "web"}) },
+		},
+		{
+			name:   "update while being deleted",
+			update: deleting,
+		},
+		{
+			name: "spec update",
+			update: func(obj metav1.Object) {
+				switch o := obj.(type) {
+				case *App:
+					o.Spec.Replicas = 2
+				case *Router:
+					o.Spec.Domains = []string{"Example_Com"}
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := invalidApp.DeepCopy()
+			tt.update(app)
+			if _, err := app.ValidateUpdate(invalidApp); (err != nil) != tt.wantErr {
+				t.Errorf("App.ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			r := invalidRouter.DeepCopy()
+			tt.update(r)
+			if _, err := (&routerValidator{}).ValidateUpdate(context.TODO(), invalidRouter, r); (err != nil) != tt.wantErr {
+				t.Errorf("routerValidator.ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePerReplicaVolumesUpdate(t *testing.T) {
+	data := AppVolume{Name: "data", Storage: ct.Storage{Size: "1Gi", StorageClass: "standard"}, AccessMode: "ReadWriteOnce", PerReplica: true}
+	logs := AppVolume{Name: "logs", Storage: ct.Storage{Size: "1Gi"}, AccessMode: "ReadWriteOnce", PerReplica: true}
+	shared := AppVolume{Name: "shared", Storage: ct.Storage{Size: "1Gi"}, AccessMode: "ReadWriteMany"}
+
+	with := func(v AppVolume, update func(v *AppVolume)) AppVolume {
+		update(&v)
+		return v
+	}
+
+	tests := []struct {
+		name    string
+		old     []AppVolume
+		new     []AppVolume
+		wantErr bool
+	}{
+		{name: "unchanged", old: []AppVolume{data, shared}, new: []AppVolume{data, shared}},
+		{name: "same size, in other units", old: []AppVolume{data}, new: []AppVolume{with(data, func(v *AppVolume) { v.Size = "1024Mi" })}},
+		{name: "shared volume resized", old: []AppVolume{data, shared}, new: []AppVolume{data, with(shared, func(v *AppVolume) { v.Size = "5Gi" })}},
+		{name: "first per replica volume", old: []AppVolume{shared}, new: []AppVolume{shared, data}},
+		{name: "all per replica volumes removed", old: []AppVolume{data, logs}, new: nil},
+		{name: "per replica volume resized", old: []AppVolume{data}, new: []AppVolume{with(data, func(v *AppVolume) { v.Size = "5Gi" })}, wantErr: true},
+		{name: "storage class changed", old: []AppVolume{data}, new: []AppVolume{with(data, func(v *AppVolume) { v.StorageClass = "fast" })}, wantErr: true},
+		{name: "per replica volume added", old: []AppVolume{data}, new: []AppVolume{data, logs}, wantErr: true},
+		{name: "some per replica volumes removed", old: []AppVolume{data, logs}, new: []AppVolume{data}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := validatePerReplicaVolumesUpdate(tt.old, tt.new, field.NewPath("spec", "volumes")); (len(errs) > 0) != tt.wantErr {
+				t.Errorf("validatePerReplicaVolumesUpdate() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvironmentValidateCloneFrom(t *testing.T) {
+	seed := EnvironmentSeed{Name: "db", ManagedResourceName: "db", Snapshot: "s3://dumps/db.gz", Image: "mongo:6"}
+
+	tests := []struct {
+		name      string
+		cloneFrom *EnvironmentCloneFrom
+		wantErr   bool
+	}{
+		{
+			name:      "valid clone",
+			cloneFrom: &EnvironmentCloneFrom{EnvironmentName: "staging", Seeds: []EnvironmentSeed{seed}},
+		},
+		{
+			name:      "no source environment",
+			cloneFrom: &EnvironmentCloneFrom{},
+			wantErr:   true,
+		},
+		{
+			name:      "cloned from itself",
+			cloneFrom: &EnvironmentCloneFrom{EnvironmentName: "pr-42"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid domain template",
+			cloneFrom: &EnvironmentCloneFrom{EnvironmentName: "staging", DomainTemplate: "{{.Name"},
+			wantErr:   true,
+		},
+		{
+			name:      "duplicate seeds",
+			cloneFrom: &EnvironmentCloneFrom{EnvironmentName: "staging", Seeds: []EnvironmentSeed{seed, seed}},
+			wantErr:   true,
+		},
+		{
+			name:      "seed without snapshot",
+			cloneFrom: &EnvironmentCloneFrom{EnvironmentName: "staging", Seeds: []EnvironmentSeed{{Name: "db", ManagedResourceName: "db", Image: "mongo:6"}}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}, Spec: EnvironmentSpec{ProjectName: "sample", TargetNamespace: "sample-pr-42", CloneFrom: tt.cloneFrom}}
+			e.Default()
+			if errs := e.validate(); (len(errs) > 0) != tt.wantErr {
+				t.Errorf("validate() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	h := &HelmChart{ObjectMeta: metav1.ObjectMeta{Name: "redis"}}
+	if !ApplyDefaults(h) {
+		t.Fatal("ApplyDefaults() = false, while release name was defaulted")
+	}
+	if h.Spec.ReleaseName != "redis" {
+		t.Fatalf("ApplyDefaults() did not default release name, got %q", h.Spec.ReleaseName)
+	}
+	if ApplyDefaults(h) {
+		t.Fatal("ApplyDefaults() = true, on an already defaulted object")
+	}
+}
@@ -20,6 +20,7 @@ type ServiceSpec struct {
 	ReplicaCount int `json:"replicaCount"`
 
 	// Storage   ct.Storage   `json:"storage"`
+	// +kubebuilder:validation:XValidation:rule="has(self.storage) == has(oldSelf.storage) && (!has(self.storage) || self.storage == oldSelf.storage)",message="storage of elasticsearch services can not be changed, as it is not resized in place"
 	Resources ct.Resources `json:"resources"`
 
 	// +kubebuilder:default=true
@@ -0,0 +1,68 @@
+package v1
+
+import (
+	ct "github.com/kloudlite/operator/apis/common-types"
+	"github.com/kloudlite/operator/pkg/constants"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.databaseName",name=Database,type=string
+// +kubebuilder:printcolumn:JSONPath=".output.completedAt",name=Completed_At,type=date
+// +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/checks",name=Checks,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/resource\\.ready",name=Ready,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// Backup is a mongodump of a Database, streamed to an s3 compatible bucket
+type Backup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ct.BackupSpec `json:"spec"`
+	Status rApi.Status   `json:"status,omitempty"`
+
+	Output ct.BackupOutput `json:"output,omitempty"`
+}
+
+func (b *Backup) EnsureGVK() {
+	if b != nil {
+		b.SetGroupVersionKind(GroupVersion.WithKind("Backup"))
+	}
+}
+
+func (b *Backup) GetStatus() *rApi.Status {
+	return &b.Status
+}
+
+func (b *Backup) GetBackupSpec() *ct.BackupSpec {
+	return &b.Spec
+}
+
+func (b *Backup) GetBackupOutput() *ct.BackupOutput {
+	return &b.Output
+}
+
+func (b *Backup) GetEnsuredLabels() map[string]string {
+	return map[string]string{}
+}
+
+func (b *Backup) GetEnsuredAnnotations() map[string]string {
+	return map[string]string{
+		constants.AnnotationKeys.GroupVersionKind: GroupVersion.WithKind("Backup").String(),
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// BackupList contains a list of Backup
+type BackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Backup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Backup{}, &BackupList{})
+}
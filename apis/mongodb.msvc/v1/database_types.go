@@ -10,6 +10,9 @@ import (
 // DatabaseSpec defines the desired state of Database
 type DatabaseSpec struct {
 	MsvcRef ct.MsvcRef `json:"msvcRef"`
+
+	// Rotation rotates credentials of the database user, periodically
+	Rotation *ct.CredentialRotation `json:"rotation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -41,6 +44,10 @@ func (d *Database) GetStatus() *rApi.Status {
 	return &d.Status
 }
 
+func (d *Database) GetMsvcRef() ct.MsvcRef {
+	return d.Spec.MsvcRef
+}
+
 func (d *Database) GetEnsuredLabels() map[string]string {
 	return map[string]string{
 		constants.MsvcNameKey:      d.Spec.MsvcRef.Name,
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	ct "github.com/kloudlite/operator/apis/common-types"
+	"github.com/kloudlite/operator/pkg/constants"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.databaseName",name=Database,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.schedule",name=Schedule,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/checks",name=Checks,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/resource\\.ready",name=Ready,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// BackupSchedule creates Backups of a Database, on a cron schedule, and prunes them, as per its retention
+type BackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ct.BackupScheduleSpec `json:"spec"`
+	Status rApi.Status           `json:"status,omitempty"`
+}
+
+func (b *BackupSchedule) EnsureGVK() {
+	if b != nil {
+		b.SetGroupVersionKind(GroupVersion.WithKind("BackupSchedule"))
+	}
+}
+
+func (b *BackupSchedule) GetStatus() *rApi.Status {
+	return &b.Status
+}
+
+func (b *BackupSchedule) GetScheduleSpec() *ct.BackupScheduleSpec {
+	return &b.Spec
+}
+
+func (b *BackupSchedule) GetEnsuredLabels() map[string]string {
+	return map[string]string{}
+}
+
+func (b *BackupSchedule) GetEnsuredAnnotations() map[string]string {
+	return map[string]string{
+		constants.AnnotationKeys.GroupVersionKind: GroupVersion.WithKind("BackupSchedule").String(),
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// BackupScheduleList contains a list of BackupSchedule
+type BackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BackupSchedule{}, &BackupScheduleList{})
+}
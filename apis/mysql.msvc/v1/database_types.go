@@ -11,6 +11,9 @@ import (
 type DatabaseSpec struct {
 	MsvcRef      ct.MsvcRef `json:"msvcRef"`
 	ResourceName string     `json:"resourceName"`
+
+	// Rotation rotates credentials of the database user, periodically
+	Rotation *ct.CredentialRotation `json:"rotation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -37,6 +40,10 @@ func (db *Database) GetStatus() *rApi.Status {
 	return &db.Status
 }
 
+func (db *Database) GetMsvcRef() ct.MsvcRef {
+	return db.Spec.MsvcRef
+}
+
 func (db *Database) GetEnsuredLabels() map[string]string {
 	return map[string]string{
 		constants.MsvcNameKey:      db.Spec.MsvcRef.Name,
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	ct "github.com/kloudlite/operator/apis/common-types"
+	"github.com/kloudlite/operator/pkg/constants"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.databaseName",name=Database,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/checks",name=Checks,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.annotations.kloudlite\\.io\\/resource\\.ready",name=Ready,type=string
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// Restore restores a Backup into a new, or an existing Database
+type Restore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ct.RestoreSpec `json:"spec"`
+	Status rApi.Status    `json:"status,omitempty"`
+}
+
+func (r *Restore) EnsureGVK() {
+	if r != nil {
+		r.SetGroupVersionKind(GroupVersion.WithKind("Restore"))
+	}
+}
+
+func (r *Restore) GetStatus() *rApi.Status {
+	return &r.Status
+}
+
+func (r *Restore) GetRestoreSpec() *ct.RestoreSpec {
+	return &r.Spec
+}
+
+func (r *Restore) GetEnsuredLabels() map[string]string {
+	return map[string]string{}
+}
+
+func (r *Restore) GetEnsuredAnnotations() map[string]string {
+	return map[string]string{
+		constants.AnnotationKeys.GroupVersionKind: GroupVersion.WithKind("Restore").String(),
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// RestoreList contains a list of Restore
+type RestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Restore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Restore{}, &RestoreList{})
+}
@@ -15,6 +15,9 @@ type ACLAccountSpec struct {
 	KeyPrefix    string     `json:"keyPrefix,omitempty"`
 	MsvcRef      ct.MsvcRef `json:"msvcRef"`
 	ResourceName string     `json:"resourceName,omitempty"`
+
+	// Rotation rotates credentials of the acl user, periodically
+	Rotation *ct.CredentialRotation `json:"rotation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -6,6 +6,7 @@
 package v1
 
 import (
+	"github.com/kloudlite/operator/apis/common-types"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -14,7 +15,7 @@ func (in *ACLAccount) DeepCopyInto(out *ACLAccount) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -72,6 +73,11 @@ func (in *ACLAccountList) DeepCopyObject() runtime.Object {
 func (in *ACLAccountSpec) DeepCopyInto(out *ACLAccountSpec) {
 	*out = *in
 	out.MsvcRef = in.MsvcRef
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(common_types.CredentialRotation)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACLAccountSpec.
@@ -16,6 +16,7 @@ type ServiceSpec struct {
 	// +kubebuilder:default=1
 	ReplicaCount int `json:"replicaCount,omitempty"`
 	// Inputs    rawJson.KubeRawJson `json:"inputs,omitempty"`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="storage of redpanda services can not be changed, as it is not resized in place"
 	Storage   ct.Storage   `json:"storage"`
 	Resources ct.Resources `json:"resources"`
 }
@@ -15,8 +15,10 @@ type ServiceSpec struct {
 
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default=1
-	ReplicaCount int          `json:"replicaCount,omitempty"`
-	Resources    ct.Resources `json:"resources"`
+	ReplicaCount int `json:"replicaCount,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="has(self.storage) == has(oldSelf.storage) && (!has(self.storage) || self.storage == oldSelf.storage)",message="storage of zookeeper services can not be changed, as it is not resized in place"
+	Resources ct.Resources `json:"resources"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+// name of the App controller's check, that passes once app's deployment is ready
+const deploymentReadyCheck = "deployment-ready"
+
+type target struct {
+	namespace string
+	app       string
+	path      string
+}
+
+// activator serves every app with scale to zero enabled. It records requests on the app, so that the App controller knows when it turns idle,
+// and holds requests to an idle app, until the App controller has scaled it back up
+type activator struct {
+	client            client.Client
+	clusterDNSSuffix  string
+	waitTimeout       time.Duration
+	reportInterval    time.Duration
+	readinessInterval time.Duration
+
+	mu sync.RWMutex
+	// routes, keyed by host and port, that requests arrive on
+	routes    map[string][]target
+	listeners map[uint16]struct{}
+	reported  map[string]time.Time
+	proxies   map[string]*httputil.ReverseProxy
+}
+
+func routeKey(host string, port uint16) string {
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// refresh rebuilds routes from routers, that point to apps with scale to zero, and starts listening on ports of those apps
+func (a *activator) refresh(ctx context.Context) error {
+	var apps crdsv1.AppList
+	if err := a.client.List(ctx, &apps); err != nil {
+		return err
+	}
+
+	scaleToZero := make(map[string]struct{}, len(apps.Items))
+	ports := map[uint16]struct{}{}
+	routes := map[string][]target{}
+	for i := range apps.Items {
+		app := &apps.Items[i]
+		if app.Spec.ScaleToZero == nil || !app.Spec.ScaleToZero.Enabled {
+			continue
+		}
+		scaleToZero[fn.NN(app.Namespace, app.Name).String()] = struct{}{}
+		for _, svc := range app.Spec.Services {
+			ports[svc.Port] = struct{}{}
+			// in cluster clients reach an idle app through its service, which points to activator
+			for _, host := range a.serviceHosts(app) {
+				key := routeKey(host, svc.Port)
+				routes[key] = append(routes[key], target{namespace: app.Namespace, app: app.Name})
+			}
+		}
+	}
+
+	var routers crdsv1.RouterList
+	if err := a.client.List(ctx, &routers); err != nil {
+		return err
+	}
+
+	for i := range routers.Items {
+		router := &routers.Items[i]
+		for _, route := range router.Spec.Routes {
+			backends := append([]crdsv1.RouteBackend{{App: route.App, Port: route.Port}}, route.Backends...)
+			for _, b := range backends {
+				if _, ok := scaleToZero[fn.NN(router.Namespace, b.App).String()]; !ok {
+					continue
+				}
+				for _, domain := range router.Spec.Domains {
+					key := routeKey(domain, b.Port)
+					routes[key] = append(routes[key], target{namespace: router.Namespace, app: b.App, path: route.Path})
+				}
+			}
+		}
+	}
+
+	a.mu.Lock()
+	a.routes = routes
+	a.mu.Unlock()
+
+	for port := range ports {
+		a.listen(port)
+	}
+	return nil
+}
+
+func (a *activator) listen(port uint16) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.listeners[port]; ok {
+		return
+	}
+
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Printf("failed to listen on port %d: %v\n", port, err)
+		return
+	}
+	a.listeners[port] = struct{}{}
+
+	log.Printf("listening on port %d\n", port)
+	go func() {
+		log.Printf("stopped listening on port %d: %v\n", port, http.Serve(l, a.handler(port)))
+	}()
+}
+
+// serviceHosts are hosts, that requests sent to app's service carry
+func (a *activator) serviceHosts(app *crdsv1.App) []string {
+	svc := fmt.Sprintf("%s.%s.svc", app.Name, app.Namespace)
+	return []string{fmt.Sprintf("%s.%s", app.Name, app.Namespace), svc, fmt.Sprintf("%s.%s", svc, a.clusterDNSSuffix)}
+}
+
+// resolve picks the app, whose route has the longest path prefix matching the request. Requests, sent to an app's service, match the
+// app on any path
+func (a *activator) resolve(host string, port uint16, path string) (*target, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var match *target
+	for i, t := range a.routes[routeKey(host, port)] {
+		if !strings.HasPrefix(path, t.path) {
+			continue
+		}
+		if match == nil || len(t.path) > len(match.path) {
+			match = &a.routes[routeKey(host, port)][i]
+		}
+	}
+
+	// rewritten routes lose their prefix before reaching here, in which case the only app served on that host and port is picked
+	if match == nil && len(a.routes[routeKey(host, port)]) == 1 {
+		match = &a.routes[routeKey(host, port)][0]
+	}
+	return match, match != nil
+}
+
+func isAwake(app *crdsv1.App) bool {
+	if app.Status.IdleSince != nil {
+		return false
+	}
+	check, ok := app.Status.Checks[deploymentReadyCheck]
+	return ok && check.Status && check.Generation == app.Generation
+}
+
+// recordRequest stamps app with time of the request. It is done at most once every report interval, unless app is idle, where it wakes the app up
+func (a *activator) recordRequest(ctx context.Context, app *crdsv1.App) error {
+	key := fn.NN(app.Namespace, app.Name).String()
+	now := time.Now()
+
+	a.mu.RLock()
+	last, ok := a.reported[key]
+	a.mu.RUnlock()
+	if app.Status.IdleSince == nil && ok && now.Sub(last) < a.reportInterval {
+		return nil
+	}
+
+	patch := client.MergeFrom(app.DeepCopy())
+	ann := app.GetAnnotations()
+	if ann == nil {
+		ann = map[string]string{}
+	}
+	ann[constants.AppLastRequestAtKey] = now.UTC().Format(time.RFC3339)
+	app.SetAnnotations(ann)
+	if err := a.client.Patch(ctx, app, patch); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.reported[key] = now
+	a.mu.Unlock()
+	return nil
+}
+
+// waitUntilAwake polls app, until App controller reports its deployment as ready
+func (a *activator) waitUntilAwake(ctx context.Context, nn types.NamespacedName, app *crdsv1.App) error {
+	ctx, cancel := context.WithTimeout(ctx, a.waitTimeout)
+	defer cancel()
+
+	for {
+		if isAwake(app) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("app %s did not get ready within %s", nn, a.waitTimeout)
+		case <-time.After(a.readinessInterval):
+		}
+
+		if err := a.client.Get(ctx, nn, app); err != nil {
+			return err
+		}
+	}
+}
+
+func (a *activator) proxy(t *target, port uint16) *httputil.ReverseProxy {
+	remote := fmt.Sprintf("http://%s-internal.%s.svc.%s:%d", t.app, t.namespace, a.clusterDNSSuffix, port)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if p, ok := a.proxies[remote]; ok {
+		return p
+	}
+
+	u, _ := url.Parse(remote)
+	p := httputil.NewSingleHostReverseProxy(u)
+	a.proxies[remote] = p
+	return p
+}
+
+func (a *activator) handler(port uint16) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t, ok := a.resolve(req.Host, port, req.URL.Path)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no app, with scale to zero, is served on %s", routeKey(req.Host, port)), http.StatusNotFound)
+			return
+		}
+
+		nn := fn.NN(t.namespace, t.app)
+		var app crdsv1.App
+		if err := a.client.Get(req.Context(), nn, &app); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		if err := a.recordRequest(req.Context(), &app); err != nil {
+			log.Printf("failed to record request on app %s: %v\n", nn, err)
+		}
+
+		if !isAwake(&app) {
+			log.Printf("holding request for app %s, until it is ready\n", nn)
+			if err := a.waitUntilAwake(req.Context(), nn, &app); err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		a.proxy(t, port).ServeHTTP(w, req)
+	})
+}
+
+func main() {
+	var healthAddr string
+	var clusterDNSSuffix string
+	var waitTimeout time.Duration
+	var reportInterval time.Duration
+	var refreshInterval time.Duration
+
+	// apps are served on their own service ports, so health server sits on a port, that apps are unlikely to use
+	flag.StringVar(&healthAddr, "health-addr", ":18081", "--health-addr <host:port>")
+	flag.StringVar(&clusterDNSSuffix, "cluster-dns-suffix", "cluster.local", "--cluster-dns-suffix <suffix>")
+	flag.DurationVar(&waitTimeout, "wait-timeout", 2*time.Minute, "--wait-timeout <duration>, for an idle app to get ready")
+	flag.DurationVar(&reportInterval, "report-interval", 1*time.Minute, "--report-interval <duration>, between recording requests on a running app")
+	flag.DurationVar(&refreshInterval, "refresh-interval", 5*time.Second, "--refresh-interval <duration>, between rebuilding routes")
+	flag.Parse()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		log.Fatalln(err)
+	}
+	if err := crdsv1.AddToScheme(scheme); err != nil {
+		log.Fatalln(err)
+	}
+
+	cl, err := cluster.New(ctrl.GetConfigOrDie(), func(o *cluster.Options) {
+		o.Scheme = scheme
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+	go func() {
+		if err := cl.Start(ctx); err != nil {
+			log.Fatalln(err)
+		}
+	}()
+
+	if !cl.GetCache().WaitForCacheSync(ctx) {
+		log.Fatalln("failed to sync cache")
+	}
+
+	a := &activator{
+		client:            cl.GetClient(),
+		clusterDNSSuffix:  clusterDNSSuffix,
+		waitTimeout:       waitTimeout,
+		reportInterval:    reportInterval,
+		readinessInterval: 500 * time.Millisecond,
+		routes:            map[string][]target{},
+		listeners:         map[uint16]struct{}{},
+		reported:          map[string]time.Time{},
+		proxies:           map[string]*httputil.ReverseProxy{},
+	}
+
+	go func() {
+		for {
+			if err := a.refresh(ctx); err != nil {
+				log.Printf("failed to refresh routes: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(refreshInterval):
+			}
+		}
+	}()
+
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	fmt.Printf("starting health server on %s ...\n", healthAddr)
+	log.Fatalln(http.ListenAndServe(healthAddr, nil))
+}
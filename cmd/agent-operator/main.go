@@ -3,10 +3,13 @@ package main
 import (
 	"github.com/kloudlite/operator/operator"
 
+	agent "github.com/kloudlite/operator/operators/agent/controller"
+
 	app "github.com/kloudlite/operator/operators/app-n-lambda/controller"
 	helmCharts "github.com/kloudlite/operator/operators/helm-charts/controller"
 	msvcMongo "github.com/kloudlite/operator/operators/msvc-mongo/controller"
 	msvcAndMres "github.com/kloudlite/operator/operators/msvc-n-mres/controller"
+	msvcPostgres "github.com/kloudlite/operator/operators/msvc-postgres/controller"
 
 	distribution "github.com/kloudlite/operator/operators/distribution/controller"
 	msvcRedis "github.com/kloudlite/operator/operators/msvc-redis/controller"
@@ -29,6 +32,7 @@ func main() {
 	// kloudlite managed services
 	msvcAndMres.RegisterInto(mgr)
 	msvcMongo.RegisterInto(mgr)
+	msvcPostgres.RegisterInto(mgr)
 	msvcRedis.RegisterInto(mgr)
 
 	// kloudlite cluster management
@@ -41,5 +45,8 @@ func main() {
 
 	wireguard.RegisterInto(mgr)
 
+	// kloudlite actions, pushed from platform
+	agent.RegisterInto(mgr)
+
 	mgr.Start()
 }
@@ -8,6 +8,7 @@ import (
 	job "github.com/kloudlite/operator/operators/job/controller"
 	msvcMongo "github.com/kloudlite/operator/operators/msvc-mongo/controller"
 	msvcAndMres "github.com/kloudlite/operator/operators/msvc-n-mres/controller"
+	msvcPostgres "github.com/kloudlite/operator/operators/msvc-postgres/controller"
 	msvcRedis "github.com/kloudlite/operator/operators/msvc-redis/controller"
 	nodepool "github.com/kloudlite/operator/operators/nodepool/controller"
 	project "github.com/kloudlite/operator/operators/project/controller"
@@ -26,6 +27,7 @@ func main() {
 
 	// kloudlite managed services
 	msvcMongo.RegisterInto(mgr)
+	msvcPostgres.RegisterInto(mgr)
 	msvcRedis.RegisterInto(mgr)
 	msvcAndMres.RegisterInto(mgr)
 
@@ -39,6 +39,7 @@ type Operator interface {
 }
 
 type operator struct {
+	name       string
 	mgrConfig  *rest.Config
 	mgrOptions ctrl.Options
 
@@ -47,11 +48,12 @@ type operator struct {
 
 	registeredControllers map[string]struct{}
 
-	Logger        logging.Logger
-	IsDev         bool
-	schemesAdded  bool
-	Scheme        *runtime.Scheme
-	k8sYamlClient kubectl.YAMLClient
+	Logger         logging.Logger
+	IsDev          bool
+	enableWebhooks bool
+	schemesAdded   bool
+	Scheme         *runtime.Scheme
+	k8sYamlClient  kubectl.YAMLClient
 }
 
 func New(name string) Operator {
@@ -60,6 +62,7 @@ func New(name string) Operator {
 	var probeAddr string
 	var isDev bool
 	var devServerHost string
+	var enableWebhooks bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":12345", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":12346", "The address the probe endpoint binds to.")
@@ -77,6 +80,7 @@ func New(name string) Operator {
 
 	flag.BoolVar(&isDev, "dev", false, "--dev")
 	flag.StringVar(&devServerHost, "serverHost", "localhost:8080", "--serverHost <host:port>")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "serves admission webhooks, requires serving certs to be mounted at /tmp/k8s-webhook-server/serving-certs")
 	// flag.BoolVar(&isAllEnabled, "all", true, "--all")
 	flag.Parse()
 
@@ -101,7 +105,7 @@ func New(name string) Operator {
 			return &rest.Config{Host: devServerHost}, cOpts
 		}
 
-		// cOpts.MetricsBindAddress = metricsAddr
+		cOpts.Metrics.BindAddress = metricsAddr
 		cOpts.HealthProbeBindAddress = probeAddr
 		return ctrl.GetConfigOrDie(), cOpts
 	}()
@@ -112,11 +116,13 @@ func New(name string) Operator {
 	}
 
 	return &operator{
-		mgrConfig:     mgrConfig,
-		mgrOptions:    mgrOptions,
-		Logger:        logger,
-		IsDev:         isDev,
-		k8sYamlClient: k8sYamlClient,
+		name:           name,
+		mgrConfig:      mgrConfig,
+		mgrOptions:     mgrOptions,
+		Logger:         logger,
+		IsDev:          isDev,
+		enableWebhooks: enableWebhooks,
+		k8sYamlClient:  k8sYamlClient,
 	}
 }
 
@@ -162,9 +168,9 @@ func (op *operator) RegisterWebhooks(types ...WebhookEnabledType) {
 	for i := range types {
 		webhookType := types[i]
 		op.webhooks = append(op.webhooks, func(mgr manager.Manager) {
-			setupLog.Info("registering webhook", "for", webhookType.GetName())
+			setupLog.Info("registering webhook", "for", fmt.Sprintf("%T", webhookType))
 			if err := webhookType.SetupWebhookWithManager(mgr); err != nil {
-				setupLog.Error(err, "unable to create webhook", "webhook", types[i].GetName())
+				setupLog.Error(err, "unable to create webhook", "webhook", fmt.Sprintf("%T", webhookType))
 				os.Exit(1)
 			}
 		})
@@ -175,6 +181,10 @@ func (op *operator) Operator() *operator {
 	return op
 }
 
+func (op *operator) YAMLClient() kubectl.YAMLClient {
+	return op.k8sYamlClient
+}
+
 func (op *operator) Start() {
 	op.Logger.Infof("starting manager")
 
@@ -187,8 +197,10 @@ func (op *operator) Start() {
 		op.controllers[i](mgr)
 	}
 
-	for i := range op.webhooks {
-		op.webhooks[i](mgr)
+	if op.enableWebhooks {
+		for i := range op.webhooks {
+			op.webhooks[i](mgr)
+		}
 	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -202,7 +214,10 @@ func (op *operator) Start() {
 	}
 
 	common.PrintReadyBanner()
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	// controllers reconcile with contexts derived from this one, so every rApi.Request emits events on check state transitions, with
+	// the manager's recorder
+	ctx := rApi.WithEventRecorder(ctrl.SetupSignalHandler(), mgr.GetEventRecorderFor(fmt.Sprintf("kloudlite-%s", op.name)))
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		panic(err)
 	}
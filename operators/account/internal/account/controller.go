@@ -41,7 +41,7 @@ const (
 // +kubebuilder:rbac:groups=crds.kloudlite.io,resources=accounts/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &crdsv1.Account{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &crdsv1.Account{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/kloudlite/operator/operator"
+	"github.com/kloudlite/operator/operators/agent/internal/actions"
+	"github.com/kloudlite/operator/operators/agent/internal/env"
+	libGrpc "github.com/kloudlite/operator/pkg/grpc"
+	"github.com/kloudlite/operator/pkg/logging"
+	"google.golang.org/grpc/connectivity"
+)
+
+func RegisterInto(mgr operator.Operator) {
+	ev := env.GetEnvOrDie()
+
+	logger := logging.NewOrDie(&logging.Options{Name: "agent", Dev: mgr.Operator().IsDev})
+
+	handler := actions.NewHandler(mgr.Operator().YAMLClient(), logger)
+
+	consumeActions := func(logger logging.Logger) error {
+		logger.Infof("connecting to addr: %s", ev.GrpcAddr)
+
+		cc, err := libGrpc.Connect(ev.GrpcAddr, libGrpc.ConnectOpts{
+			SecureConnect: ev.GrpcSecureConnect,
+			Timeout:       5 * time.Second,
+		})
+		if err != nil {
+			logger.Infof("failed to connect to grpc addr: %s", ev.GrpcAddr)
+			return err
+		}
+
+		defer func() {
+			cc.Close()
+			logger.Infof("closed grpc connection")
+		}()
+
+		for {
+			connState := cc.GetState()
+			logger.Infof("waiting for connection to become %s, current: %s", connectivity.Ready, connState.String())
+			if connState == connectivity.Ready {
+				break
+			}
+			<-time.After(2 * time.Second)
+		}
+
+		consumer := actions.NewConsumer(cc, handler, ev, logger)
+
+		ctx, cf := context.WithTimeout(context.TODO(), 2*time.Second)
+		defer cf()
+		if err := consumer.ValidateAccessToken(ctx); err != nil {
+			logger.Infof("failed to validate access token: %v", err)
+			return err
+		}
+
+		return consumer.Consume(context.TODO())
+	}
+
+	go func() {
+		logger := logger.WithKV("component", "actions-consumer")
+		for {
+			if err := consumeActions(logger); err != nil {
+				logger.Errorf(err, "actions stream broke, reconnecting")
+			}
+			<-time.After(2 * time.Second)
+		}
+	}()
+}
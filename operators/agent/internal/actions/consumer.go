@@ -0,0 +1,123 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/kloudlite/operator/grpc-interfaces/grpc/messages"
+	"github.com/kloudlite/operator/operators/agent/internal/env"
+	t "github.com/kloudlite/operator/operators/agent/types"
+	"github.com/kloudlite/operator/pkg/errors"
+	"github.com/kloudlite/operator/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type Consumer struct {
+	handler        *Handler
+	ev             *env.Env
+	logger         logging.Logger
+	msgDispatchCli messages.MessageDispatchServiceClient
+}
+
+func (c *Consumer) withAuth(ctx context.Context) context.Context {
+	return metadata.NewOutgoingContext(ctx, metadata.Pairs(
+		"authorization", c.ev.AccessToken,
+		"accountName", c.ev.AccountName,
+		"clusterName", c.ev.ClusterName,
+	))
+}
+
+// ValidateAccessToken ensures that message office api accepts agent's access token, before any actions are consumed
+func (c *Consumer) ValidateAccessToken(ctx context.Context) error {
+	out, err := c.msgDispatchCli.ValidateAccessToken(ctx, &messages.ValidateAccessTokenIn{
+		AccountName: c.ev.AccountName,
+		ClusterName: c.ev.ClusterName,
+		AccessToken: c.ev.AccessToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	if out == nil || !out.Valid {
+		return errors.Newf("accessToken is invalid, aborting")
+	}
+	return nil
+}
+
+// Consume blocks, and processes actions one by one in the order they are received, until the stream breaks
+func (c *Consumer) Consume(ctx context.Context) error {
+	stream, err := c.msgDispatchCli.SendActions(c.withAuth(ctx), &messages.Empty{})
+	if err != nil {
+		return errors.NewEf(err, "could not open SendActions stream")
+	}
+
+	c.logger.Infof("listening for actions from message office api")
+
+	for {
+		action, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				c.logger.Infof("SendActions stream closed by server")
+				return nil
+			}
+			return err
+		}
+
+		c.process(ctx, action)
+	}
+}
+
+func (c *Consumer) process(ctx context.Context, action *messages.Action) {
+	msg, err := ParseAgentMessage(action.Message)
+	if err == nil {
+		err = c.handler.Handle(ctx, msg)
+	}
+
+	if err != nil {
+		c.logger.Errorf(err, "failed to process action")
+		if rerr := c.reportError(ctx, msg, err); rerr != nil {
+			c.logger.Errorf(rerr, "failed to report error to message office api")
+		}
+	}
+}
+
+func (c *Consumer) reportError(ctx context.Context, msg *t.AgentMessage, err error) error {
+	errMsg := t.AgentErrMessage{
+		AccountName: c.ev.AccountName,
+		ClusterName: c.ev.ClusterName,
+		Error:       err.Error(),
+	}
+
+	if msg != nil {
+		errMsg.Action = msg.Action
+		errMsg.Object = msg.Object
+	}
+
+	b, err := json.Marshal(errMsg)
+	if err != nil {
+		return err
+	}
+
+	tctx, cf := context.WithTimeout(ctx, 2*time.Second)
+	defer cf()
+
+	_, err = c.msgDispatchCli.ReceiveError(tctx, &messages.ErrorData{
+		AccountName: c.ev.AccountName,
+		ClusterName: c.ev.ClusterName,
+		AccessToken: c.ev.AccessToken,
+		Message:     b,
+	})
+	return err
+}
+
+func NewConsumer(cc *grpc.ClientConn, handler *Handler, ev *env.Env, logger logging.Logger) *Consumer {
+	return &Consumer{
+		handler:        handler,
+		ev:             ev,
+		logger:         logger,
+		msgDispatchCli: messages.NewMessageDispatchServiceClient(cc),
+	}
+}
@@ -0,0 +1,101 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	t "github.com/kloudlite/operator/operators/agent/types"
+	"github.com/kloudlite/operator/pkg/constants"
+	"github.com/kloudlite/operator/pkg/errors"
+	"github.com/kloudlite/operator/pkg/kubectl"
+	"github.com/kloudlite/operator/pkg/logging"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Handler struct {
+	yamlClient kubectl.YAMLClient
+	logger     logging.Logger
+}
+
+// native resources, that kloudlite resources depend upon, and are allowed to be managed via agent
+var allowedNativeKinds = map[schema.GroupKind]struct{}{
+	{Group: "", Kind: "ConfigMap"}: {},
+	{Group: "", Kind: "Secret"}:    {},
+}
+
+func isAllowed(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group == constants.KloudliteLabelPrefix || strings.HasSuffix(gvk.Group, "."+constants.KloudliteLabelPrefix) {
+		return true
+	}
+	_, ok := allowedNativeKinds[gvk.GroupKind()]
+	return ok
+}
+
+func ParseAgentMessage(b []byte) (*t.AgentMessage, error) {
+	var msg t.AgentMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return nil, errors.NewEf(err, "could not unmarshal action message")
+	}
+
+	if msg.Action != t.ActionApply && msg.Action != t.ActionDelete {
+		return &msg, errors.Newf("unknown action (%q), must be one of [%s, %s]", msg.Action, t.ActionApply, t.ActionDelete)
+	}
+
+	if len(msg.Object) == 0 {
+		return &msg, errors.Newf("action message has no object")
+	}
+
+	obj := unstructured.Unstructured{Object: msg.Object}
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+		return &msg, errors.Newf("object must have apiVersion, kind and metadata.name")
+	}
+
+	if !isAllowed(&obj) {
+		return &msg, errors.Newf("resource of gvk (%s) is not managed by kloudlite agent", obj.GroupVersionKind().String())
+	}
+
+	return &msg, nil
+}
+
+func (h *Handler) Handle(ctx context.Context, msg *t.AgentMessage) error {
+	obj := unstructured.Unstructured{Object: msg.Object}
+
+	logger := h.logger.WithKV("action", msg.Action).WithKV("gvk", obj.GroupVersionKind().String()).WithKV("NN", fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName()))
+
+	b, err := json.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+
+	switch msg.Action {
+	case t.ActionApply:
+		{
+			if _, err := h.yamlClient.ApplyYAML(ctx, b); err != nil {
+				return err
+			}
+		}
+	case t.ActionDelete:
+		{
+			if err := h.yamlClient.DeleteYAML(ctx, b); err != nil {
+				if !apiErrors.IsNotFound(err) {
+					return err
+				}
+				logger.Infof("resource does not exist, nothing to delete")
+			}
+		}
+	default:
+		return errors.Newf("unknown action (%q)", msg.Action)
+	}
+
+	logger.Infof("processed action")
+	return nil
+}
+
+func NewHandler(yamlClient kubectl.YAMLClient, logger logging.Logger) *Handler {
+	return &Handler{yamlClient: yamlClient, logger: logger}
+}
@@ -0,0 +1,56 @@
+package actions
+
+import (
+	"testing"
+)
+
+func TestParseAgentMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		wantErr bool
+	}{
+		{
+			name:    "1. apply on a kloudlite resource",
+			msg:     `{"action": "apply", "object": {"apiVersion": "crds.kloudlite.io/v1", "kind": "App", "metadata": {"name": "sample", "namespace": "sample"}}}`,
+			wantErr: false,
+		},
+		{
+			name:    "2. delete on a config map",
+			msg:     `{"action": "delete", "object": {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "sample", "namespace": "sample"}}}`,
+			wantErr: false,
+		},
+		{
+			name:    "3. unknown action",
+			msg:     `{"action": "restart", "object": {"apiVersion": "crds.kloudlite.io/v1", "kind": "App", "metadata": {"name": "sample"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "4. non kloudlite resource",
+			msg:     `{"action": "apply", "object": {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "sample"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "5. group merely suffixed with kloudlite.io",
+			msg:     `{"action": "apply", "object": {"apiVersion": "evilkloudlite.io/v1", "kind": "App", "metadata": {"name": "sample"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "6. object without name",
+			msg:     `{"action": "apply", "object": {"apiVersion": "crds.kloudlite.io/v1", "kind": "App"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "7. malformed json",
+			msg:     `{"action": `,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseAgentMessage([]byte(tt.msg)); (err != nil) != tt.wantErr {
+				t.Errorf("ParseAgentMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package env
+
+import (
+	"github.com/codingconcepts/env"
+)
+
+type Env struct {
+	AccountName string `env:"ACCOUNT_NAME" required:"true"`
+	ClusterName string `env:"CLUSTER_NAME" required:"true"`
+	AccessToken string `env:"ACCESS_TOKEN" required:"true"`
+
+	GrpcAddr          string `env:"GRPC_ADDR" required:"true"`
+	GrpcSecureConnect bool   `env:"GRPC_SECURE_CONNECT" default:"true"`
+}
+
+func GetEnvOrDie() *Env {
+	var ev Env
+	if err := env.Set(&ev); err != nil {
+		panic(err)
+	}
+	return &ev
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/kloudlite/operator/operator"
+	"github.com/kloudlite/operator/operators/agent/controller"
+)
+
+func main() {
+	mgr := operator.New("agent")
+	controller.RegisterInto(mgr)
+	mgr.Start()
+}
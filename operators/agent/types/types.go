@@ -0,0 +1,25 @@
+package types
+
+type Action string
+
+const (
+	ActionApply  Action = "apply"
+	ActionDelete Action = "delete"
+)
+
+// AgentMessage is the payload carried in `Action.message`, streamed by the message office api over `SendActions`
+type AgentMessage struct {
+	AccountName string         `json:"accountName"`
+	ClusterName string         `json:"clusterName"`
+	Action      Action         `json:"action"`
+	Object      map[string]any `json:"object"`
+}
+
+// AgentErrMessage is what agent reports back via `ReceiveError`, when it fails to process an AgentMessage
+type AgentErrMessage struct {
+	AccountName string         `json:"accountName"`
+	ClusterName string         `json:"clusterName"`
+	Error       string         `json:"error"`
+	Action      Action         `json:"action"`
+	Object      map[string]any `json:"object"`
+}
@@ -13,4 +13,5 @@ func RegisterInto(mgr operator.Operator) {
 	mgr.RegisterControllers(
 		&app.Reconciler{Name: "app", Env: ev},
 	)
+	mgr.RegisterWebhooks(&crdsv1.App{})
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,7 +14,6 @@ import (
 	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
 	"github.com/kloudlite/operator/operators/app-n-lambda/internal/env"
 	"github.com/kloudlite/operator/operators/app-n-lambda/internal/templates"
-	"github.com/kloudlite/operator/pkg/conditions"
 	"github.com/kloudlite/operator/pkg/constants"
 	fn "github.com/kloudlite/operator/pkg/functions"
 	"github.com/kloudlite/operator/pkg/kubectl"
@@ -23,13 +23,16 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/meta"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiLabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type Reconciler struct {
@@ -53,6 +56,9 @@ const (
 	ImagesLabelled             string = "images-labelled"
 	DeploymentReady            string = "deployment-ready"
 
+	PersistentVolumesProvisioned string = "persistent-volumes-provisioned"
+	HPAReady                     string = "hpa-ready"
+
 	CleanedOwnedResources string = "cleaned-owned-resources"
 )
 
@@ -72,7 +78,7 @@ var (
 // +kubebuilder:rbac:groups=crds.kloudlite.io,resources=apps/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.Logger), r.Client, request.NamespacedName, &crdsv1.App{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.Logger, r.GetName()), r.Client, request.NamespacedName, &crdsv1.App{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -91,7 +97,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
-	if step := req.EnsureCheckList(ApplyChecklist); !step.ShouldProceed() {
+	checklist := ApplyChecklist
+	if len(req.Object.Spec.Volumes) > 0 {
+		checklist = append([]rApi.CheckMeta{{Name: PersistentVolumesProvisioned, Title: "Persistent volumes provisioned"}}, checklist...)
+	}
+	if isRolloutEnabled(req.Object) {
+		checklist = append(slices.Clone(checklist), RolloutChecklist...)
+	}
+	if isHpaEnabled(req.Object) {
+		checklist = append(slices.Clone(checklist), rApi.CheckMeta{Name: HPAReady, Title: "Autoscaler ready"})
+	}
+
+	if step := req.EnsureCheckList(checklist); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
@@ -115,6 +132,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.ensurePersistentVolumes(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.ensureIdleState(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	if step := r.ensureDeploymentThings(req); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
@@ -123,8 +148,16 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.ensureRollout(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.checkHPA(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: idleRequeueAfter(req.Object)}, nil
 }
 
 func (r *Reconciler) finalize(req *rApi.Request[*crdsv1.App]) stepResult.Result {
@@ -158,6 +191,10 @@ func (r *Reconciler) reconLabellingImages(req *rApi.Request[*crdsv1.App]) stepRe
 		newLabels[fmt.Sprintf("kloudlite.io/image-%s", fn.Sha1Sum([]byte(obj.Spec.Containers[i].Image)))] = "true"
 	}
 
+	for i := range obj.Spec.InitContainers {
+		newLabels[fmt.Sprintf("kloudlite.io/image-%s", fn.Sha1Sum([]byte(obj.Spec.InitContainers[i].Image)))] = "true"
+	}
+
 	if !reflect.DeepEqual(newLabels, obj.GetLabels()) {
 		obj.SetLabels(newLabels)
 		if err := r.Update(ctx, obj); err != nil {
@@ -174,18 +211,73 @@ func (r *Reconciler) ensureDeploymentThings(req *rApi.Request[*crdsv1.App]) step
 	ctx, obj := req.Context(), req.Object
 	check := rApi.NewRunningCheck(DeploymentSvcAndHpaCreated, req)
 
-	volumes, vMounts := crdsv1.ParseVolumes(obj.Spec.Containers)
+	tplObj := templateObject(obj)
+	revision := appRevision(tplObj)
+
+	holdStable := false
+	if isRolloutEnabled(obj) {
+		stable, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &appsv1.Deployment{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return check.StillRunning(err)
+		}
+		holdStable = shouldHoldStable(tplObj, stable, revision)
+	}
+
+	podSpec, err := appVolumes(tplObj)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+
+	ingresses, err := r.routerIngresses(ctx, obj)
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	// router might not have created its ingresses yet, app is reconciled again, once it does
+	metrics, pendingMetrics, err := hpaMetrics(obj, ingresses)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+	if len(pendingMetrics) > 0 {
+		check.Info = fmt.Sprintf("hpa metrics (%s) are left out, until their routers have an ingress routing to the app", strings.Join(pendingMetrics, ", "))
+	}
+
+	activatorHost, err := r.activatorHost(obj)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+
+	workloadKind := "Deployment"
+	if obj.HasPerReplicaVolumes() {
+		workloadKind = "StatefulSet"
+	}
 
 	b, err := templates.ParseBytes(
 		r.appDeploymentTemplate, map[string]any{
-			"object":             obj,
-			"volumes":            volumes,
-			"volume-mounts":      vMounts,
+			"object":             tplObj,
+			"init-containers":    podSpec.initContainers,
+			"containers":         podSpec.containers,
+			"volumes":            podSpec.volumes,
+			"init-volume-mounts": podSpec.initVolumeMounts,
+			"volume-mounts":      podSpec.volumeMounts,
 			"owner-refs":         []metav1.OwnerReference{fn.AsOwner(obj, true)},
 			"account-name":       obj.GetAnnotations()[constants.AccountNameKey],
 			"pod-labels":         fn.MapFilter(obj.Labels, "kloudlite.io/"),
 			"pod-annotations":    fn.FilterObservabilityAnnotations(obj.GetAnnotations()),
 			"cluster-dns-suffix": r.Env.ClusterInternalDNS,
+
+			"revision":             revision,
+			"skip-deployment":      holdStable,
+			"service-selector-app": serviceSelectorApp(obj),
+
+			"workload-kind":          workloadKind,
+			"volume-claim-templates": podSpec.claimTemplates,
+			"pvc-retention-policy":   pvcRetentionPolicy(obj),
+
+			"hpa-metrics": metrics,
+
+			"idle":           obj.Status.IdleSince != nil,
+			"activator-host": activatorHost,
 		},
 	)
 	if err != nil {
@@ -199,6 +291,19 @@ func (r *Reconciler) ensureDeploymentThings(req *rApi.Request[*crdsv1.App]) step
 
 	req.AddToOwnedResources(resRefs...)
 
+	if err := r.deleteStaleWorkload(ctx, obj); err != nil {
+		return check.StillRunning(err)
+	}
+
+	if holdStable {
+		// stable deployment is not applied, while a new revision is being rolled out, but it is still owned by the app
+		req.AddToOwnedResources(rApi.ResourceRef{
+			TypeMeta:  metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			Namespace: obj.Namespace,
+			Name:      obj.Name,
+		})
+	}
+
 	return check.Completed()
 }
 
@@ -206,19 +311,12 @@ func (r *Reconciler) checkDeploymentReady(req *rApi.Request[*crdsv1.App]) stepRe
 	ctx, obj := req.Context(), req.Object
 	check := rApi.NewRunningCheck(DeploymentReady, req)
 
-	deployment, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &appsv1.Deployment{})
+	workload, err := r.getWorkload(ctx, obj)
 	if err != nil {
 		return check.Failed(err)
 	}
 
-	cds, err := conditions.FromObject(deployment)
-	if err != nil {
-		return check.StillRunning(err)
-	}
-
-	isReady := meta.IsStatusConditionTrue(cds, "Available")
-
-	if !isReady {
+	if !workload.available {
 		var podList corev1.PodList
 		if err := r.List(
 			ctx, &podList, &client.ListOptions{
@@ -234,16 +332,66 @@ func (r *Reconciler) checkDeploymentReady(req *rApi.Request[*crdsv1.App]) stepRe
 		if err != nil {
 			return check.StillRunning(err)
 		}
-		return check.StillRunning(fmt.Errorf(string(bMsg)))
+		return r.deploymentNotReady(req, check, pMessages, fmt.Errorf(string(bMsg)))
 	}
 
-	if deployment.Status.ReadyReplicas != deployment.Status.Replicas {
-		return check.StillRunning(fmt.Errorf("ready-replicas (%d) != total replicas (%d)", deployment.Status.ReadyReplicas, deployment.Status.Replicas))
+	if workload.readyReplicas != workload.replicas {
+		notReadyErr := fmt.Errorf("ready-replicas (%d) != total replicas (%d)", workload.readyReplicas, workload.replicas)
+		if !isAutoRollbackEnabled(obj) {
+			return check.StillRunning(notReadyErr)
+		}
+
+		// a deployment stays available during a rolling update, even when new pods keep failing
+		var podList corev1.PodList
+		if err := r.List(ctx, &podList, client.InNamespace(obj.Namespace), client.MatchingLabels{"app": obj.Name}); err != nil {
+			return check.StillRunning(err)
+		}
+		return r.deploymentNotReady(req, check, rApi.GetMessagesFromPods(podList.Items...), notReadyErr)
+	}
+
+	if obj.IsRolledBack() {
+		rb := obj.Status.Rollback
+		return check.Failed(fmt.Errorf("generation %d has been rolled back to revision %s (generation %d), as %s", rb.FailedGeneration, rb.ToRevision, rb.ToGeneration, rb.Reason)).Err(nil)
+	}
+
+	obj.Status.FailingSince = nil
+	obj.Status.FailingGeneration = 0
+	if !isAutoRollbackEnabled(obj) {
+		obj.Status.Revisions = nil
+	} else if revision := appRevision(obj); workload.annotations[constants.AppRolloutRevisionKey] == revision {
+		recordRevision(obj, revision, obj.Spec.AutoRollback.GetRevisionHistoryLimit())
 	}
 
 	return check.Completed()
 }
 
+type runningCheck interface {
+	StillRunning(err error) stepResult.Result
+}
+
+// deploymentNotReady rolls the app back, once its pods have been failing for longer than progress deadline, and otherwise keeps waiting for the deployment
+func (r *Reconciler) deploymentNotReady(req *rApi.Request[*crdsv1.App], check runningCheck, msgs []rApi.ContainerMessage, notReadyErr error) stepResult.Result {
+	obj := req.Object
+
+	rollback, wait := evaluateRollback(obj, msgs, time.Now())
+	if rollback != nil {
+		obj.Status.Rollback = rollback
+		obj.Status.FailingSince = nil
+		obj.Status.FailingGeneration = 0
+		if r.recorder != nil {
+			r.recorder.Eventf(obj, corev1.EventTypeWarning, "RolledBack", "generation %d rolled back to revision %s (generation %d), as %s", rollback.FailedGeneration, rollback.ToRevision, rollback.ToGeneration, rollback.Reason)
+		}
+		return check.StillRunning(fmt.Errorf("rolling back to revision %s (generation %d), as %s", rollback.ToRevision, rollback.ToGeneration, rollback.Reason)).Err(nil).RequeueAfter(500 * time.Millisecond)
+	}
+
+	if wait > 0 {
+		// error backoff could take longer than the remaining progress deadline
+		return check.StillRunning(notReadyErr).Err(nil).RequeueAfter(wait)
+	}
+
+	return check.StillRunning(notReadyErr)
+}
+
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) error {
 	r.Client = mgr.GetClient()
 	r.Scheme = mgr.GetScheme()
@@ -259,8 +407,19 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) e
 
 	builder := ctrl.NewControllerManagedBy(mgr).For(&crdsv1.App{})
 	builder.Owns(&appsv1.Deployment{})
+	builder.Owns(&appsv1.StatefulSet{})
 	builder.Owns(&corev1.Service{})
 	builder.Owns(&autoscalingv2.HorizontalPodAutoscaler{})
+	builder.Owns(&networkingv1.Ingress{})
+	builder.Watches(
+		&networkingv1.Ingress{},
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			if router, ok := obj.GetLabels()[constants.RouteIngressOfKey]; ok {
+				return r.appsWithRouterMetrics(ctx, obj.GetNamespace(), router)
+			}
+			return nil
+		}),
+	)
 
 	builder.WithOptions(controller.Options{MaxConcurrentReconciles: r.Env.MaxConcurrentReconciles})
 	builder.WithEventFilter(rApi.ReconcileFilter())
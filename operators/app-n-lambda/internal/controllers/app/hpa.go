@@ -0,0 +1,222 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func isHpaEnabled(obj *crdsv1.App) bool {
+	return obj.Spec.Hpa != nil && obj.Spec.Hpa.Enabled
+}
+
+func hpaMetricTarget(m crdsv1.HPAMetric) (autoscalingv2.MetricTarget, error) {
+	if m.AverageValue != "" {
+		q, err := resource.ParseQuantity(m.AverageValue)
+		if err != nil {
+			return autoscalingv2.MetricTarget{}, err
+		}
+		return autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: &q}, nil
+	}
+
+	q, err := resource.ParseQuantity(m.Value)
+	if err != nil {
+		return autoscalingv2.MetricTarget{}, err
+	}
+	return autoscalingv2.MetricTarget{Type: autoscalingv2.ValueMetricType, Value: &q}, nil
+}
+
+// ingressRoutingTo picks the first ingress, by name, with a path to service
+func ingressRoutingTo(ingresses []networkingv1.Ingress, service string) (string, bool) {
+	var names []string
+	for _, ing := range ingresses {
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, p := range rule.HTTP.Paths {
+				if p.Backend.Service != nil && p.Backend.Service.Name == service {
+					names = append(names, ing.Name)
+				}
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[0], true
+}
+
+// routerIngresses resolves the ingress, of each router, that object metrics of app's hpa describe. Routers split their routes across
+// ingresses, labelled with the router's name, so the one routing to app is picked. Routers, that have no such ingress yet, are left out
+func (r *Reconciler) routerIngresses(ctx context.Context, obj *crdsv1.App) (map[string]string, error) {
+	// autoscaler is not rendered, while app is idle
+	if !isHpaEnabled(obj) || obj.Status.IdleSince != nil {
+		return nil, nil
+	}
+
+	ingresses := map[string]string{}
+	for _, m := range obj.Spec.Hpa.Metrics {
+		if m.Type != crdsv1.HPAMetricTypeObject {
+			continue
+		}
+		if _, ok := ingresses[m.Router]; ok {
+			continue
+		}
+
+		var ingList networkingv1.IngressList
+		if err := r.List(ctx, &ingList, client.InNamespace(obj.Namespace), client.MatchingLabels{constants.RouteIngressOfKey: m.Router}); err != nil {
+			return nil, err
+		}
+
+		if name, ok := ingressRoutingTo(ingList.Items, obj.Name); ok {
+			ingresses[m.Router] = name
+		}
+	}
+
+	return ingresses, nil
+}
+
+// hpaMetrics translates additional metrics of app's hpa, into autoscaling/v2 metric sources. ingresses are router ingresses, that
+// object metrics describe, see routerIngresses. Object metrics of routers, without an ingress routing to app yet, are left out, until
+// it exists, and their names are returned as pending
+func hpaMetrics(obj *crdsv1.App, ingresses map[string]string) (metrics []autoscalingv2.MetricSpec, pending []string, err error) {
+	if !isHpaEnabled(obj) {
+		return nil, nil, nil
+	}
+
+	metrics = make([]autoscalingv2.MetricSpec, 0, len(obj.Spec.Hpa.Metrics))
+	for _, m := range obj.Spec.Hpa.Metrics {
+		target, err := hpaMetricTarget(m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid target for metric %s: %w", m.Name, err)
+		}
+
+		identifier := autoscalingv2.MetricIdentifier{Name: m.Name}
+		if len(m.Selector) > 0 {
+			identifier.Selector = &metav1.LabelSelector{MatchLabels: m.Selector}
+		}
+
+		switch m.Type {
+		case crdsv1.HPAMetricTypePods:
+			metrics = append(metrics, autoscalingv2.MetricSpec{
+				Type: autoscalingv2.PodsMetricSourceType,
+				Pods: &autoscalingv2.PodsMetricSource{Metric: identifier, Target: target},
+			})
+		case crdsv1.HPAMetricTypeObject:
+			ingress, ok := ingresses[m.Router]
+			if !ok {
+				pending = append(pending, m.Name)
+				continue
+			}
+			metrics = append(metrics, autoscalingv2.MetricSpec{
+				Type: autoscalingv2.ObjectMetricSourceType,
+				Object: &autoscalingv2.ObjectMetricSource{
+					DescribedObject: autoscalingv2.CrossVersionObjectReference{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: ingress},
+					Metric:          identifier,
+					Target:          target,
+				},
+			})
+		case crdsv1.HPAMetricTypeExternal:
+			metrics = append(metrics, autoscalingv2.MetricSpec{
+				Type:     autoscalingv2.ExternalMetricSourceType,
+				External: &autoscalingv2.ExternalMetricSource{Metric: identifier, Target: target},
+			})
+		default:
+			return nil, nil, fmt.Errorf("unknown type (%s) for metric %s", m.Type, m.Name)
+		}
+	}
+
+	return metrics, pending, nil
+}
+
+// appsWithRouterMetrics are apps, in namespace, whose hpa has object metrics, described by an ingress of router
+func (r *Reconciler) appsWithRouterMetrics(ctx context.Context, namespace string, router string) []reconcile.Request {
+	var apps crdsv1.AppList
+	if err := r.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i := range apps.Items {
+		if !isHpaEnabled(&apps.Items[i]) {
+			continue
+		}
+		for _, m := range apps.Items[i].Spec.Hpa.Metrics {
+			if m.Type == crdsv1.HPAMetricTypeObject && m.Router == router {
+				reqs = append(reqs, reconcile.Request{NamespacedName: fn.NN(namespace, apps.Items[i].Name)})
+				break
+			}
+		}
+	}
+	return reqs
+}
+
+// hpaStatusInfo summarizes what the autoscaler is doing, to be shown on app's checks
+func hpaStatusInfo(hpa *autoscalingv2.HorizontalPodAutoscaler) string {
+	info := []string{
+		fmt.Sprintf("current replicas: %d", hpa.Status.CurrentReplicas),
+		fmt.Sprintf("desired replicas: %d", hpa.Status.DesiredReplicas),
+	}
+	if hpa.Status.LastScaleTime != nil {
+		info = append(info, fmt.Sprintf("last scaled at: %s", hpa.Status.LastScaleTime.UTC().Format(time.RFC3339)))
+	}
+	for _, c := range hpa.Status.Conditions {
+		if c.Type == autoscalingv2.ScalingLimited && c.Status == corev1.ConditionTrue {
+			info = append(info, fmt.Sprintf("scaling limited: %s", c.Message))
+		}
+	}
+	return strings.Join(info, ", ")
+}
+
+func (r *Reconciler) checkHPA(req *rApi.Request[*crdsv1.App]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+
+	if !isHpaEnabled(obj) {
+		return req.Next()
+	}
+
+	check := rApi.NewRunningCheck(HPAReady, req)
+
+	if obj.Status.IdleSince != nil {
+		// autoscaler is removed, while app is scaled to zero, see ensureIdleState
+		return check.Completed()
+	}
+
+	hpa, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &autoscalingv2.HorizontalPodAutoscaler{})
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	check.Info = hpaStatusInfo(hpa)
+
+	for _, c := range hpa.Status.Conditions {
+		if c.Status != corev1.ConditionFalse {
+			continue
+		}
+		switch c.Type {
+		case autoscalingv2.AbleToScale, autoscalingv2.ScalingActive:
+			// metrics, especially custom and external ones, may take a while to be served, so it is checked again later
+			return check.StillRunning(fmt.Errorf("%s: %s", c.Reason, c.Message)).Err(nil).RequeueAfter(30 * time.Second)
+		}
+	}
+
+	return check.Completed()
+}
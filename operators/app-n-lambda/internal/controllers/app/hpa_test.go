@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHpaMetrics(t *testing.T) {
+	app := appWithImage(1, "worker")
+	app.Spec.Hpa = &crdsv1.HPA{
+		Enabled: true,
+		Metrics: []crdsv1.HPAMetric{
+			{Type: crdsv1.HPAMetricTypePods, Name: "inflight_jobs", AverageValue: "10"},
+			{Type: crdsv1.HPAMetricTypeObject, Name: "requests_per_second", Router: "web", Value: "2k"},
+			{Type: crdsv1.HPAMetricTypeExternal, Name: "queue_lag", Selector: map[string]string{"queue": "jobs"}, AverageValue: "100"},
+		},
+	}
+
+	metrics, pending, err := hpaMetrics(app, map[string]string{"web": "web-1a2b3c4d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 3 || len(pending) != 0 {
+		t.Fatalf("expected 3 metrics, and none pending, got %d, and %v", len(metrics), pending)
+	}
+
+	if m := metrics[0]; m.Type != autoscalingv2.PodsMetricSourceType || m.Pods.Target.Type != autoscalingv2.AverageValueMetricType || m.Pods.Target.AverageValue.String() != "10" {
+		t.Errorf("unexpected pods metric %+v", m)
+	}
+
+	if m := metrics[1]; m.Type != autoscalingv2.ObjectMetricSourceType || m.Object.DescribedObject.Kind != "Ingress" || m.Object.DescribedObject.Name != "web-1a2b3c4d" || m.Object.Target.Type != autoscalingv2.ValueMetricType {
+		t.Errorf("unexpected object metric %+v", m)
+	}
+
+	if m := metrics[2]; m.Type != autoscalingv2.ExternalMetricSourceType || m.External.Metric.Selector == nil || m.External.Metric.Selector.MatchLabels["queue"] != "jobs" {
+		t.Errorf("unexpected external metric %+v", m)
+	}
+
+	// router's ingress might not exist yet, which must not hold back the other metrics
+	metrics, pending, err = hpaMetrics(app, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 2 || metrics[1].Type != autoscalingv2.ExternalMetricSourceType || !reflect.DeepEqual(pending, []string{"requests_per_second"}) {
+		t.Errorf("expected object metric to be left out, and pending, got %d metrics, and %v pending", len(metrics), pending)
+	}
+
+	app.Spec.Hpa.Enabled = false
+	if metrics, _, _ := hpaMetrics(app, nil); len(metrics) != 0 {
+		t.Errorf("expected no metrics, when hpa is disabled, got %d", len(metrics))
+	}
+}
+
+func TestIngressRoutingTo(t *testing.T) {
+	ingress := func(name string, services ...string) networkingv1.Ingress {
+		paths := make([]networkingv1.HTTPIngressPath, 0, len(services))
+		for _, svc := range services {
+			paths = append(paths, networkingv1.HTTPIngressPath{
+				Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: svc}},
+			})
+		}
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths}},
+			}}},
+		}
+	}
+
+	// routes of the app, with settings of their own, are on an ingress named after a checksum of them
+	ingresses := []networkingv1.Ingress{ingress("web", "frontend"), ingress("web-5e6f7a8b", "worker"), ingress("web-1a2b3c4d", "frontend", "worker")}
+
+	if got, ok := ingressRoutingTo(ingresses, "worker"); !ok || got != "web-1a2b3c4d" {
+		t.Errorf("ingressRoutingTo(worker) = %q, %v, want web-1a2b3c4d", got, ok)
+	}
+	if got, ok := ingressRoutingTo(ingresses, "frontend"); !ok || got != "web" {
+		t.Errorf("ingressRoutingTo(frontend) = %q, %v, want web", got, ok)
+	}
+	if _, ok := ingressRoutingTo(ingresses, "api"); ok {
+		t.Errorf("ingressRoutingTo(api) found an ingress, where none routes to it")
+	}
+}
+
+func TestHpaStatusInfo(t *testing.T) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	hpa.Status.CurrentReplicas = 2
+	hpa.Status.DesiredReplicas = 5
+	hpa.Status.Conditions = []autoscalingv2.HorizontalPodAutoscalerCondition{
+		{Type: autoscalingv2.ScalingLimited, Status: corev1.ConditionTrue, Message: "the desired replica count is more than the maximum replica count"},
+	}
+
+	info := hpaStatusInfo(hpa)
+	for _, want := range []string{"current replicas: 2", "desired replicas: 5", "scaling limited"} {
+		if !strings.Contains(info, want) {
+			t.Errorf("expected %q in %q", want, info)
+		}
+	}
+}
+
+func TestCheckHPAWhileIdle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, crdsv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := appWithImage(1, "nginx")
+	app.SetName("web")
+	app.SetNamespace("env")
+	app.SetAnnotations(map[string]string{constants.AppLastRequestAtKey: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)})
+	app.Spec.ScaleToZero = &crdsv1.AppScaleToZero{Enabled: true, IdleAfter: &metav1.Duration{Duration: 10 * time.Minute}}
+	app.Spec.Hpa = &crdsv1.HPA{Enabled: true}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "env"}}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(app, hpa).WithStatusSubresource(app).Build()
+
+	logger, err := logging.New(&logging.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Reconciler{Client: cli, Name: "app", Logger: logger}
+
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(context.TODO(), logger, r.Name), cli, client.ObjectKeyFromObject(app), &crdsv1.App{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if step := r.ensureIdleState(req); !step.ShouldProceed() {
+		t.Fatal("ensureIdleState() should proceed")
+	}
+	if req.Object.Status.IdleSince == nil {
+		t.Fatal("expected app to be idle")
+	}
+	if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(hpa), &autoscalingv2.HorizontalPodAutoscaler{}); !apiErrors.IsNotFound(err) {
+		t.Fatalf("expected hpa of idle app to be deleted, got err=%v", err)
+	}
+
+	step := r.checkHPA(req)
+	if !step.ShouldProceed() {
+		t.Fatal("checkHPA() should proceed, while app is idle")
+	}
+	if _, err := step.ReconcilerResponse(); err != nil {
+		t.Fatalf("checkHPA() failed: %v", err)
+	}
+	if !req.Object.Status.Checks[HPAReady].Status {
+		t.Errorf("expected check %q to be completed, while app is idle", HPAReady)
+	}
+}
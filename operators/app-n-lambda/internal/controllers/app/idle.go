@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func isScaleToZeroEnabled(obj *crdsv1.App) bool {
+	return obj.Spec.ScaleToZero != nil && obj.Spec.ScaleToZero.Enabled
+}
+
+// lastRequestAt is when app activator last proxied a request to the app
+func lastRequestAt(obj *crdsv1.App) (time.Time, bool) {
+	v, ok := obj.GetAnnotations()[constants.AppLastRequestAtKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// evaluateIdle decides whether app should be scaled to zero by now. When it should not, it also returns how long to wait before evaluating again, zero meaning never
+func evaluateIdle(obj *crdsv1.App, now time.Time) (bool, time.Duration) {
+	if !isScaleToZeroEnabled(obj) || isIntercepted(obj) || obj.Spec.Freeze || obj.Status.Rollout.IsActive() {
+		return false, 0
+	}
+
+	last, ok := lastRequestAt(obj)
+	if !ok {
+		return false, 0
+	}
+
+	idleAfter := obj.Spec.ScaleToZero.GetIdleAfter()
+	if elapsed := now.Sub(last); elapsed < idleAfter {
+		return false, idleAfter - elapsed
+	}
+	return true, 0
+}
+
+// ensureIdleState scales app to zero, once it has been idle for long enough, and back up, as soon as activator records a request for it
+func (r *Reconciler) ensureIdleState(req *rApi.Request[*crdsv1.App]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+
+	if !isScaleToZeroEnabled(obj) {
+		obj.Status.IdleSince = nil
+		return req.Next()
+	}
+
+	now := time.Now()
+
+	if _, ok := lastRequestAt(obj); !ok {
+		// idle period starts counting, when scale to zero gets enabled
+		ann := obj.GetAnnotations()
+		if ann == nil {
+			ann = make(map[string]string, 1)
+		}
+		ann[constants.AppLastRequestAtKey] = now.UTC().Format(time.RFC3339)
+		obj.SetAnnotations(ann)
+
+		if err := rApi.UpdatePreservingStatus(ctx, r.Client, obj); err != nil {
+			return req.Done().Err(err)
+		}
+	}
+
+	idle, _ := evaluateIdle(obj, now)
+	switch {
+	case idle && obj.Status.IdleSince == nil:
+		obj.Status.IdleSince = &metav1.Time{Time: now}
+		if r.recorder != nil {
+			r.recorder.Eventf(obj, corev1.EventTypeNormal, "ScaledToZero", "no requests received for %s", obj.Spec.ScaleToZero.GetIdleAfter())
+		}
+	case !idle && obj.Status.IdleSince != nil:
+		obj.Status.IdleSince = nil
+		// activator holds requests, until deployment is ready again, so readiness of the idle deployment must not linger
+		if obj.Status.Checks == nil {
+			obj.Status.Checks = map[string]rApi.Check{}
+		}
+		obj.Status.Checks[DeploymentReady] = rApi.Check{Generation: obj.Generation, State: rApi.RunningState, Message: "scaling up, from zero replicas"}
+		if r.recorder != nil {
+			r.recorder.Eventf(obj, corev1.EventTypeNormal, "ScaledUp", "received a request, after being idle")
+		}
+	}
+
+	if obj.Status.IdleSince != nil && isHpaEnabled(obj) {
+		// autoscaler would otherwise scale the app back to its min replicas
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: obj.Name, Namespace: obj.Namespace}}
+		if err := r.Delete(ctx, hpa); client.IgnoreNotFound(err) != nil {
+			return req.Done().Err(err)
+		}
+	}
+
+	return req.Next()
+}
+
+// activatorHost is where the app's service points to, while the app is idle. A running app is served directly, and its service points
+// back to it, as soon as activator wakes it up
+func (r *Reconciler) activatorHost(obj *crdsv1.App) (string, error) {
+	if !isScaleToZeroEnabled(obj) {
+		return "", nil
+	}
+	if r.Env.AppActivatorHost == "" {
+		return "", fmt.Errorf("app activator is not configured, set env var APP_ACTIVATOR_HOST to use scale to zero")
+	}
+	if obj.Status.IdleSince == nil {
+		return "", nil
+	}
+	return r.Env.AppActivatorHost, nil
+}
+
+// idleRequeueAfter is when app should be evaluated again, for being idle
+func idleRequeueAfter(obj *crdsv1.App) time.Duration {
+	_, after := evaluateIdle(obj, time.Now())
+	return after
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/operators/app-n-lambda/internal/env"
+	"github.com/kloudlite/operator/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvaluateIdle(t *testing.T) {
+	now := time.Now()
+
+	newApp := func(lastRequest time.Time) *crdsv1.App {
+		app := appWithImage(1, "nginx")
+		app.Spec.ScaleToZero = &crdsv1.AppScaleToZero{Enabled: true, IdleAfter: &metav1.Duration{Duration: 10 * time.Minute}}
+		app.SetAnnotations(map[string]string{constants.AppLastRequestAtKey: lastRequest.UTC().Format(time.RFC3339)})
+		return app
+	}
+
+	t.Run("recently requested app is not idle", func(t *testing.T) {
+		idle, after := evaluateIdle(newApp(now.Add(-4*time.Minute)), now)
+		if idle {
+			t.Fatal("expected app not to be idle")
+		}
+		if after < 5*time.Minute || after > 6*time.Minute+time.Second {
+			t.Errorf("expected app to be evaluated again in about 6m, got %s", after)
+		}
+	})
+
+	t.Run("app without requests for idleAfter is idle", func(t *testing.T) {
+		if idle, _ := evaluateIdle(newApp(now.Add(-11*time.Minute)), now); !idle {
+			t.Error("expected app to be idle")
+		}
+	})
+
+	t.Run("frozen, or intercepted apps are never idle", func(t *testing.T) {
+		app := newApp(now.Add(-time.Hour))
+		app.Spec.Freeze = true
+		if idle, after := evaluateIdle(app, now); idle || after != 0 {
+			t.Errorf("expected frozen app not to be idle, got idle=%v after=%s", idle, after)
+		}
+
+		app = newApp(now.Add(-time.Hour))
+		app.Spec.Intercept = &crdsv1.Intercept{Enabled: true, ToDevice: "laptop"}
+		if idle, _ := evaluateIdle(app, now); idle {
+			t.Error("expected intercepted app not to be idle")
+		}
+	})
+
+	t.Run("app stays up, while a rollout is in progress", func(t *testing.T) {
+		app := newApp(now.Add(-time.Hour))
+		app.Status.Rollout = &crdsv1.AppRolloutStatus{Phase: crdsv1.AppRolloutPhaseProgressing}
+		if idle, _ := evaluateIdle(app, now); idle {
+			t.Error("expected app not to be idle")
+		}
+	})
+
+	t.Run("app without scale to zero is never idle", func(t *testing.T) {
+		app := newApp(now.Add(-time.Hour))
+		app.Spec.ScaleToZero.Enabled = false
+		if idle, after := evaluateIdle(app, now); idle || after != 0 {
+			t.Errorf("expected app not to be idle, got idle=%v after=%s", idle, after)
+		}
+	})
+}
+
+func TestActivatorHost(t *testing.T) {
+	r := &Reconciler{Env: &env.Env{AppActivatorHost: "app-activator.kloudlite.svc.cluster.local"}}
+
+	app := appWithImage(1, "nginx")
+	app.Spec.ScaleToZero = &crdsv1.AppScaleToZero{Enabled: true}
+
+	if host, err := r.activatorHost(app); err != nil || host != "" {
+		t.Errorf("activatorHost() = %q, %v, want running app to be served directly", host, err)
+	}
+
+	app.Status.IdleSince = &metav1.Time{Time: time.Now()}
+	if host, err := r.activatorHost(app); err != nil || host != r.Env.AppActivatorHost {
+		t.Errorf("activatorHost() = %q, %v, want idle app to be served through activator", host, err)
+	}
+
+	r.Env.AppActivatorHost = ""
+	if _, err := r.activatorHost(app); err == nil {
+		t.Error("activatorHost() should fail, while activator is not configured")
+	}
+}
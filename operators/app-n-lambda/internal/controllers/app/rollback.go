@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// container waiting reasons, that would not go away without a change to the pod template
+var failingContainerReasons = map[string]struct{}{
+	"CrashLoopBackOff":           {},
+	"ImagePullBackOff":           {},
+	"ErrImagePull":               {},
+	"InvalidImageName":           {},
+	"CreateContainerConfigError": {},
+	"CreateContainerError":       {},
+	"RunContainerError":          {},
+}
+
+func failingReason(msgs []rApi.ContainerMessage) (string, bool) {
+	for _, msg := range msgs {
+		if msg.State != "waiting" {
+			continue
+		}
+		if _, ok := failingContainerReasons[msg.Reason]; ok {
+			return fmt.Sprintf("container %s of pod %s is in %s", msg.Container, msg.Pod, msg.Reason), true
+		}
+	}
+	return "", false
+}
+
+func isAutoRollbackEnabled(obj *crdsv1.App) bool {
+	return obj.Spec.AutoRollback != nil && obj.Spec.AutoRollback.Enabled
+}
+
+func findRevision(obj *crdsv1.App, revision string) *crdsv1.AppRevision {
+	for i := len(obj.Status.Revisions) - 1; i >= 0; i-- {
+		if obj.Status.Revisions[i].Revision == revision {
+			return &obj.Status.Revisions[i]
+		}
+	}
+	return nil
+}
+
+// templateObject is the app, as its deployment should be rendered. A rolled back app keeps running the pod template it was rolled back to
+func templateObject(obj *crdsv1.App) *crdsv1.App {
+	if !obj.IsRolledBack() {
+		return obj
+	}
+
+	if rev := findRevision(obj, obj.Status.Rollback.ToRevision); rev != nil {
+		return obj.WithPodTemplate(rev.PodTemplate)
+	}
+	return obj
+}
+
+// recordRevision appends a ready revision to status, keeping at most limit of them
+func recordRevision(obj *crdsv1.App, revision string, limit int) {
+	revisions := obj.Status.Revisions
+	if n := len(revisions); n > 0 && revisions[n-1].Revision == revision {
+		return
+	}
+
+	// a revision, that gets ready again, moves to the end of history
+	for i := range revisions {
+		if revisions[i].Revision == revision {
+			revisions = append(revisions[:i], revisions[i+1:]...)
+			break
+		}
+	}
+
+	podTemplate := obj.PodTemplate()
+	revisions = append(revisions, crdsv1.AppRevision{
+		Generation:  obj.Generation,
+		Revision:    revision,
+		PodTemplate: *podTemplate.DeepCopy(),
+	})
+
+	if len(revisions) > limit {
+		revisions = revisions[len(revisions)-limit:]
+	}
+
+	obj.Status.Revisions = revisions
+}
+
+// rollbackTarget prefers the revision of last ready generation, and falls back to the latest recorded one
+func rollbackTarget(obj *crdsv1.App, currentRevision string) *crdsv1.AppRevision {
+	var target *crdsv1.AppRevision
+	for i := len(obj.Status.Revisions) - 1; i >= 0; i-- {
+		rev := &obj.Status.Revisions[i]
+		if rev.Revision == currentRevision {
+			continue
+		}
+		if rev.Generation == obj.Status.LastReadyGeneration {
+			return rev
+		}
+		if target == nil {
+			target = rev
+		}
+	}
+	return target
+}
+
+// evaluateRollback tracks, since when pods of current generation have been failing, and decides to roll back once that exceeds progress deadline.
+// When no rollback is due yet, it returns how long to wait before evaluating again, zero meaning that pods are not failing
+func evaluateRollback(obj *crdsv1.App, msgs []rApi.ContainerMessage, now time.Time) (*crdsv1.AppRollbackStatus, time.Duration) {
+	if !isAutoRollbackEnabled(obj) || obj.IsRolledBack() {
+		return nil, 0
+	}
+
+	reason, failing := failingReason(msgs)
+	if !failing {
+		obj.Status.FailingSince = nil
+		obj.Status.FailingGeneration = 0
+		return nil, 0
+	}
+
+	if obj.Status.FailingSince == nil || obj.Status.FailingGeneration != obj.Generation {
+		obj.Status.FailingSince = &metav1.Time{Time: now}
+		obj.Status.FailingGeneration = obj.Generation
+	}
+
+	deadline := obj.Spec.AutoRollback.GetProgressDeadline()
+	if elapsed := now.Sub(obj.Status.FailingSince.Time); elapsed < deadline {
+		return nil, deadline - elapsed
+	}
+
+	currentRevision := appRevision(obj)
+	target := rollbackTarget(obj, currentRevision)
+	if target == nil {
+		return nil, 0
+	}
+
+	return &crdsv1.AppRollbackStatus{
+		FailedGeneration: obj.Generation,
+		FailedRevision:   currentRevision,
+		ToGeneration:     target.Generation,
+		ToRevision:       target.Revision,
+		Reason:           fmt.Sprintf("%s, for more than %s", reason, deadline),
+		RolledBackAt:     metav1.Time{Time: now},
+	}, 0
+}
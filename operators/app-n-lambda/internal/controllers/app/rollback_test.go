@@ -0,0 +1,115 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func appWithImage(generation int64, image string) *crdsv1.App {
+	app := &crdsv1.App{Spec: crdsv1.AppSpec{Containers: []crdsv1.AppContainer{{Name: "main", Image: image}}}}
+	app.Generation = generation
+	return app
+}
+
+func TestRecordRevision(t *testing.T) {
+	app := appWithImage(1, "nginx:1")
+	for gen, image := range []string{"nginx:1", "nginx:2", "nginx:3", "nginx:4"} {
+		app.Generation = int64(gen + 1)
+		app.Spec.Containers[0].Image = image
+		recordRevision(app, appRevision(app), 3)
+	}
+
+	if len(app.Status.Revisions) != 3 {
+		t.Fatalf("expected 3 revisions, got %d", len(app.Status.Revisions))
+	}
+	if got := app.Status.Revisions[0].PodTemplate.Containers[0].Image; got != "nginx:2" {
+		t.Errorf("expected oldest revision to be nginx:2, got %s", got)
+	}
+
+	// same revision becoming ready again, is not recorded twice
+	recordRevision(app, appRevision(app), 3)
+	if len(app.Status.Revisions) != 3 {
+		t.Errorf("expected 3 revisions, got %d", len(app.Status.Revisions))
+	}
+
+	// an older revision, that becomes ready again, moves to the end
+	app.Generation = 5
+	app.Spec.Containers[0].Image = "nginx:2"
+	recordRevision(app, appRevision(app), 3)
+	if n := len(app.Status.Revisions); n != 3 || app.Status.Revisions[n-1].PodTemplate.Containers[0].Image != "nginx:2" || app.Status.Revisions[n-1].Generation != 5 {
+		t.Errorf("expected nginx:2 at generation 5 to be the latest revision, got %+v", app.Status.Revisions)
+	}
+}
+
+func TestEvaluateRollback(t *testing.T) {
+	now := time.Now()
+	crashing := []rApi.ContainerMessage{{Pod: "p", Container: "main", State: "waiting", Reason: "CrashLoopBackOff"}}
+
+	newApp := func() *crdsv1.App {
+		ready := appWithImage(1, "nginx:1")
+		app := appWithImage(2, "nginx:broken")
+		app.Spec.AutoRollback = &crdsv1.AppAutoRollback{Enabled: true, ProgressDeadline: &metav1.Duration{Duration: 5 * time.Minute}}
+		app.Status.LastReadyGeneration = 1
+		app.Status.Revisions = []crdsv1.AppRevision{{Generation: 1, Revision: appRevision(ready), PodTemplate: ready.PodTemplate()}}
+		return app
+	}
+
+	t.Run("pods are not failing", func(t *testing.T) {
+		app := newApp()
+		app.Status.FailingSince = &metav1.Time{Time: now.Add(-time.Hour)}
+		app.Status.FailingGeneration = 2
+		rollback, wait := evaluateRollback(app, []rApi.ContainerMessage{{State: "waiting", Reason: "ContainerCreating"}}, now)
+		if rollback != nil || wait != 0 || app.Status.FailingSince != nil {
+			t.Errorf("expected no rollback, and failure tracking to be reset")
+		}
+	})
+
+	t.Run("within progress deadline", func(t *testing.T) {
+		app := newApp()
+		rollback, wait := evaluateRollback(app, crashing, now)
+		if rollback != nil || wait != 5*time.Minute {
+			t.Errorf("expected to wait out progress deadline, got rollback=%v wait=%s", rollback, wait)
+		}
+	})
+
+	t.Run("past progress deadline", func(t *testing.T) {
+		app := newApp()
+		app.Status.FailingSince = &metav1.Time{Time: now.Add(-10 * time.Minute)}
+		app.Status.FailingGeneration = 2
+		rollback, _ := evaluateRollback(app, crashing, now)
+		if rollback == nil {
+			t.Fatalf("expected a rollback")
+		}
+		if rollback.ToGeneration != 1 || rollback.FailedGeneration != 2 {
+			t.Errorf("expected rollback from generation 2 to 1, got %+v", rollback)
+		}
+
+		app.Status.Rollback = rollback
+		if got := templateObject(app).Spec.Containers[0].Image; got != "nginx:1" {
+			t.Errorf("expected rolled back app to render nginx:1, got %s", got)
+		}
+	})
+
+	t.Run("failure of a previous generation", func(t *testing.T) {
+		app := newApp()
+		app.Status.FailingSince = &metav1.Time{Time: now.Add(-10 * time.Minute)}
+		app.Status.FailingGeneration = 1
+		if rollback, _ := evaluateRollback(app, crashing, now); rollback != nil {
+			t.Errorf("expected progress deadline to restart for a new generation")
+		}
+	})
+
+	t.Run("nothing to roll back to", func(t *testing.T) {
+		app := newApp()
+		app.Status.Revisions = nil
+		app.Status.FailingSince = &metav1.Time{Time: now.Add(-10 * time.Minute)}
+		app.Status.FailingGeneration = 2
+		if rollback, _ := evaluateRollback(app, crashing, now); rollback != nil {
+			t.Errorf("expected no rollback, without any recorded revision")
+		}
+	})
+}
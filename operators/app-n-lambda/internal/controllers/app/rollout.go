@@ -0,0 +1,472 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/operators/app-n-lambda/internal/templates"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	RolloutRevisionReady string = "rollout-revision-ready"
+	RolloutPromoted      string = "rollout-promoted"
+)
+
+var RolloutChecklist = []rApi.CheckMeta{
+	{Name: RolloutRevisionReady, Title: "New revision ready"},
+	{Name: RolloutPromoted, Title: "New revision promoted"},
+}
+
+const (
+	ingressCanaryAnnotation       = "nginx.ingress.kubernetes.io/canary"
+	ingressCanaryWeightAnnotation = "nginx.ingress.kubernetes.io/canary-weight"
+
+	labelCanaryOf = "kloudlite.io/canary-of"
+)
+
+// appRevision hashes everything that ends up in the pod template, so that any change to it results in a new revision
+func appRevision(obj *crdsv1.App) string {
+	b, _ := json.Marshal(obj.PodTemplate())
+	return fn.Sha1Sum(b)[:10]
+}
+
+func rolloutTrack(strategy crdsv1.AppRolloutStrategy) string {
+	if strategy == crdsv1.AppRolloutStrategyBlueGreen {
+		return "preview"
+	}
+	return "canary"
+}
+
+// rollouts run a Deployment per revision, so they are not available to apps running as a StatefulSet
+func isRolloutEnabled(obj *crdsv1.App) bool {
+	return obj.Spec.Rollout != nil && !obj.HasPerReplicaVolumes()
+}
+
+func isIntercepted(obj *crdsv1.App) bool {
+	return obj.Spec.Intercept != nil && obj.Spec.Intercept.Enabled
+}
+
+// canaryReplicas scales the canary in proportion to the traffic it receives, but always runs at least one pod
+func canaryReplicas(stableReplicas int32, weight int) int32 {
+	replicas := int32(math.Ceil(float64(stableReplicas) * float64(weight) / 100))
+	if replicas < 1 {
+		return 1
+	}
+	return replicas
+}
+
+func isDeploymentRolledOut(d *appsv1.Deployment) bool {
+	if d.Generation > d.Status.ObservedGeneration {
+		return false
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	return d.Status.UpdatedReplicas == replicas && d.Status.ReadyReplicas == replicas && d.Status.Replicas == replicas
+}
+
+// shouldHoldStable tells whether stable deployment must keep running its current revision, as a new one is still being rolled out
+func shouldHoldStable(obj *crdsv1.App, stable *appsv1.Deployment, revision string) bool {
+	if !isRolloutEnabled(obj) || stable == nil || obj.Spec.Freeze || isIntercepted(obj) {
+		return false
+	}
+
+	stableRevision := stable.GetAnnotations()[constants.AppRolloutRevisionKey]
+	if stableRevision == "" || stableRevision == revision {
+		return false
+	}
+
+	rs := obj.Status.Rollout
+	return rs == nil || rs.Revision != revision || rs.Phase != crdsv1.AppRolloutPhasePromoting
+}
+
+// serviceSelectorApp keeps traffic on blue-green preview pods, while the stable deployment is being switched over to the new revision
+func serviceSelectorApp(obj *crdsv1.App) string {
+	if rs := obj.Status.Rollout; rs != nil && rs.Strategy == crdsv1.AppRolloutStrategyBlueGreen && rs.Phase == crdsv1.AppRolloutPhasePromoting {
+		return fmt.Sprintf("%s-%s", obj.Name, rolloutTrack(rs.Strategy))
+	}
+	return obj.Name
+}
+
+// nextCanaryStep returns the step, canary should be at, and how long to wait before it moves further.
+// A zero wait means that the step is paused, until it is promoted
+func nextCanaryStep(steps []crdsv1.CanaryStep, step int, stepStartedAt time.Time, now time.Time, promoted bool) (int, time.Duration) {
+	if step >= len(steps) {
+		return len(steps), 0
+	}
+
+	if promoted {
+		return step + 1, 0
+	}
+
+	if steps[step].Pause == nil {
+		return step, 0
+	}
+
+	if elapsed := now.Sub(stepStartedAt); elapsed < steps[step].Pause.Duration {
+		return step, steps[step].Pause.Duration - elapsed
+	}
+
+	return step + 1, 0
+}
+
+func (r *Reconciler) removeAnnotation(ctx context.Context, obj *crdsv1.App, key string) error {
+	ann := obj.GetAnnotations()
+	if _, ok := ann[key]; !ok {
+		return nil
+	}
+	delete(ann, key)
+	obj.SetAnnotations(ann)
+
+	return rApi.UpdatePreservingStatus(ctx, r.Client, obj)
+}
+
+func (r *Reconciler) applyRevision(req *rApi.Request[*crdsv1.App], track string, replicas int32) error {
+	ctx, obj := req.Context(), req.Object
+
+	podSpec, err := appVolumes(obj)
+	if err != nil {
+		return err
+	}
+
+	b, err := templates.ParseBytes(
+		r.appDeploymentTemplate, map[string]any{
+			"object":             obj,
+			"init-containers":    podSpec.initContainers,
+			"containers":         podSpec.containers,
+			"volumes":            podSpec.volumes,
+			"init-volume-mounts": podSpec.initVolumeMounts,
+			"volume-mounts":      podSpec.volumeMounts,
+			"owner-refs":         []metav1.OwnerReference{fn.AsOwner(obj, true)},
+			"account-name":       obj.GetAnnotations()[constants.AccountNameKey],
+			"pod-labels":         fn.MapFilter(obj.Labels, "kloudlite.io/"),
+			"pod-annotations":    fn.FilterObservabilityAnnotations(obj.GetAnnotations()),
+			"cluster-dns-suffix": r.Env.ClusterInternalDNS,
+			"revision":           appRevision(obj),
+			"track":              track,
+			"track-replicas":     replicas,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	resRefs, err := r.YamlClient.ApplyYAML(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	req.AddToOwnedResources(resRefs...)
+	return nil
+}
+
+// ensureCanaryIngresses mirrors every ingress routing to this app, as an ingress-nginx canary ingress, pointing to the canary service
+func (r *Reconciler) ensureCanaryIngresses(ctx context.Context, obj *crdsv1.App, weight int) error {
+	var ingList networkingv1.IngressList
+	if err := r.List(ctx, &ingList, client.InNamespace(obj.Namespace)); err != nil {
+		return err
+	}
+
+	canarySvc := fmt.Sprintf("%s-canary", obj.Name)
+	expected := map[string]struct{}{}
+
+	for i := range ingList.Items {
+		ing := ingList.Items[i]
+		// ingress-nginx allows no canary of a canary, which also leaves out canary ingresses of router backends
+		if ing.GetLabels()[labelCanaryOf] != "" || ing.GetAnnotations()[ingressCanaryAnnotation] == "true" {
+			continue
+		}
+
+		var rules []networkingv1.IngressRule
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+
+			var paths []networkingv1.HTTPIngressPath
+			for _, p := range rule.HTTP.Paths {
+				if p.Backend.Service == nil || p.Backend.Service.Name != obj.Name {
+					continue
+				}
+				svc := *p.Backend.Service
+				svc.Name = canarySvc
+				p.Backend.Service = &svc
+				paths = append(paths, p)
+			}
+
+			if len(paths) > 0 {
+				rules = append(rules, networkingv1.IngressRule{
+					Host:             rule.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths}},
+				})
+			}
+		}
+
+		if len(rules) == 0 {
+			continue
+		}
+
+		annotations := fn.MapFilter(ing.GetAnnotations(), "nginx.ingress.kubernetes.io/")
+		annotations[ingressCanaryAnnotation] = "true"
+		annotations[ingressCanaryWeightAnnotation] = strconv.Itoa(weight)
+
+		canaryIng := &networkingv1.Ingress{
+			TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("%s-%s-canary", ing.Name, obj.Name),
+				Namespace:       obj.Namespace,
+				Labels:          map[string]string{labelCanaryOf: obj.Name},
+				Annotations:     annotations,
+				OwnerReferences: []metav1.OwnerReference{fn.AsOwner(obj, true)},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: ing.Spec.IngressClassName,
+				Rules:            rules,
+			},
+		}
+
+		if _, err := r.YamlClient.Apply(ctx, canaryIng); err != nil {
+			return err
+		}
+		expected[canaryIng.Name] = struct{}{}
+	}
+
+	return r.deleteCanaryIngresses(ctx, obj, expected)
+}
+
+// deleteCanaryIngresses deletes canary ingresses of this app, except the ones in keep
+func (r *Reconciler) deleteCanaryIngresses(ctx context.Context, obj *crdsv1.App, keep map[string]struct{}) error {
+	var ingList networkingv1.IngressList
+	if err := r.List(ctx, &ingList, client.InNamespace(obj.Namespace), client.MatchingLabels{labelCanaryOf: obj.Name}); err != nil {
+		return err
+	}
+
+	for i := range ingList.Items {
+		if _, ok := keep[ingList.Items[i].Name]; ok {
+			continue
+		}
+		if err := r.Delete(ctx, &ingList.Items[i]); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupRollout removes everything, that was created to roll out a new revision
+func (r *Reconciler) cleanupRollout(ctx context.Context, obj *crdsv1.App) error {
+	if err := r.deleteCanaryIngresses(ctx, obj, nil); err != nil {
+		return err
+	}
+
+	for _, track := range []string{"canary", "preview"} {
+		name := fmt.Sprintf("%s-%s", obj.Name, track)
+		if err := r.Delete(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: obj.Namespace}}); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+		if err := r.Delete(ctx, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: obj.Namespace}}); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreServiceSelector points app's internal service back to the stable pods, once blue-green switch over is done
+func (r *Reconciler) restoreServiceSelector(ctx context.Context, obj *crdsv1.App) error {
+	svc, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, fmt.Sprintf("%s-internal", obj.Name)), &corev1.Service{})
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if svc.Spec.Selector["app"] == obj.Name {
+		return nil
+	}
+
+	patch := client.MergeFrom(svc.DeepCopy())
+	svc.Spec.Selector = map[string]string{"app": obj.Name}
+	return r.Patch(ctx, svc, patch)
+}
+
+func (r *Reconciler) ensureRollout(req *rApi.Request[*crdsv1.App]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+
+	if !isRolloutEnabled(obj) {
+		if obj.Status.Rollout != nil {
+			if err := r.cleanupRollout(ctx, obj); err != nil {
+				return req.Done().Err(err)
+			}
+			obj.Status.Rollout = nil
+		}
+		return req.Next()
+	}
+
+	revisionCheck := rApi.NewRunningCheck(RolloutRevisionReady, req)
+
+	stable, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &appsv1.Deployment{})
+	if err != nil {
+		return revisionCheck.StillRunning(err)
+	}
+
+	revision := appRevision(obj)
+	rs := obj.Status.Rollout
+
+	if !shouldHoldStable(obj, stable, revision) {
+		// nothing to roll out, or new revision has already been handed over to the stable deployment
+		if rs.IsActive() {
+			if !isDeploymentRolledOut(stable) {
+				return revisionCheck.StillRunning(fmt.Errorf("waiting for stable deployment to roll out revision %s", revision))
+			}
+
+			if err := r.restoreServiceSelector(ctx, obj); err != nil {
+				return revisionCheck.StillRunning(err)
+			}
+
+			rs.Phase = crdsv1.AppRolloutPhaseCompleted
+			rs.Weight = 0
+			rs.Message = fmt.Sprintf("revision %s has been promoted", rs.Revision)
+
+			if err := r.cleanupRollout(ctx, obj); err != nil {
+				return revisionCheck.StillRunning(err)
+			}
+		}
+
+		if step := revisionCheck.Completed(); !step.ShouldProceed() {
+			return step
+		}
+		return rApi.NewRunningCheck(RolloutPromoted, req).Completed()
+	}
+
+	if rs == nil || rs.Revision != revision {
+		rs = &crdsv1.AppRolloutStatus{
+			Strategy:       obj.Spec.Rollout.Strategy,
+			Revision:       revision,
+			StableRevision: stable.GetAnnotations()[constants.AppRolloutRevisionKey],
+			Phase:          crdsv1.AppRolloutPhaseProgressing,
+			StepStartedAt:  &metav1.Time{Time: time.Now()},
+		}
+		obj.Status.Rollout = rs
+	}
+
+	if rs.Phase == crdsv1.AppRolloutPhaseAborted {
+		return revisionCheck.Failed(fmt.Errorf("rollout of revision %s was aborted, update the app to roll out a new revision", rs.Revision)).Err(nil)
+	}
+
+	if obj.GetAnnotations()[constants.AppRolloutAbortKey] == "true" {
+		if err := r.removeAnnotation(ctx, obj, constants.AppRolloutAbortKey); err != nil {
+			return revisionCheck.StillRunning(err)
+		}
+
+		if err := r.cleanupRollout(ctx, obj); err != nil {
+			return revisionCheck.StillRunning(err)
+		}
+
+		rs.Phase = crdsv1.AppRolloutPhaseAborted
+		rs.Weight = 0
+		rs.Message = fmt.Sprintf("rollout aborted, traffic is back on revision %s", rs.StableRevision)
+		return revisionCheck.Failed(errors.New(rs.Message)).Err(nil)
+	}
+
+	stableReplicas := int32(1)
+	if stable.Spec.Replicas != nil {
+		stableReplicas = *stable.Spec.Replicas
+	}
+
+	track := rolloutTrack(rs.Strategy)
+
+	replicas := stableReplicas
+	if rs.Strategy == crdsv1.AppRolloutStrategyCanary && obj.Spec.Rollout.Canary != nil && rs.Step < len(obj.Spec.Rollout.Canary.Steps) {
+		replicas = canaryReplicas(stableReplicas, obj.Spec.Rollout.Canary.Steps[rs.Step].Weight)
+	}
+
+	if err := r.applyRevision(req, track, replicas); err != nil {
+		return revisionCheck.Failed(err)
+	}
+
+	revisionDeployment, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, fmt.Sprintf("%s-%s", obj.Name, track)), &appsv1.Deployment{})
+	if err != nil {
+		return revisionCheck.StillRunning(err)
+	}
+
+	if !isDeploymentRolledOut(revisionDeployment) {
+		return revisionCheck.StillRunning(fmt.Errorf("waiting for %s deployment of revision %s to be ready", track, revision))
+	}
+
+	if step := revisionCheck.Completed(); !step.ShouldProceed() {
+		return step
+	}
+
+	promotedCheck := rApi.NewRunningCheck(RolloutPromoted, req)
+
+	promoted := obj.GetAnnotations()[constants.AppRolloutPromoteKey] == "true"
+	if promoted {
+		if err := r.removeAnnotation(ctx, obj, constants.AppRolloutPromoteKey); err != nil {
+			return promotedCheck.StillRunning(err)
+		}
+	}
+
+	switch rs.Strategy {
+	case crdsv1.AppRolloutStrategyBlueGreen:
+		{
+			autoPromote := obj.Spec.Rollout.BlueGreen != nil && obj.Spec.Rollout.BlueGreen.AutoPromote
+			if !promoted && !autoPromote {
+				rs.Phase = crdsv1.AppRolloutPhasePaused
+				rs.Message = fmt.Sprintf("preview of revision %s is ready at service %s-%s, waiting to be promoted", revision, obj.Name, track)
+				return promotedCheck.StillRunning(errors.New(rs.Message)).Err(nil)
+			}
+		}
+	default:
+		{
+			steps := obj.Spec.Rollout.Canary.Steps
+			if rs.Step < len(steps) {
+				rs.Weight = steps[rs.Step].Weight
+				if err := r.ensureCanaryIngresses(ctx, obj, rs.Weight); err != nil {
+					return promotedCheck.StillRunning(err)
+				}
+			}
+
+			nextStep, wait := nextCanaryStep(steps, rs.Step, rs.StepStartedAt.Time, time.Now(), promoted)
+			if nextStep == rs.Step {
+				if wait > 0 {
+					rs.Phase = crdsv1.AppRolloutPhaseProgressing
+					rs.Message = fmt.Sprintf("step %d/%d, %d%% of traffic on revision %s", rs.Step+1, len(steps), rs.Weight, revision)
+					return promotedCheck.StillRunning(errors.New(rs.Message)).Err(nil).RequeueAfter(wait)
+				}
+
+				rs.Phase = crdsv1.AppRolloutPhasePaused
+				rs.Message = fmt.Sprintf("paused at step %d/%d, with %d%% of traffic on revision %s, waiting to be promoted", rs.Step+1, len(steps), rs.Weight, revision)
+				return promotedCheck.StillRunning(errors.New(rs.Message)).Err(nil)
+			}
+
+			rs.Step = nextStep
+			rs.StepStartedAt = &metav1.Time{Time: time.Now()}
+			if rs.Step < len(steps) {
+				rs.Phase = crdsv1.AppRolloutPhaseProgressing
+				rs.Message = fmt.Sprintf("moving to step %d/%d", rs.Step+1, len(steps))
+				return promotedCheck.StillRunning(errors.New(rs.Message)).Err(nil).RequeueAfter(500 * time.Millisecond)
+			}
+		}
+	}
+
+	// stable deployment gets the new revision on the next reconcile, while traffic stays on canary/preview pods
+	rs.Phase = crdsv1.AppRolloutPhasePromoting
+	rs.Message = fmt.Sprintf("promoting revision %s", revision)
+	return promotedCheck.StillRunning(errors.New(rs.Message)).Err(nil).RequeueAfter(500 * time.Millisecond)
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCanaryReplicas(t *testing.T) {
+	tests := []struct {
+		name           string
+		stableReplicas int32
+		weight         int
+		want           int32
+	}{
+		{name: "rounds up", stableReplicas: 3, weight: 10, want: 1},
+		{name: "proportional", stableReplicas: 10, weight: 50, want: 5},
+		{name: "at least one pod", stableReplicas: 0, weight: 20, want: 1},
+		{name: "full weight", stableReplicas: 4, weight: 100, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canaryReplicas(tt.stableReplicas, tt.weight); got != tt.want {
+				t.Errorf("canaryReplicas() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextCanaryStep(t *testing.T) {
+	now := time.Now()
+	steps := []crdsv1.CanaryStep{
+		{Weight: 10, Pause: &metav1.Duration{Duration: 5 * time.Minute}},
+		{Weight: 50},
+		{Weight: 100, Pause: &metav1.Duration{Duration: time.Minute}},
+	}
+
+	tests := []struct {
+		name          string
+		step          int
+		stepStartedAt time.Time
+		promoted      bool
+		wantStep      int
+		wantWait      time.Duration
+	}{
+		{name: "waits out the pause", step: 0, stepStartedAt: now.Add(-2 * time.Minute), wantStep: 0, wantWait: 3 * time.Minute},
+		{name: "moves on, once pause has elapsed", step: 0, stepStartedAt: now.Add(-6 * time.Minute), wantStep: 1},
+		{name: "stays paused without a pause duration", step: 1, stepStartedAt: now.Add(-time.Hour), wantStep: 1},
+		{name: "promotion skips the pause", step: 1, stepStartedAt: now, promoted: true, wantStep: 2},
+		{name: "last step moves past steps", step: 2, stepStartedAt: now.Add(-2 * time.Minute), wantStep: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotWait := nextCanaryStep(steps, tt.step, tt.stepStartedAt, now, tt.promoted)
+			if gotStep != tt.wantStep || gotWait != tt.wantWait {
+				t.Errorf("nextCanaryStep() = (%d, %s), want (%d, %s)", gotStep, gotWait, tt.wantStep, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestShouldHoldStable(t *testing.T) {
+	withRevision := func(rev string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{constants.AppRolloutRevisionKey: rev}}}
+	}
+
+	canary := &crdsv1.AppRollout{Strategy: crdsv1.AppRolloutStrategyCanary}
+
+	tests := []struct {
+		name     string
+		app      crdsv1.App
+		stable   *appsv1.Deployment
+		revision string
+		want     bool
+	}{
+		{name: "no rollout configured", app: crdsv1.App{}, stable: withRevision("old"), revision: "new", want: false},
+		{name: "first deployment", app: crdsv1.App{Spec: crdsv1.AppSpec{Rollout: canary}}, stable: nil, revision: "new", want: false},
+		{name: "stable predates rollouts", app: crdsv1.App{Spec: crdsv1.AppSpec{Rollout: canary}}, stable: withRevision(""), revision: "new", want: false},
+		{name: "same revision", app: crdsv1.App{Spec: crdsv1.AppSpec{Rollout: canary}}, stable: withRevision("old"), revision: "old", want: false},
+		{name: "new revision", app: crdsv1.App{Spec: crdsv1.AppSpec{Rollout: canary}}, stable: withRevision("old"), revision: "new", want: true},
+		{name: "frozen app", app: crdsv1.App{Spec: crdsv1.AppSpec{Rollout: canary, Freeze: true}}, stable: withRevision("old"), revision: "new", want: false},
+		{
+			name: "promoting new revision",
+			app: crdsv1.App{
+				Spec:   crdsv1.AppSpec{Rollout: canary},
+				Status: crdsv1.AppStatus{Rollout: &crdsv1.AppRolloutStatus{Revision: "new", Phase: crdsv1.AppRolloutPhasePromoting}},
+			},
+			stable:   withRevision("old"),
+			revision: "new",
+			want:     false,
+		},
+		{
+			name: "aborted revision",
+			app: crdsv1.App{
+				Spec:   crdsv1.AppSpec{Rollout: canary},
+				Status: crdsv1.AppStatus{Rollout: &crdsv1.AppRolloutStatus{Revision: "new", Phase: crdsv1.AppRolloutPhaseAborted}},
+			},
+			stable:   withRevision("old"),
+			revision: "new",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldHoldStable(&tt.app, tt.stable, tt.revision); got != tt.want {
+				t.Errorf("shouldHoldStable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"context"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/conditions"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	labelVolumeOf            = "kloudlite.io/app-volume-of"
+	labelVolumeReclaimPolicy = "kloudlite.io/volume-reclaim-policy"
+)
+
+func volumeClaimSpec(v crdsv1.AppVolume) (corev1.PersistentVolumeClaimSpec, error) {
+	size, err := resource.ParseQuantity(string(v.Size))
+	if err != nil {
+		return corev1.PersistentVolumeClaimSpec{}, err
+	}
+
+	accessMode := v.AccessMode
+	if accessMode == "" {
+		accessMode = corev1.ReadWriteOnce
+	}
+
+	spec := corev1.PersistentVolumeClaimSpec{
+		AccessModes: []corev1.PersistentVolumeAccessMode{accessMode},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+		},
+	}
+	if v.StorageClass != "" {
+		spec.StorageClassName = &v.StorageClass
+	}
+	return spec, nil
+}
+
+func volumeReclaimPolicy(v crdsv1.AppVolume) crdsv1.AppVolumeReclaimPolicy {
+	if v.ReclaimPolicy == "" {
+		return crdsv1.AppVolumeReclaimPolicyDelete
+	}
+	return v.ReclaimPolicy
+}
+
+// appPodSpec holds containers of the app, as they are run in its pods, along with their volumes
+type appPodSpec struct {
+	initContainers   []crdsv1.AppContainer
+	containers       []crdsv1.AppContainer
+	volumes          []corev1.Volume
+	initVolumeMounts [][]corev1.VolumeMount
+	volumeMounts     [][]corev1.VolumeMount
+	claimTemplates   []corev1.PersistentVolumeClaim
+}
+
+// appVolumes resolves container volumes of the app into pod volumes, and their mounts.
+// Shared app volumes are mounted with their provisioned claims, while per replica volumes become StatefulSet volume claim templates
+func appVolumes(obj *crdsv1.App) (*appPodSpec, error) {
+	declared := make(map[string]crdsv1.AppVolume, len(obj.Spec.Volumes))
+	for _, v := range obj.Spec.Volumes {
+		declared[v.Name] = v
+	}
+
+	initContainers, containers := obj.PodContainers()
+
+	// init containers, and containers share volumes of the pod, so they are parsed together
+	all := make([]crdsv1.AppContainer, len(initContainers)+len(containers))
+	for i, c := range append(initContainers, containers...) {
+		c.DeepCopyInto(&all[i])
+		for j := range all[i].Volumes {
+			cv := &all[i].Volumes[j]
+			if v, ok := declared[cv.RefName]; ok && cv.Type == crdsv1.PVCType && !v.PerReplica {
+				cv.RefName = obj.VolumeClaimName(v.Name)
+			}
+		}
+	}
+
+	volumes, mounts := crdsv1.ParseVolumes(all)
+
+	ps := &appPodSpec{
+		initContainers:   all[:len(initContainers)],
+		containers:       all[len(initContainers):],
+		initVolumeMounts: mounts[:len(initContainers)],
+		volumeMounts:     mounts[len(initContainers):],
+		volumes:          make([]corev1.Volume, 0, len(volumes)),
+	}
+
+	for _, vol := range volumes {
+		if vol.PersistentVolumeClaim != nil {
+			if v, ok := declared[vol.PersistentVolumeClaim.ClaimName]; ok && v.PerReplica {
+				spec, err := volumeClaimSpec(v)
+				if err != nil {
+					return nil, err
+				}
+				ps.claimTemplates = append(ps.claimTemplates, corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: vol.Name},
+					Spec:       spec,
+				})
+				continue
+			}
+		}
+		ps.volumes = append(ps.volumes, vol)
+	}
+
+	return ps, nil
+}
+
+// pvcRetentionPolicy maps reclaim policy of per replica volumes, to StatefulSet's PVC retention policy
+func pvcRetentionPolicy(obj *crdsv1.App) appsv1.PersistentVolumeClaimRetentionPolicyType {
+	for _, v := range obj.Spec.Volumes {
+		if v.PerReplica && volumeReclaimPolicy(v) == crdsv1.AppVolumeReclaimPolicyRetain {
+			return appsv1.RetainPersistentVolumeClaimRetentionPolicyType
+		}
+	}
+	return appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+}
+
+func (r *Reconciler) ensurePersistentVolumes(req *rApi.Request[*crdsv1.App]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+
+	if len(obj.Spec.Volumes) == 0 {
+		// volumes, that were removed along with the last of them, still need to be cleaned up
+		if err := r.deleteStaleVolumes(ctx, obj, nil); err != nil {
+			return req.Done().Err(err)
+		}
+		return req.Next()
+	}
+
+	check := rApi.NewRunningCheck(PersistentVolumesProvisioned, req)
+
+	expected := make(map[string]struct{}, len(obj.Spec.Volumes))
+	for _, v := range obj.Spec.Volumes {
+		if v.PerReplica {
+			continue
+		}
+
+		spec, err := volumeClaimSpec(v)
+		if err != nil {
+			return check.Failed(err).Err(nil)
+		}
+
+		policy := volumeReclaimPolicy(v)
+
+		pvc := &corev1.PersistentVolumeClaim{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      obj.VolumeClaimName(v.Name),
+				Namespace: obj.Namespace,
+				Labels: map[string]string{
+					labelVolumeOf:            obj.Name,
+					labelVolumeReclaimPolicy: string(policy),
+				},
+			},
+			Spec: spec,
+		}
+
+		// retained volumes must survive app's deletion, so they are neither owned by the app, nor cleaned up with its resources
+		if policy == crdsv1.AppVolumeReclaimPolicyDelete {
+			pvc.OwnerReferences = []metav1.OwnerReference{fn.AsOwner(obj, true)}
+		}
+
+		resRefs, err := r.YamlClient.Apply(ctx, pvc)
+		if err != nil {
+			return check.Failed(err)
+		}
+
+		if policy == crdsv1.AppVolumeReclaimPolicyDelete {
+			req.AddToOwnedResources(resRefs...)
+		}
+		expected[pvc.Name] = struct{}{}
+	}
+
+	if err := r.deleteStaleVolumes(ctx, obj, expected); err != nil {
+		return check.StillRunning(err)
+	}
+
+	return check.Completed()
+}
+
+// deleteStaleVolumes deletes claims of volumes, that have been removed from the app, unless they are to be retained
+func (r *Reconciler) deleteStaleVolumes(ctx context.Context, obj *crdsv1.App, keep map[string]struct{}) error {
+	var pvcList corev1.PersistentVolumeClaimList
+	if err := r.List(ctx, &pvcList, client.InNamespace(obj.Namespace), client.MatchingLabels{labelVolumeOf: obj.Name}); err != nil {
+		return err
+	}
+
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if _, ok := keep[pvc.Name]; ok {
+			continue
+		}
+		if pvc.GetLabels()[labelVolumeReclaimPolicy] != string(crdsv1.AppVolumeReclaimPolicyDelete) {
+			continue
+		}
+		if err := r.Delete(ctx, pvc); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// appWorkload is readiness of app's Deployment, or StatefulSet, when app has per replica volumes
+type appWorkload struct {
+	annotations   map[string]string
+	available     bool
+	replicas      int32
+	readyReplicas int32
+}
+
+func (r *Reconciler) getWorkload(ctx context.Context, obj *crdsv1.App) (*appWorkload, error) {
+	if obj.HasPerReplicaVolumes() {
+		sts, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &appsv1.StatefulSet{})
+		if err != nil {
+			return nil, err
+		}
+
+		replicas := int32(1)
+		if sts.Spec.Replicas != nil {
+			replicas = *sts.Spec.Replicas
+		}
+
+		return &appWorkload{
+			annotations:   sts.GetAnnotations(),
+			available:     sts.Status.ObservedGeneration >= sts.Generation && sts.Status.UpdatedReplicas == replicas && sts.Status.ReadyReplicas == replicas,
+			replicas:      sts.Status.Replicas,
+			readyReplicas: sts.Status.ReadyReplicas,
+		}, nil
+	}
+
+	deployment, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &appsv1.Deployment{})
+	if err != nil {
+		return nil, err
+	}
+
+	cds, err := conditions.FromObject(deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appWorkload{
+		annotations:   deployment.GetAnnotations(),
+		available:     deployment.Status.ObservedGeneration >= deployment.Generation && meta.IsStatusConditionTrue(cds, "Available"),
+		replicas:      deployment.Status.Replicas,
+		readyReplicas: deployment.Status.ReadyReplicas,
+	}, nil
+}
+
+// deleteStaleWorkload deletes the Deployment, once app switches to a StatefulSet, and vice versa
+func (r *Reconciler) deleteStaleWorkload(ctx context.Context, obj *crdsv1.App) error {
+	var stale client.Object = &appsv1.StatefulSet{}
+	if obj.HasPerReplicaVolumes() {
+		stale = &appsv1.Deployment{}
+	}
+
+	if err := r.Get(ctx, fn.NN(obj.Namespace, obj.Name), stale); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	// leaves alone workloads, that were not created by this app
+	if !fn.IsOwner(stale, fn.AsOwner(obj, true)) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Delete(ctx, stale))
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"testing"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func TestAppVolumes(t *testing.T) {
+	app := appWithImage(1, "nginx")
+	app.Name = "sample"
+	app.Spec.Containers[0].Volumes = []crdsv1.ContainerVolume{
+		{MountPath: "/data", Type: crdsv1.PVCType, RefName: "data"},
+		{MountPath: "/cache", Type: crdsv1.PVCType, RefName: "cache"},
+	}
+	app.Spec.Volumes = []crdsv1.AppVolume{
+		{Name: "data", Storage: ct.Storage{Size: "1Gi"}},
+		{Name: "cache", Storage: ct.Storage{Size: "2Gi", StorageClass: "fast"}, PerReplica: true, ReclaimPolicy: crdsv1.AppVolumeReclaimPolicyRetain},
+	}
+
+	ps, err := appVolumes(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volumes, mounts, claimTemplates := ps.volumes, ps.volumeMounts, ps.claimTemplates
+
+	if len(volumes) != 1 || volumes[0].PersistentVolumeClaim == nil || volumes[0].PersistentVolumeClaim.ClaimName != "sample-data" {
+		t.Errorf("expected shared volume to be mounted with claim sample-data, got %+v", volumes)
+	}
+
+	if len(claimTemplates) != 1 || claimTemplates[0].Name != "cache" || *claimTemplates[0].Spec.StorageClassName != "fast" {
+		t.Fatalf("expected a claim template for per replica volume cache, got %+v", claimTemplates)
+	}
+
+	if len(mounts) != 1 || len(mounts[0]) != 2 {
+		t.Fatalf("expected 2 mounts for the container, got %+v", mounts)
+	}
+	for _, m := range mounts[0] {
+		if m.MountPath == "/cache" && m.Name != claimTemplates[0].Name {
+			t.Errorf("expected /cache to be mounted from claim template %s, got %s", claimTemplates[0].Name, m.Name)
+		}
+	}
+
+	// spec of the app itself must be left untouched
+	if app.Spec.Containers[0].Volumes[0].RefName != "data" {
+		t.Errorf("expected app spec not to be modified, got refName %s", app.Spec.Containers[0].Volumes[0].RefName)
+	}
+
+	if got := pvcRetentionPolicy(app); got != appsv1.RetainPersistentVolumeClaimRetentionPolicyType {
+		t.Errorf("expected retain policy, got %s", got)
+	}
+}
+
+func TestAppVolumesWithInitContainers(t *testing.T) {
+	app := appWithImage(1, "nginx")
+	app.Name = "sample"
+	app.Spec.Containers[0].Volumes = []crdsv1.ContainerVolume{{MountPath: "/data", Type: crdsv1.PVCType, RefName: "data"}}
+	app.Spec.Containers = append(app.Spec.Containers, crdsv1.AppContainer{Name: "proxy", Image: "envoy", Role: crdsv1.AppContainerRoleSidecar})
+	app.Spec.InitContainers = []crdsv1.AppContainer{{
+		Name:    "migrate",
+		Image:   "migrate",
+		Volumes: []crdsv1.ContainerVolume{{MountPath: "/var/data", Type: crdsv1.PVCType, RefName: "data"}},
+	}}
+	app.Spec.Volumes = []crdsv1.AppVolume{{Name: "data", Storage: ct.Storage{Size: "1Gi"}}}
+
+	ps, err := appVolumes(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sidecars start ahead of init containers
+	if len(ps.initContainers) != 2 || ps.initContainers[0].Name != "proxy" || ps.initContainers[1].Name != "migrate" {
+		t.Fatalf("expected init containers [proxy migrate], got %+v", ps.initContainers)
+	}
+	if len(ps.containers) != 1 || ps.containers[0].Name != "main" {
+		t.Fatalf("expected containers [main], got %+v", ps.containers)
+	}
+
+	// a claim, mounted by both init container, and container, is a single pod volume
+	if len(ps.volumes) != 1 {
+		t.Fatalf("expected 1 pod volume, got %+v", ps.volumes)
+	}
+	if len(ps.initVolumeMounts) != 2 || len(ps.initVolumeMounts[1]) != 1 || ps.initVolumeMounts[1][0].Name != ps.volumes[0].Name {
+		t.Errorf("expected migrate to mount volume %s, got %+v", ps.volumes[0].Name, ps.initVolumeMounts)
+	}
+	if len(ps.volumeMounts) != 1 || len(ps.volumeMounts[0]) != 1 || ps.volumeMounts[0][0].Name != ps.volumes[0].Name {
+		t.Errorf("expected main to mount volume %s, got %+v", ps.volumes[0].Name, ps.volumeMounts)
+	}
+}
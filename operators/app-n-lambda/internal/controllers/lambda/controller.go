@@ -44,7 +44,7 @@ const (
 // +kubebuilder:rbac:groups=crds.kloudlite.io,resources=lambdas/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &serverlessv1.Lambda{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &serverlessv1.Lambda{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -7,6 +7,9 @@ import (
 type Env struct {
 	MaxConcurrentReconciles int    `env:"MAX_CONCURRENT_RECONCILES"`
 	ClusterInternalDNS      string `env:"CLUSTER_INTERNAL_DNS"`
+
+	// host of app activator's headless service, that idle apps with scale to zero are served through
+	AppActivatorHost string `env:"APP_ACTIVATOR_HOST"`
 }
 
 func GetEnvOrDie() *Env {
@@ -1,6 +1,9 @@
 {{- $obj := get . "object"}}
 {{- $volumes := get . "volumes"}}
 {{- $vMounts := get . "volume-mounts"}}
+{{- $containers := get . "containers" | default list }}
+{{- $initContainers := get . "init-containers" | default list }}
+{{- $initVMounts := get . "init-volume-mounts" | default list }}
 {{- $ownerRefs := get . "owner-refs" | default list  }}
 
 {{- $podLabels := get . "pod-labels" | default dict }}
@@ -14,30 +17,72 @@
 
 {{- $clusterDnsSuffix := get . "cluster-dns-suffix" | default "cluster.local"}}
 
+{{/* for rollouts */}}
+{{- $revision := get . "revision" | default "" }}
+{{- $skipDeployment := get . "skip-deployment" | default false }}
+{{- $serviceSelectorApp := get . "service-selector-app" | default $obj.Name }}
+{{- /* when track is set, only a deployment, and a ClusterIP service for that revision are rendered */}}
+{{- $track := get . "track" | default "" }}
+{{- $trackReplicas := get . "track-replicas" | default 1 }}
+
+{{/* apps with per replica volumes run as a StatefulSet */}}
+{{- $workloadKind := get . "workload-kind" | default "Deployment" }}
+{{- $volumeClaimTemplates := get . "volume-claim-templates" | default list }}
+{{- $pvcRetentionPolicy := get . "pvc-retention-policy" | default "Delete" }}
+
+{{- /* custom, object and external metrics, as autoscaling/v2 metric sources */}}
+{{- $hpaMetrics := get . "hpa-metrics" | default list }}
+
+{{/* for scale to zero */}}
+{{- $idle := get . "idle" | default false }}
+{{- $activatorHost := get . "activator-host" | default "" }}
+
 {{- with $obj }}
 
 {{- $isIntercepted := (and .Spec.Intercept .Spec.Intercept.Enabled) }}
-{{- $isHpaEnabled := (and .Spec.Hpa .Spec.Hpa.Enabled) }}
+{{- /* idle apps stay scaled to zero, until activator receives a request for them */}}
+{{- $isHpaEnabled := (and .Spec.Hpa .Spec.Hpa.Enabled (not $idle)) }}
+
+{{- $deploymentName := .Name }}
+{{- if $track }}
+{{- $deploymentName = printf "%s-%s" .Name $track }}
+{{- end }}
 
 {{- /* gotype: github.com/kloudlite/operator/apis/crds/v1.App */ -}}
+{{- if not $skipDeployment }}
 apiVersion: apps/v1
-kind: Deployment
+kind: {{$workloadKind}}
 metadata:
-  name: {{.Name}}
+  name: {{$deploymentName}}
   namespace: {{.Namespace}}
   ownerReferences: {{ $ownerRefs | toYAML | nindent 4}}
   labels: {{.Labels | toYAML | nindent 4}}
+  {{- if $revision }}
+  annotations:
+    kloudlite.io/rollout.revision: {{$revision | squote}}
+  {{- end }}
 spec:
-  {{- if not (and .Spec.Hpa .Spec.Hpa.Enabled) }}
-  replicas: {{if (or .Spec.Freeze $isIntercepted )}}0{{ else }}{{.Spec.Replicas}}{{end}}
+  {{- if $track }}
+  replicas: {{$trackReplicas}}
+  {{- else if not $isHpaEnabled }}
+  replicas: {{if (or .Spec.Freeze $isIntercepted $idle)}}0{{ else }}{{.Spec.Replicas}}{{end}}
   {{- end}}
   selector:
     matchLabels:
-      app: {{.Name}}
+      app: {{$deploymentName}}
+  {{- if eq $workloadKind "StatefulSet" }}
+  serviceName: {{.Name}}-internal
+  persistentVolumeClaimRetentionPolicy:
+    whenDeleted: {{$pvcRetentionPolicy}}
+    whenScaled: Retain
+  {{- if $volumeClaimTemplates }}
+  volumeClaimTemplates: {{ $volumeClaimTemplates | toYAML | nindent 4 }}
+  {{- end }}
+  {{- end }}
   template:
     metadata:
       labels:
-        app: {{.Name}}
+        app: {{$deploymentName}}
         {{ $podLabels | toYAML | nindent 8 }}
       annotations: {{$podAnnotations | toYAML | nindent 8 }}
     spec:
@@ -75,15 +120,44 @@ spec:
       {{- /*                 - {{$weight | squote}} */ -}}
       {{- /*       {{- end }} */ -}}
 
-      {{- if .Spec.Containers }}
-      {{- $myDict := dict "containers" .Spec.Containers "volumeMounts" $vMounts }}
+      {{- if $initContainers }}
+      {{- $initDict := dict "containers" $initContainers "volumeMounts" $initVMounts "init" true }}
+      initContainers: {{- include "TemplateContainer" $initDict | nindent 8 }}
+      {{- end }}
+
+      {{- if $containers }}
+      {{- $myDict := dict "containers" $containers "volumeMounts" $vMounts }}
       containers: {{- include "TemplateContainer" $myDict | nindent 8 }}
       {{- if $volumes }}
       volumes: {{- $volumes| toYAML | nindent 8 }}
       {{- end }}
       {{- end }}
+{{- end }}
 ---
 
+{{- if $track }}
+{{- if .Spec.Services }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{$deploymentName}}
+  namespace: {{.Namespace}}
+  ownerReferences: {{ $ownerRefs | toYAML | nindent 4}}
+spec:
+  selector:
+    app: {{$deploymentName}}
+  ports:
+    {{- range $svc := .Spec.Services }}
+    {{- with $svc }}
+    - protocol: {{.Type | upper | default "TCP"}}
+      port: {{.Port}}
+      name: {{.Port | squote}}
+      targetPort: {{.TargetPort}}
+    {{- end }}
+    {{- end }}
+{{- end }}
+{{- else }}
+
 {{- if .Spec.Services }}
 apiVersion: v1
 kind: Service
@@ -95,6 +169,8 @@ spec:
   type: ExternalName
   {{- if $isIntercepted }}
   externalName: {{.Spec.Intercept.ToDevice}}.{{.Namespace}}.svc.{{$clusterDnsSuffix}}
+  {{- else if $activatorHost }}
+  externalName: {{$activatorHost}}
   {{- else}}
   externalName: {{.Name}}-internal.{{.Namespace}}.svc.{{$clusterDnsSuffix}}
   {{- end }}
@@ -107,7 +183,7 @@ metadata:
   ownerReferences: {{ $ownerRefs | toYAML | nindent 4}}
 spec:
   selector:
-    app: {{.Name}}
+    app: {{$serviceSelectorApp}}
   ports:
     {{- range $svc := .Spec.Services }}
     {{- with $svc }}
@@ -130,7 +206,7 @@ metadata:
 spec:
   scaleTargetRef:
     apiVersion: apps/v1
-    kind: Deployment
+    kind: {{$workloadKind}}
     name: {{.Name}}
   minReplicas: {{ .Spec.Hpa.MinReplicas }}
   maxReplicas: {{ .Spec.Hpa.MaxReplicas }}
@@ -147,6 +223,13 @@ spec:
         target:
           type: Utilization
           averageUtilization: {{.Spec.Hpa.ThresholdMemory}}
+    {{- if $hpaMetrics }}
+    {{- $hpaMetrics | toYAML | nindent 4 }}
+    {{- end }}
+  {{- if .Spec.Hpa.Behavior }}
+  behavior: {{ .Spec.Hpa.Behavior | toYAML | nindent 4 }}
+  {{- end }}
+{{- end }}
 {{- end }}
 {{- end }}
 
@@ -67,7 +67,7 @@ var (
 // +kubebuilder:rbac:groups=clusters,resources=clusters/finalizers,verbs=update
 
 func (r *AwsVPCReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &clustersv1.AwsVPC{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &clustersv1.AwsVPC{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -91,7 +91,7 @@ var DeleteCheckList = []rApi.CheckMeta{}
 // +kubebuilder:rbac:groups=clusters.kloudlite.io,resources=clusters/finalizers,verbs=update
 
 func (r *ClusterReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &clustersv1.Cluster{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &clustersv1.Cluster{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
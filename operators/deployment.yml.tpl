@@ -9,6 +9,9 @@
 {{- $svcAccountName := .SvcAccountName | pipefail "no .SvcAccountName provided" -}}
 {{- $tolerations := .Tolerations | default "[]" | mustFromJson -}}
 {{- $nodeSelectors := .NodeSelectors | default "{}" | mustFromJson -}}
+{{- $enableWebhooks := .EnableWebhooks | default false -}}
+{{- /* kinds, in lowercase, whose webhooks this operator registers, e.g. ["app", "router"] */ -}}
+{{- $webhooks := .Webhooks | default "[]" | mustFromJson -}}
 
 ---
 apiVersion: apps/v1
@@ -68,6 +71,9 @@ spec:
             - --health-probe-bind-address=:8081
             - --metrics-bind-address=127.0.0.1:8080
             - --leader-elect
+            {{- if $enableWebhooks }}
+            - --enable-webhooks
+            {{- end }}
           env:
             - name: RECONCILE_PERIOD
               value: "30s"
@@ -84,6 +90,16 @@ spec:
           name: manager
           securityContext:
             allowPrivilegeEscalation: false
+          {{- if $enableWebhooks }}
+          ports:
+            - containerPort: 9443
+              name: webhook-server
+              protocol: TCP
+          volumeMounts:
+            - mountPath: /tmp/k8s-webhook-server/serving-certs
+              name: webhook-cert
+              readOnly: true
+          {{- end }}
           livenessProbe:
             httpGet:
               path: /healthz
@@ -105,6 +121,13 @@ spec:
               memory: 20Mi
       serviceAccountName: {{$svcAccountName}}
       terminationGracePeriodSeconds: 10
+      {{- if $enableWebhooks }}
+      volumes:
+        - name: webhook-cert
+          secret:
+            defaultMode: 420
+            secretName: {{$name}}-webhook-server-cert
+      {{- end }}
 ---
 apiVersion: v1
 kind: Service
@@ -123,3 +146,108 @@ spec:
   selector:
     app: {{$name}}
     control-plane: {{$name}}
+
+{{- if $enableWebhooks }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: {{$name}}
+    control-plane: {{$name}}
+  name: {{$name}}-webhook-service
+  namespace: {{$namespace}}
+spec:
+  ports:
+    - port: 443
+      protocol: TCP
+      targetPort: 9443
+  selector:
+    app: {{$name}}
+    control-plane: {{$name}}
+---
+apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: {{$name}}-selfsigned-issuer
+  namespace: {{$namespace}}
+spec:
+  selfSigned: {}
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: {{$name}}-serving-cert
+  namespace: {{$namespace}}
+spec:
+  dnsNames:
+    - {{$name}}-webhook-service.{{$namespace}}.svc
+    - {{$name}}-webhook-service.{{$namespace}}.svc.cluster.local
+  issuerRef:
+    kind: Issuer
+    name: {{$name}}-selfsigned-issuer
+  secretName: {{$name}}-webhook-server-cert
+{{- if $webhooks }}
+---
+{{- /* mirrors config/webhook/manifests.yaml, for just the kinds served by this operator */}}
+apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: {{$name}}-mutating-webhook-configuration
+  annotations:
+    cert-manager.io/inject-ca-from: {{$namespace}}/{{$name}}-serving-cert
+webhooks:
+  {{- range $kind := $webhooks }}
+  - admissionReviewVersions:
+      - v1
+    clientConfig:
+      service:
+        name: {{$name}}-webhook-service
+        namespace: {{$namespace}}
+        path: /mutate-crds-kloudlite-io-v1-{{$kind}}
+    failurePolicy: Fail
+    name: m{{$kind}}.kb.io
+    rules:
+      - apiGroups:
+          - crds.kloudlite.io
+        apiVersions:
+          - v1
+        operations:
+          - CREATE
+          - UPDATE
+        resources:
+          - {{$kind}}s
+    sideEffects: None
+  {{- end }}
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: {{$name}}-validating-webhook-configuration
+  annotations:
+    cert-manager.io/inject-ca-from: {{$namespace}}/{{$name}}-serving-cert
+webhooks:
+  {{- range $kind := $webhooks }}
+  - admissionReviewVersions:
+      - v1
+    clientConfig:
+      service:
+        name: {{$name}}-webhook-service
+        namespace: {{$namespace}}
+        path: /validate-crds-kloudlite-io-v1-{{$kind}}
+    failurePolicy: Fail
+    name: v{{$kind}}.kb.io
+    rules:
+      - apiGroups:
+          - crds.kloudlite.io
+        apiVersions:
+          - v1
+        operations:
+          - CREATE
+          - UPDATE
+        resources:
+          - {{$kind}}s
+    sideEffects: None
+  {{- end }}
+{{- end }}
+{{- end }}
@@ -62,7 +62,7 @@ var (
 // +kubebuilder:rbac:groups=distribution.kloudlite.io,resources=devices/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &dbv1.BuildRun{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &dbv1.BuildRun{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -13,4 +13,5 @@ func RegisterInto(mgr operator.Operator) {
 	mgr.RegisterControllers(
 		&helm_controller.Reconciler{Name: "helm-controller", Env: ev},
 	)
+	mgr.RegisterWebhooks(&crdsv1.HelmChart{})
 }
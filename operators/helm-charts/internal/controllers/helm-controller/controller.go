@@ -101,7 +101,7 @@ func getJobSvcAccountName() string {
 // +kubebuilder:rbac:groups=helm.kloudlite.io,resources=helmcharts/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &crdsv1.HelmChart{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &crdsv1.HelmChart{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -165,13 +165,7 @@ func (r *Reconciler) patchDefaults(req *rApi.Request[*crdsv1.HelmChart]) stepRes
 		return req.CheckFailed(checkName, check, err.Error())
 	}
 
-	hasPatched := false
-	if obj.Spec.ReleaseName == "" {
-		hasPatched = true
-		obj.Spec.ReleaseName = obj.Name
-	}
-
-	if hasPatched {
+	if crdsv1.ApplyDefaults(obj) {
 		if err := r.Update(ctx, obj); err != nil {
 			return fail(err)
 		}
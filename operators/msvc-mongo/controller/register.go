@@ -1,8 +1,10 @@
 package controller
 
 import (
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
 	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
 	"github.com/kloudlite/operator/operator"
+	"github.com/kloudlite/operator/operators/msvc-mongo/internal/controllers/backup"
 	clusterService "github.com/kloudlite/operator/operators/msvc-mongo/internal/controllers/cluster-service"
 	"github.com/kloudlite/operator/operators/msvc-mongo/internal/controllers/database"
 	standaloneService "github.com/kloudlite/operator/operators/msvc-mongo/internal/controllers/standalone-service"
@@ -11,10 +13,11 @@ import (
 
 func RegisterInto(mgr operator.Operator) {
 	ev := env.GetEnvOrDie()
-	mgr.AddToSchemes(mongodbMsvcv1.AddToScheme)
+	mgr.AddToSchemes(mongodbMsvcv1.AddToScheme, crdsv1.AddToScheme)
 	mgr.RegisterControllers(
 		&clusterService.Reconciler{Name: "msvc-mongo:cluster-service", Env: ev},
 		&standaloneService.Reconciler{Name: "msvc-mongo:standalone-svc", Env: ev},
 		&database.Reconciler{Name: "msvc-mongo:database", Env: ev},
 	)
+	mgr.RegisterControllers(backup.NewEngine(ev).Reconcilers("msvc-mongo:")...)
 }
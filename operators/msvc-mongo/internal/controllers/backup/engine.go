@@ -0,0 +1,52 @@
+package backup
+
+import (
+	ct "github.com/kloudlite/operator/apis/common-types"
+	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-mongo/internal/env"
+	"github.com/kloudlite/operator/pkg/backup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dumpCmd authenticates against the database itself, as its user has no access to others
+const dumpCmd = `mongodump --host "$HOSTS" --username "$USERNAME" --password "$PASSWORD" --authenticationDatabase "$DB_NAME" --db "$DB_NAME" --archive --gzip`
+
+// restoreCmd renames collections of the backed up database, to the one restored into
+const restoreCmd = `mongorestore --host "$HOSTS" --username "$USERNAME" --password "$PASSWORD" --authenticationDatabase "$DB_NAME" --archive --gzip --drop --nsFrom "$SOURCE_DB_NAME.*" --nsTo "$DB_NAME.*"`
+
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=backups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=backups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=backups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=backupschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=backupschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=backupschedules/finalizers,verbs=update
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=restores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=restores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=restores/finalizers,verbs=update
+
+func NewEngine(ev *env.Env) *backup.Engine[*mongodbMsvcv1.BackupSchedule, *mongodbMsvcv1.Backup, *mongodbMsvcv1.Restore, *mongodbMsvcv1.Database] {
+	return &backup.Engine[*mongodbMsvcv1.BackupSchedule, *mongodbMsvcv1.Backup, *mongodbMsvcv1.Restore, *mongodbMsvcv1.Database]{
+		NewSchedule:   func() *mongodbMsvcv1.BackupSchedule { return &mongodbMsvcv1.BackupSchedule{} },
+		NewBackup:     func() *mongodbMsvcv1.Backup { return &mongodbMsvcv1.Backup{} },
+		NewBackupList: func() client.ObjectList { return &mongodbMsvcv1.BackupList{} },
+		NewRestore:    func() *mongodbMsvcv1.Restore { return &mongodbMsvcv1.Restore{} },
+		NewDatabase:   func() *mongodbMsvcv1.Database { return &mongodbMsvcv1.Database{} },
+		NewRestoreTarget: func(name string, namespace string, msvc ct.MsvcRef) *mongodbMsvcv1.Database {
+			return &mongodbMsvcv1.Database{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       mongodbMsvcv1.DatabaseSpec{MsvcRef: msvc},
+				Output:     ct.ManagedResourceOutput{CredentialsRef: ct.LocalObjectReference{Name: "mres-" + name}},
+			}
+		},
+		DatabaseSecret: func(db *mongodbMsvcv1.Database) string { return db.Output.CredentialsRef.Name },
+
+		ArtifactExt:   "archive.gz",
+		DumpScript:    dumpCmd,
+		RestoreScript: restoreCmd,
+
+		JobImage:                ev.BackupJobImage,
+		CredentialsNamespaces:   ev.BackupCredentialsNamespaces,
+		MaxConcurrentReconciles: ev.MaxConcurrentReconciles,
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/kloudlite/operator/pkg/logging"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/resize"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -59,13 +60,13 @@ const (
 	AnnotationCurrentStorageSize string = "kloudlite.io/msvc.storage-size"
 )
 
-var ApplyCheckList = []rApi.CheckMeta{
+var ApplyCheckList = append([]rApi.CheckMeta{
 	{Name: DefaultsPatched, Title: "Defaults Patched", Debug: true},
 	{Name: AccessCredsGenerated, Title: "Access Credentials Generated"},
 	{Name: MongoDBHelmApplied, Title: "MongoDB Helm Applied"},
 	{Name: MongoDBHelmReady, Title: "MongoDB Helm Ready"},
 	{Name: MongoDBStatefulSetsReady, Title: "MongoDB StatefulSets Ready"},
-}
+}, resize.CheckList...)
 
 // DefaultsPatched string = "defaults-patched"
 var DeleteCheckList = []rApi.CheckMeta{
@@ -77,7 +78,7 @@ var DeleteCheckList = []rApi.CheckMeta{
 // +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=clusterServices/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &mongodbMsvcv1.ClusterService{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &mongodbMsvcv1.ClusterService{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -100,7 +101,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
-	if step := req.EnsureChecks(DefaultsPatched, AccessCredsGenerated, MongoDBHelmApplied, MongoDBHelmReady, MongoDBStatefulSetsReady); !step.ShouldProceed() {
+	if step := req.EnsureChecks(DefaultsPatched, AccessCredsGenerated, MongoDBHelmApplied, MongoDBHelmReady, MongoDBStatefulSetsReady, resize.StorageExpanded, resize.StatefulSetsRecreated, resize.PodsRolled); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
@@ -116,6 +117,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := resize.ExpandStorage(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := resize.RecreateStatefulSets(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	if step := r.applyHelm(req); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
@@ -124,6 +133,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := resize.RollPods(req, r.Client); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
 	return ctrl.Result{}, nil
 }
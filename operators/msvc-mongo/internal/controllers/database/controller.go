@@ -15,6 +15,7 @@ import (
 	libMongo "github.com/kloudlite/operator/pkg/mongo"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/rotation"
 	"github.com/kloudlite/operator/pkg/templates"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -48,8 +49,9 @@ const (
 	DBUserReady      string = "db-user-ready"
 	IsOwnedByMsvc    string = "is-owned-by-msvc"
 
-	DBUserDeleted   string = "db-user-deleted"
-	DefaultsPatched string = "defaults-patched"
+	DBUserDeleted      string = "db-user-deleted"
+	DefaultsPatched    string = "defaults-patched"
+	CredentialsRotated string = "credentials-rotated"
 )
 
 const (
@@ -75,7 +77,7 @@ func (r *Reconciler) newMongoContext(parent context.Context) (context.Context, c
 // +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=databases/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &mongodbMsvcv1.Database{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &mongodbMsvcv1.Database{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -106,8 +108,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.rotateCredentials(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: rotation.RequeueAfter(req.Object, req.Object.Spec.Rotation, time.Now())}, nil
 }
 
 func (r *Reconciler) finalize(req *rApi.Request[*mongodbMsvcv1.Database]) stepResult.Result {
@@ -135,6 +141,11 @@ func (r *Reconciler) finalize(req *rApi.Request[*mongodbMsvcv1.Database]) stepRe
 		return check.Failed(err)
 	}
 
+	// user, that rotated credentials might have switched to
+	if err := mongoCli.DeleteUser(ctx, obj.Name, rotation.AlternateUsername(obj.Name, obj.Name)); err != nil {
+		return check.Failed(err)
+	}
+
 	return req.Finalize()
 }
 
@@ -183,6 +194,14 @@ func (r *Reconciler) getMsvcConnectionParams(ctx context.Context, obj *mongodbMs
 	}
 }
 
+func mresURI(obj *mongodbMsvcv1.Database, username string, password string, hosts string) string {
+	baseURI := fmt.Sprintf("mongodb://%s:%s@%s/%s?authSource=%s", username, password, hosts, obj.Name, obj.Name)
+	if obj.Spec.MsvcRef.Kind == "ClusterService" {
+		return baseURI + "&replicaSet=rs"
+	}
+	return baseURI
+}
+
 func (r *Reconciler) reconDBCreds(req *rApi.Request[*mongodbMsvcv1.Database]) stepResult.Result {
 	ctx, obj := req.Context(), req.Object
 	check := rApi.NewRunningCheck(AccessCredsReady, req)
@@ -226,13 +245,7 @@ func (r *Reconciler) reconDBCreds(req *rApi.Request[*mongodbMsvcv1.Database]) st
 			Password: dbPasswd,
 			Hosts:    msvcHosts,
 			DbName:   obj.Name,
-			URI: func() string {
-				baseURI := fmt.Sprintf("mongodb://%s:%s@%s/%s?authSource=%s", obj.Name, dbPasswd, msvcHosts, obj.Name, obj.Name)
-				if obj.Spec.MsvcRef.Kind == "ClusterService" {
-					return baseURI + "&replicaSet=rs"
-				}
-				return baseURI
-			}(),
+			URI:      mresURI(obj, obj.Name, dbPasswd, msvcHosts),
 		}
 
 		b2, err := templates.Parse(
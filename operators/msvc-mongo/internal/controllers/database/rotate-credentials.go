@@ -0,0 +1,116 @@
+package database
+
+import (
+	"time"
+
+	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-mongo/internal/types"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	libMongo "github.com/kloudlite/operator/pkg/mongo"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/rotation"
+	"github.com/kloudlite/operator/pkg/templates"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rotateCredentials switches the database over to its alternate user, with a fresh password, as mongodb users can't have two passwords.
+// The previous user is dropped, once the grace period ends, and consumers have restarted
+func (r *Reconciler) rotateCredentials(req *rApi.Request[*mongodbMsvcv1.Database]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(CredentialsRotated, req)
+
+	if err := rotation.EnsureConsumersRestarted(ctx, r.Client, obj, obj.Output.CredentialsRef.Name); err != nil {
+		return check.StillRunning(err)
+	}
+
+	now := time.Now()
+	_, revokePending := rotation.RevokeAt(obj)
+	if revokePending && !rotation.CanRevoke(obj, now) {
+		return check.Completed()
+	}
+	if !revokePending && !rotation.IsDue(obj, obj.Spec.Rotation, now) {
+		return check.Completed()
+	}
+
+	scrt, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Output.CredentialsRef.Name), &corev1.Secret{})
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	mresOutput, err := fn.ParseFromSecret[types.MresOutput](scrt)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+
+	msvcHosts, msvcURI, err := r.getMsvcConnectionParams(ctx, obj)
+	if err != nil {
+		return check.Failed(err)
+	}
+
+	mctx, cancel := r.newMongoContext(ctx)
+	defer cancel()
+	mongoCli, err := libMongo.NewClient(mctx, msvcURI)
+	if err != nil {
+		return check.Failed(err)
+	}
+	defer mongoCli.Close()
+
+	if revokePending {
+		if err := mongoCli.DeleteUser(ctx, mresOutput.DbName, rotation.AlternateUsername(obj.Name, mresOutput.Username)); err != nil {
+			return check.Failed(err)
+		}
+		rotation.MarkRevoked(obj)
+	} else {
+		username := rotation.AlternateUsername(obj.Name, mresOutput.Username)
+		password := fn.CleanerNanoid(40)
+
+		exists, err := mongoCli.UserExists(ctx, mresOutput.DbName, username)
+		if err != nil {
+			return check.Failed(err)
+		}
+
+		if exists {
+			err = mongoCli.UpdateUserPassword(ctx, mresOutput.DbName, username, password)
+		} else {
+			err = mongoCli.UpsertUser(ctx, mresOutput.DbName, username, password)
+		}
+		if err != nil {
+			return check.Failed(err)
+		}
+
+		b, err := templates.Parse(
+			templates.Secret, map[string]any{
+				"name":       scrt.Name,
+				"namespace":  scrt.Namespace,
+				"owner-refs": obj.GetOwnerReferences(),
+				"string-data": types.MresOutput{
+					Username: username,
+					Password: password,
+					Hosts:    msvcHosts,
+					DbName:   mresOutput.DbName,
+					URI:      mresURI(obj, username, password, msvcHosts),
+				},
+			},
+		)
+		if err != nil {
+			return check.Failed(err).Err(nil)
+		}
+
+		if _, err := r.yamlClient.ApplyYAML(ctx, b); err != nil {
+			return check.Failed(err)
+		}
+
+		rotation.MarkRotated(obj, obj.Spec.Rotation, now)
+	}
+
+	if err := rApi.UpdatePreservingStatus(ctx, r.Client, obj); err != nil {
+		return check.StillRunning(err)
+	}
+
+	if err := rotation.EnsureConsumersRestarted(ctx, r.Client, obj, scrt.Name); err != nil {
+		return check.StillRunning(err)
+	}
+
+	return check.Completed()
+}
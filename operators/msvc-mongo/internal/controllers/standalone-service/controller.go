@@ -4,9 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
 
-	ct "github.com/kloudlite/operator/apis/common-types"
 	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
 	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
 	"github.com/kloudlite/operator/operators/msvc-mongo/internal/env"
@@ -18,10 +16,9 @@ import (
 	"github.com/kloudlite/operator/pkg/logging"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/resize"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	apiErrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiLabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -59,17 +56,15 @@ const (
 	DefaultsPatched string = "defaults-patched"
 	Cleanup         string = "cleanup"
 	KeyMsvcOutput   string = "msvc-output"
-
-	AnnotationCurrentStorageSize string = "kloudlite.io/msvc.storage-size"
 )
 
-var ApplyCheckList = []rApi.CheckMeta{
+var ApplyCheckList = append([]rApi.CheckMeta{
 	{Name: DefaultsPatched, Title: "Defaults Patched", Debug: true},
 	{Name: AccessCredsGenerated, Title: "Access Credentials Generated"},
 	{Name: MongoDBHelmApplied, Title: "MongoDB Helm Applied"},
 	{Name: MongoDBHelmReady, Title: "MongoDB Helm Ready"},
 	{Name: MongoDBStatefulSetsReady, Title: "MongoDB StatefulSets Ready"},
-}
+}, resize.CheckList...)
 
 // DefaultsPatched string = "defaults-patched"
 var DeleteCheckList = []rApi.CheckMeta{
@@ -81,7 +76,7 @@ var DeleteCheckList = []rApi.CheckMeta{
 // +kubebuilder:rbac:groups=mongodb.msvc.kloudlite.io,resources=services/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &mongodbMsvcv1.StandaloneService{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &mongodbMsvcv1.StandaloneService{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -112,6 +107,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := resize.ExpandStorage(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := resize.RecreateStatefulSets(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	if step := r.applyMongoDBStandaloneHelm(req); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
@@ -124,6 +127,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := resize.RollPods(req, r.Client); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
 	return ctrl.Result{}, nil
 }
@@ -222,122 +229,38 @@ func (r *Reconciler) applyMongoDBStandaloneHelm(req *rApi.Request[*mongodbMsvcv1
 	ctx, obj := req.Context(), req.Object
 	check := rApi.NewRunningCheck(MongoDBHelmApplied, req)
 
-	hc, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &crdsv1.HelmChart{})
-	if err != nil {
-		if !apiErrors.IsNotFound(err) {
-			return check.Failed(err)
-		}
-		hc = nil
-	}
-
-	if hc == nil {
-		fn.MapSet(&obj.Annotations, AnnotationCurrentStorageSize, string(obj.Spec.Resources.Storage.Size))
-		if err := r.Update(ctx, obj); err != nil {
-			return check.Failed(err)
-		}
-
-		b, err := templates.ParseBytes(r.templateHelmMongoDB, map[string]any{
-			"name":          obj.Name,
-			"namespace":     obj.Namespace,
-			"labels":        obj.GetLabels(),
-			"owner-refs":    []metav1.OwnerReference{fn.AsOwner(obj, true)},
-			"node-selector": obj.Spec.NodeSelector,
-			"tolerations":   obj.Spec.Tolerations,
+	b, err := templates.ParseBytes(r.templateHelmMongoDB, map[string]any{
+		"name":          obj.Name,
+		"namespace":     obj.Namespace,
+		"labels":        obj.GetLabels(),
+		"owner-refs":    []metav1.OwnerReference{fn.AsOwner(obj, true)},
+		"node-selector": obj.Spec.NodeSelector,
+		"tolerations":   obj.Spec.Tolerations,
 
-			"pod-labels":      obj.GetLabels(),
-			"pod-annotations": fn.FilterObservabilityAnnotations(obj.GetAnnotations()),
+		"pod-labels":      obj.GetLabels(),
+		"pod-annotations": fn.FilterObservabilityAnnotations(obj.GetAnnotations()),
 
-			"storage-class": obj.Spec.Resources.Storage.StorageClass,
-			"storage-size":  obj.Spec.Resources.Storage.Size,
+		"storage-class": obj.Spec.Resources.Storage.StorageClass,
+		"storage-size":  obj.Spec.Resources.Storage.Size,
 
-			"requests-cpu": obj.Spec.Resources.Cpu.Min,
-			"requests-mem": obj.Spec.Resources.Memory.Min,
+		"requests-cpu": obj.Spec.Resources.Cpu.Min,
+		"requests-mem": obj.Spec.Resources.Memory.Min,
 
-			"limits-cpu": obj.Spec.Resources.Cpu.Max,
-			"limits-mem": obj.Spec.Resources.Memory.Max,
+		"limits-cpu": obj.Spec.Resources.Cpu.Max,
+		"limits-mem": obj.Spec.Resources.Memory.Max,
 
-			"existing-secret": getHelmSecretName(obj.Name),
-		})
-		if err != nil {
-			return check.Failed(err).Err(nil)
-		}
-
-		if _, err := r.yamlClient.ApplyYAML(ctx, b); err != nil {
-			return check.Failed(err)
-		}
-
-		return req.Done().RequeueAfter(500 * time.Millisecond)
-	}
-
-	req.AddToOwnedResources(rApi.ParseResourceRef(hc))
-
-	shouldUpdatePVCs := false
-
-	oldSize, ok := obj.GetAnnotations()[AnnotationCurrentStorageSize]
-	if ok {
-		oldSizeNum, err := ct.StorageSize(oldSize).ToInt()
-		if err != nil {
-			return check.Failed(err).Err(nil)
-		}
-
-		newSizeNum, err := ct.StorageSize(obj.Spec.Resources.Storage.Size).ToInt()
-		if err != nil {
-			return check.Failed(err).Err(nil)
-		}
-
-		if oldSizeNum > newSizeNum {
-			return check.Failed(fmt.Errorf("new storage size (%s), must be higher than or equal to old size (%s)", obj.Spec.Resources.Storage.Size, oldSize)).Err(nil)
-		}
-
-		shouldUpdatePVCs = newSizeNum > oldSizeNum
+		"existing-secret": getHelmSecretName(obj.Name),
+	})
+	if err != nil {
+		return check.Failed(err).Err(nil)
 	}
 
-	if !ok {
-		shouldUpdatePVCs = true
+	rr, err := r.yamlClient.ApplyYAML(ctx, b)
+	if err != nil {
+		return check.Failed(err)
 	}
 
-	if shouldUpdatePVCs {
-		// need to do something
-		// 1. Patch the PVC directly
-		// 2. Rollout the Statefulsets
-
-		ss, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &appsv1.StatefulSet{})
-		if err != nil {
-			if !apiErrors.IsNotFound(err) {
-				return check.Failed(err).Err(nil)
-			}
-			ss = nil
-		}
-
-		if ss != nil {
-			m := types.ExtractPVCLabelsFromStatefulSetLabels(ss.GetLabels())
-
-			var pvclist corev1.PersistentVolumeClaimList
-			if err := r.List(ctx, &pvclist, &client.ListOptions{
-				LabelSelector: apiLabels.SelectorFromValidatedSet(m),
-				Namespace:     obj.Namespace,
-			}); err != nil {
-				return check.Failed(err)
-			}
-
-			for i := range pvclist.Items {
-				pvclist.Items[i].Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse(string(obj.Spec.Resources.Storage.Size))
-				if err := r.Update(ctx, &pvclist.Items[i]); err != nil {
-					return check.Failed(err)
-				}
-			}
-
-			// STEP 2: rollout statefulset
-			if err := fn.RolloutRestart(r.Client, fn.StatefulSet, obj.Namespace, ss.GetLabels()); err != nil {
-				return check.Failed(err)
-			}
-
-			fn.MapSet(&obj.Annotations, AnnotationCurrentStorageSize, string(obj.Spec.Resources.Storage.Size))
-			if err := r.Update(ctx, obj); err != nil {
-				return check.Failed(err)
-			}
-		}
-	}
+	req.AddToOwnedResources(rr...)
 
 	return check.Completed()
 }
@@ -8,6 +8,11 @@ type Env struct {
 	IsDev                   bool
 	MaxConcurrentReconciles int    `env:"MAX_CONCURRENT_RECONCILES"`
 	ClusterInternalDNS      string `env:"CLUSTER_INTERNAL_DNS"`
+
+	// BackupJobImage runs backups, and restores. It needs bash, mongodb database tools, and the aws cli
+	BackupJobImage string `env:"BACKUP_JOB_IMAGE"`
+	// BackupCredentialsNamespaces are comma separated namespaces, besides their own, that backups, and restores may read s3 credentials from
+	BackupCredentialsNamespaces []string `env:"BACKUP_CREDENTIALS_NAMESPACES"`
 }
 
 func GetEnvOrDie() *Env {
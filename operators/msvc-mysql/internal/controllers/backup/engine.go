@@ -0,0 +1,57 @@
+package backup
+
+import (
+	ct "github.com/kloudlite/operator/apis/common-types"
+	mysqlMsvcv1 "github.com/kloudlite/operator/apis/mysql.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-mysql/internal/env"
+	"github.com/kloudlite/operator/pkg/backup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// connectCmd splits the first of HOSTS into host, and port, for mysql clients
+const connectCmd = `HOST="${HOSTS%%,*}"; PORT=3306; case "$HOST" in *:*) PORT="${HOST##*:}"; HOST="${HOST%:*}" ;; esac; export MYSQL_PWD="$PASSWORD"`
+
+// dumpCmd leaves out the database name, so that the dump can be restored into another database. Tablespaces require a privilege, the
+// database user does not have
+const dumpCmd = `mysqldump --host "$HOST" --port "$PORT" --user "$USERNAME" --single-transaction --no-tablespaces "$DB_NAME" | gzip`
+
+// restoreCmd replays the dump, that drops tables before recreating them, into the database restored into
+const restoreCmd = `gunzip | mysql --host "$HOST" --port "$PORT" --user "$USERNAME" "$DB_NAME"`
+
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=backups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=backups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=backups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=backupschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=backupschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=backupschedules/finalizers,verbs=update
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=restores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=restores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=restores/finalizers,verbs=update
+
+func NewEngine(ev *env.Env) *backup.Engine[*mysqlMsvcv1.BackupSchedule, *mysqlMsvcv1.Backup, *mysqlMsvcv1.Restore, *mysqlMsvcv1.Database] {
+	return &backup.Engine[*mysqlMsvcv1.BackupSchedule, *mysqlMsvcv1.Backup, *mysqlMsvcv1.Restore, *mysqlMsvcv1.Database]{
+		NewSchedule:   func() *mysqlMsvcv1.BackupSchedule { return &mysqlMsvcv1.BackupSchedule{} },
+		NewBackup:     func() *mysqlMsvcv1.Backup { return &mysqlMsvcv1.Backup{} },
+		NewBackupList: func() client.ObjectList { return &mysqlMsvcv1.BackupList{} },
+		NewRestore:    func() *mysqlMsvcv1.Restore { return &mysqlMsvcv1.Restore{} },
+		NewDatabase:   func() *mysqlMsvcv1.Database { return &mysqlMsvcv1.Database{} },
+		NewRestoreTarget: func(name string, namespace string, msvc ct.MsvcRef) *mysqlMsvcv1.Database {
+			return &mysqlMsvcv1.Database{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       mysqlMsvcv1.DatabaseSpec{MsvcRef: msvc, ResourceName: name},
+			}
+		},
+		// database controller names the access secret after the database
+		DatabaseSecret: func(db *mysqlMsvcv1.Database) string { return "mres-" + db.Name },
+
+		ArtifactExt:   "sql.gz",
+		SetupScript:   connectCmd,
+		DumpScript:    dumpCmd,
+		RestoreScript: restoreCmd,
+
+		JobImage:                ev.BackupJobImage,
+		CredentialsNamespaces:   ev.BackupCredentialsNamespaces,
+		MaxConcurrentReconciles: ev.MaxConcurrentReconciles,
+	}
+}
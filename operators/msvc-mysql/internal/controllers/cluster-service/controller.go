@@ -18,11 +18,11 @@ import (
 	"github.com/kloudlite/operator/pkg/logging"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/resize"
 	"github.com/kloudlite/operator/pkg/templates"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -49,7 +49,6 @@ const (
 	HelmReady        string = "helm-ready"
 	StsReady         string = "sts-ready"
 	AccessCredsReady string = "access-creds-ready"
-	PVCReady         string = "pvc-ready"
 )
 
 const (
@@ -104,6 +103,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := resize.ExpandStorage(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := resize.RecreateStatefulSets(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	if step := r.reconHelm(req); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
@@ -112,7 +119,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
-	if step := r.reconPVC(req); !step.ShouldProceed() {
+	if step := resize.RollPods(req, r.Client); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
@@ -204,7 +211,7 @@ func (r *Reconciler) reconAccessCreds(req *rApi.Request[*mysqlMsvcv1.ClusterServ
 					OwnerReferences: []metav1.OwnerReference{fn.AsOwner(obj, true)},
 				},
 				StringData: map[string]string{
-					KeyStsPvcInitSize:            obj.Spec.Resources.Storage.Size,
+					KeyStsPvcInitSize:            string(obj.Spec.Resources.Storage.Size),
 					"mysql-root-password":        output.RootPassword,
 					"mysql-replication-password": output.ReplicationPassword,
 					"mysql-password":             "",
@@ -344,79 +351,6 @@ func (r *Reconciler) reconSts(req *rApi.Request[*mysqlMsvcv1.ClusterService]) st
 	return req.Next()
 }
 
-func (r *Reconciler) reconPVC(req *rApi.Request[*mysqlMsvcv1.ClusterService]) stepResult.Result {
-	ctx, obj, checks := req.Context(), req.Object, req.Object.Status.Checks
-	check := rApi.Check{Generation: obj.Generation}
-
-	var pvcList corev1.PersistentVolumeClaimList
-	if err := r.List(
-		ctx, &pvcList, &client.ListOptions{
-			LabelSelector: labels.SelectorFromValidatedSet(
-				map[string]string{
-					constants.MsvcNameKey: obj.Name,
-				},
-			),
-			Namespace: obj.Namespace,
-		},
-	); err != nil {
-		return req.CheckFailed(PVCReady, check, err.Error())
-	}
-
-	hasResized := false
-
-	for _, pvc := range pvcList.Items {
-		currSize, ok := pvc.Spec.Resources.Requests.Storage().AsInt64()
-		if !ok {
-			return req.CheckFailed(PVCReady, check, "storage can't be converted to int64")
-		}
-
-		newSize, err := obj.Spec.Resources.Storage.ToInt()
-		if err != nil {
-			return req.CheckFailed(PVCReady, check, err.Error()).Err(nil)
-		}
-
-		if currSize < newSize {
-			hasResized = true
-			pvc.Spec.Resources.Requests = corev1.ResourceList{
-				corev1.ResourceStorage: resource.MustParse(obj.Spec.Resources.Storage.Size),
-			}
-
-			if err := r.Update(ctx, &pvc); err != nil {
-				return req.CheckFailed(PVCReady, check, err.Error())
-			}
-		}
-	}
-
-	if hasResized {
-		var podsList corev1.PodList
-		if err := r.List(
-			ctx, &podsList, &client.ListOptions{
-				LabelSelector: labels.SelectorFromValidatedSet(
-					map[string]string{
-						constants.MsvcNameKey: obj.Name,
-					},
-				),
-				Namespace: obj.Namespace,
-			},
-		); err != nil {
-			return req.CheckFailed(PVCReady, check, err.Error()).Err(nil)
-		}
-
-		for _, pod := range podsList.Items {
-			if err := r.Delete(ctx, &pod); err != nil {
-				return req.CheckFailed(PVCReady, check, err.Error())
-			}
-		}
-	}
-
-	check.Status = true
-	if check != checks[PVCReady] {
-		checks[PVCReady] = check
-		return req.UpdateStatus()
-	}
-	return req.Next()
-}
-
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) error {
 	r.Client = mgr.GetClient()
 	r.Scheme = mgr.GetScheme()
@@ -17,6 +17,7 @@ import (
 	libMysql "github.com/kloudlite/operator/pkg/mysql"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/rotation"
 	"github.com/kloudlite/operator/pkg/templates"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +47,8 @@ const (
 	AccessCredsReady string = "access-creds"
 	IsOwnedByMsvc    string = "is-owned-by-msvc"
 	DBUserDeleted    string = "db-user-deleted"
+
+	CredentialsRotated string = "credentials-rotated"
 )
 
 const (
@@ -58,7 +61,7 @@ const (
 // +kubebuilder:rbac:groups=mysql.msvc.kloudlite.io,resources=databases/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &mysqlMsvcv1.Database{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &mysqlMsvcv1.Database{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -81,7 +84,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
-	if step := req.EnsureChecks(DBUserReady, AccessCredsReady, IsOwnedByMsvc); !step.ShouldProceed() {
+	if step := req.EnsureChecks(DBUserReady, AccessCredsReady, IsOwnedByMsvc, CredentialsRotated); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
@@ -105,9 +108,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.rotateCredentials(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
 	req.Object.Status.LastReconcileTime = &metav1.Time{Time: time.Now()}
-	return ctrl.Result{RequeueAfter: r.Env.ReconcilePeriod * time.Second}, r.Status().Update(ctx, req.Object)
+
+	requeueAfter := r.Env.ReconcilePeriod * time.Second
+	if d := rotation.RequeueAfter(req.Object, req.Object.Spec.Rotation, time.Now()); d > 0 && d < requeueAfter {
+		requeueAfter = d
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, r.Status().Update(ctx, req.Object)
 }
 
 func (r *Reconciler) finalize(req *rApi.Request[*mysqlMsvcv1.Database]) stepResult.Result {
@@ -190,6 +202,22 @@ func sanitizeDbUsername(username string) string {
 	return fn.Md5([]byte(username))
 }
 
+func newMresOutput(msvcOutput *types.MsvcOutput, dbName string, dbUsername string, dbPasswd string) types.MresOutput {
+	mresOutput := types.MresOutput{
+		Username: dbUsername,
+		Password: dbPasswd,
+		Hosts:    msvcOutput.Hosts,
+		DbName:   dbName,
+		DSN:      fmt.Sprintf("mysql://%s:%s@tcp(%s)/%s", dbUsername, dbPasswd, msvcOutput.Hosts, dbName),
+		URI:      fmt.Sprintf("mysql://%s:%s@%s/%s", dbUsername, dbPasswd, msvcOutput.Hosts, dbName),
+	}
+
+	if msvcOutput.ExternalHost != "" {
+		mresOutput.ExternalDSN = fmt.Sprintf("mysql://%s:%s@tcp(%s)/%s", dbUsername, dbPasswd, msvcOutput.ExternalHost, dbName)
+	}
+	return mresOutput
+}
+
 func (r *Reconciler) reconDBCreds(req *rApi.Request[*mysqlMsvcv1.Database]) stepResult.Result {
 	ctx, obj, checks := req.Context(), req.Object, req.Object.Status.Checks
 	check := rApi.Check{Generation: obj.Generation}
@@ -217,24 +245,11 @@ func (r *Reconciler) reconDBCreds(req *rApi.Request[*mysqlMsvcv1.Database]) step
 		dbName := sanitizeDbName(obj.Spec.ResourceName)
 		dbUsername := sanitizeDbUsername(obj.Spec.ResourceName)
 
-		mresOutput := types.MresOutput{
-			Username: dbUsername,
-			Password: dbPasswd,
-			Hosts:    msvcOutput.Hosts,
-			DbName:   dbName,
-			DSN:      fmt.Sprintf("mysql://%s:%s@tcp(%s)/%s", dbUsername, dbPasswd, msvcOutput.Hosts, dbName),
-			URI:      fmt.Sprintf("mysql://%s:%s@%s/%s", dbUsername, dbPasswd, msvcOutput.Hosts, dbName),
-		}
-
-		if msvcOutput.ExternalHost != "" {
-			mresOutput.ExternalDSN = fmt.Sprintf("mysql://%s:%s@tcp(%s)/%s", dbUsername, dbPasswd, msvcOutput.ExternalHost, dbName)
-		}
-
 		b, err := templates.Parse(templates.Secret, map[string]any{
 			"name":        accessSecretName,
 			"namespace":   obj.Namespace,
 			"owner-refs":  obj.GetOwnerReferences(),
-			"string-data": mresOutput,
+			"string-data": newMresOutput(msvcOutput, dbName, dbUsername, dbPasswd),
 		})
 		if err != nil {
 			return req.CheckFailed(AccessCredsReady, check, err.Error())
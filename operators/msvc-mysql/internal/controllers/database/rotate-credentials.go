@@ -0,0 +1,97 @@
+package database
+
+import (
+	"time"
+
+	mysqlMsvcv1 "github.com/kloudlite/operator/apis/mysql.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-mysql/internal/types"
+	"github.com/kloudlite/operator/pkg/errors"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	libMysql "github.com/kloudlite/operator/pkg/mysql"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/rotation"
+	"github.com/kloudlite/operator/pkg/templates"
+)
+
+// rotateCredentials sets a new password for the database user, retaining the current one through the grace period, as mysql allows
+// two passwords per user. The current one is retained until consumers have restarted, too
+func (r *Reconciler) rotateCredentials(req *rApi.Request[*mysqlMsvcv1.Database]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.Check{Generation: obj.Generation}
+
+	if err := rotation.EnsureConsumersRestarted(ctx, r.Client, obj, "mres-"+obj.Name); err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error())
+	}
+
+	now := time.Now()
+	_, revokePending := rotation.RevokeAt(obj)
+	if (revokePending && !rotation.CanRevoke(obj, now)) || (!revokePending && !rotation.IsDue(obj, obj.Spec.Rotation, now)) {
+		check.Status = true
+		if check != obj.Status.Checks[CredentialsRotated] {
+			obj.Status.Checks[CredentialsRotated] = check
+			req.UpdateStatus()
+		}
+		return req.Next()
+	}
+
+	msvcOutput, ok := rApi.GetLocal[types.MsvcOutput](req, KeyMsvcOutput)
+	if !ok {
+		return req.CheckFailed(CredentialsRotated, check, errors.NotInLocals(KeyMsvcOutput).Error()).Err(nil)
+	}
+
+	mresOutput, ok := rApi.GetLocal[types.MresOutput](req, KeyMresOutput)
+	if !ok {
+		return req.CheckFailed(CredentialsRotated, check, errors.NotInLocals(KeyMresOutput).Error()).Err(nil)
+	}
+
+	mysqlCli, err := libMysql.NewClient(msvcOutput.Hosts, "mysql", "root", msvcOutput.RootPassword)
+	if err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error()).Err(nil).RequeueAfter(5 * time.Second)
+	}
+
+	if err := mysqlCli.Connect(ctx); err != nil {
+		return req.CheckFailed(CredentialsRotated, check, errors.NewEf(err, "failed to connect to db").Error()).Err(nil).RequeueAfter(5 * time.Second)
+	}
+	defer mysqlCli.Close()
+
+	if revokePending {
+		if err := mysqlCli.DiscardOldPassword(mresOutput.Username); err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error())
+		}
+		rotation.MarkRevoked(obj)
+	} else {
+		password := fn.CleanerNanoid(40)
+		if err := mysqlCli.RotateUserPassword(mresOutput.Username, password); err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error())
+		}
+
+		b, err := templates.Parse(templates.Secret, map[string]any{
+			"name":        "mres-" + obj.Name,
+			"namespace":   obj.Namespace,
+			"owner-refs":  obj.GetOwnerReferences(),
+			"string-data": newMresOutput(&msvcOutput, mresOutput.DbName, mresOutput.Username, password),
+		})
+		if err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error()).Err(nil)
+		}
+
+		if _, err := r.yamlClient.ApplyYAML(ctx, b); err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error())
+		}
+
+		rotation.MarkRotated(obj, obj.Spec.Rotation, now)
+	}
+
+	if err := rApi.UpdatePreservingStatus(ctx, r.Client, obj); err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error())
+	}
+
+	if err := rotation.EnsureConsumersRestarted(ctx, r.Client, obj, "mres-"+obj.Name); err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error())
+	}
+
+	check.Status = true
+	obj.Status.Checks[CredentialsRotated] = check
+	return req.UpdateStatus()
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/kloudlite/operator/pkg/logging"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	"github.com/kloudlite/operator/pkg/resize"
 	"github.com/kloudlite/operator/pkg/templates"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -66,7 +67,7 @@ func getHelmSecretName(name string) string {
 
 func (r *ServiceReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
 	req, err := rApi.NewRequest(
-		rApi.NewReconcilerCtx(ctx, r.logger),
+		rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()),
 		r.Client,
 		request.NamespacedName,
 		&mysqlMsvcv1.StandaloneService{},
@@ -105,6 +106,14 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return step.ReconcilerResponse()
 	}
 
+	if step := resize.ExpandStorage(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := resize.RecreateStatefulSets(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	if step := r.reconHelm(req); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
@@ -113,6 +122,10 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return step.ReconcilerResponse()
 	}
 
+	if step := resize.RollPods(req, r.Client); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
 	req.Object.Status.LastReconcileTime = &metav1.Time{Time: time.Now()}
 	return ctrl.Result{RequeueAfter: r.Env.ReconcilePeriod}, r.Status().Update(ctx, req.Object)
@@ -9,6 +9,11 @@ import (
 type Env struct {
 	ReconcilePeriod         time.Duration `env:"RECONCILE_PERIOD"`
 	MaxConcurrentReconciles int           `env:"MAX_CONCURRENT_RECONCILES"`
+
+	// BackupJobImage runs backups, and restores. It needs bash, the mysql client, and the aws cli
+	BackupJobImage string `env:"BACKUP_JOB_IMAGE"`
+	// BackupCredentialsNamespaces are comma separated namespaces, besides their own, that backups, and restores may read s3 credentials from
+	BackupCredentialsNamespaces []string `env:"BACKUP_CREDENTIALS_NAMESPACES"`
 }
 
 func GetEnvOrDie() *Env {
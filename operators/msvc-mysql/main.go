@@ -1,8 +1,10 @@
 package main
 
 import (
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
 	mysqlMsvcv1 "github.com/kloudlite/operator/apis/mysql.msvc/v1"
 	"github.com/kloudlite/operator/operator"
+	"github.com/kloudlite/operator/operators/msvc-mysql/internal/controllers/backup"
 	clusterService "github.com/kloudlite/operator/operators/msvc-mysql/internal/controllers/cluster-service"
 	"github.com/kloudlite/operator/operators/msvc-mysql/internal/controllers/database"
 	standaloneService "github.com/kloudlite/operator/operators/msvc-mysql/internal/controllers/standalone-service"
@@ -14,11 +16,13 @@ func main() {
 	mgr := operator.New("msvc-mysql")
 	mgr.AddToSchemes(
 		mysqlMsvcv1.AddToScheme,
+		crdsv1.AddToScheme,
 	)
 	mgr.RegisterControllers(
 		&standaloneService.ServiceReconciler{Name: "standalone-svc", Env: ev},
 		&clusterService.Reconciler{Name: "cluster-svc", Env: ev},
 		&database.Reconciler{Name: "database", Env: ev},
 	)
+	mgr.RegisterControllers(backup.NewEngine(ev).Reconcilers("")...)
 	mgr.Start()
 }
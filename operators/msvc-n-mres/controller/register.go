@@ -7,6 +7,7 @@ import (
 	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
 	mysqlMsvcv1 "github.com/kloudlite/operator/apis/mysql.msvc/v1"
 	neo4jMsvcv1 "github.com/kloudlite/operator/apis/neo4j.msvc/v1"
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
 	redisMsvcv1 "github.com/kloudlite/operator/apis/redis.msvc/v1"
 	redpandaMsvcv1 "github.com/kloudlite/operator/apis/redpanda.msvc/v1"
 	zookeeperMsvcv1 "github.com/kloudlite/operator/apis/zookeeper.msvc/v1"
@@ -24,6 +25,7 @@ func RegisterInto(mgr operator.Operator) {
 		crdsv1.AddToScheme,
 		mongodbMsvcv1.AddToScheme,
 		mysqlMsvcv1.AddToScheme,
+		postgresMsvcv1.AddToScheme,
 		redisMsvcv1.AddToScheme,
 		elasticsearchMsvcv1.AddToScheme,
 		influxdbMsvcv1.AddToScheme,
@@ -37,4 +39,5 @@ func RegisterInto(mgr operator.Operator) {
 		&msvc.Reconciler{Name: "msvc", Env: ev},
 		&mres.Reconciler{Name: "mres", Env: ev},
 	)
+	mgr.RegisterWebhooks(&crdsv1.ManagedResource{})
 }
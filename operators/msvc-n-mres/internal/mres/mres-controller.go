@@ -11,8 +11,10 @@ import (
 	influxdbMsvcv1 "github.com/kloudlite/operator/apis/influxdb.msvc/v1"
 	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
 	mysqlMsvcv1 "github.com/kloudlite/operator/apis/mysql.msvc/v1"
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
 	redisMsvcv1 "github.com/kloudlite/operator/apis/redis.msvc/v1"
 	"github.com/kloudlite/operator/operators/msvc-n-mres/internal/env"
+	"github.com/kloudlite/operator/operators/msvc-n-mres/internal/plugin"
 	"github.com/kloudlite/operator/operators/msvc-n-mres/internal/templates"
 	"github.com/kloudlite/operator/pkg/constants"
 	fn "github.com/kloudlite/operator/pkg/functions"
@@ -21,6 +23,8 @@ import (
 	"github.com/kloudlite/operator/pkg/logging"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -71,7 +75,7 @@ var DeleteCheckList = []rApi.CheckMeta{
 // +kubebuilder:rbac:groups=crds.kloudlite.io,resources=crds/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &crdsv1.ManagedResource{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &crdsv1.ManagedResource{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -110,11 +114,17 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	// 	return step.ReconcilerResponse()
 	// }
 
-	if step := r.ensureRealMresCreated(req); !step.ShouldProceed() {
+	// resources of kinds, declared by a plugin, are provisioned from it, instead of a real managed resource
+	_, pres, err := plugin.ForResource(ctx, r.Client, req.Object.Spec.ResourceTemplate.APIVersion, req.Object.Spec.ResourceTemplate.Kind)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if step := r.ensureRealMresCreated(req, pres); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
-	if step := r.ensureRealMresReady(req); !step.ShouldProceed() {
+	if step := r.ensureRealMresReady(req, pres); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
@@ -122,14 +132,6 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	return ctrl.Result{}, nil
 }
 
-func getRealResourceName(obj *crdsv1.ManagedResource) string {
-	if obj.Spec.ResourceNamePrefix != nil {
-		return fmt.Sprintf("%s-%s", *obj.Spec.ResourceNamePrefix, obj.Name)
-	}
-
-	return obj.Name
-}
-
 func (r *Reconciler) patchDefaults(req *rApi.Request[*crdsv1.ManagedResource]) stepResult.Result {
 	ctx, obj := req.Context(), req.Object
 	check := rApi.Check{Generation: obj.Generation, State: rApi.RunningState}
@@ -143,14 +145,7 @@ func (r *Reconciler) patchDefaults(req *rApi.Request[*crdsv1.ManagedResource]) s
 		return req.CheckFailed(checkName, check, err.Error())
 	}
 
-	hasUpdated := false
-
-	if obj.Output.CredentialsRef.Name == "" {
-		hasUpdated = true
-		obj.Output.CredentialsRef.Name = fmt.Sprintf("mres-%s-creds", getRealResourceName(obj))
-	}
-
-	if hasUpdated {
+	if crdsv1.ApplyDefaults(obj) {
 		if err := r.Update(ctx, obj); err != nil {
 			return fail(err)
 		}
@@ -240,24 +235,12 @@ func (r *Reconciler) ensureOwnedByMsvc(req *rApi.Request[*crdsv1.ManagedResource
 	return req.Next()
 }
 
-func (r *Reconciler) ensureRealMresCreated(req *rApi.Request[*crdsv1.ManagedResource]) stepResult.Result {
-	ctx, obj := req.Context(), req.Object
-	check := rApi.Check{Generation: obj.Generation, State: rApi.RunningState}
-
-	checkName := UnderlyingManagedResourceCreated
-
-	req.LogPreCheck(checkName)
-	defer req.LogPostCheck(checkName)
-
-	fail := func(err error) stepResult.Result {
-		return req.CheckFailed(checkName, check, err.Error())
-	}
-
+func (r *Reconciler) applyResourceTemplate(ctx context.Context, obj *crdsv1.ManagedResource) ([]rApi.ResourceRef, error) {
 	b, err := templates.ParseBytes(r.templateCommonMres, map[string]any{
 		"api-version": obj.Spec.ResourceTemplate.APIVersion,
 		"kind":        obj.Spec.ResourceTemplate.Kind,
 
-		"name":       getRealResourceName(obj),
+		"name":       obj.RealResourceName(),
 		"namespace":  obj.Namespace,
 		"owner-refs": []metav1.OwnerReference{fn.AsOwner(obj, true)},
 		"labels":     obj.GetEnsuredLabels(),
@@ -268,10 +251,71 @@ func (r *Reconciler) ensureRealMresCreated(req *rApi.Request[*crdsv1.ManagedReso
 		"output": obj.Output,
 	})
 	if err != nil {
-		return fail(err).Err(nil)
+		return nil, err
+	}
+
+	return r.yamlClient.ApplyYAML(ctx, b)
+}
+
+func (r *Reconciler) pluginValues(ctx context.Context, obj *crdsv1.ManagedResource) (plugin.Values, error) {
+	msvcRef := obj.Spec.ResourceTemplate.MsvcRef
+	msvcValues, err := plugin.MsvcValues(ctx, r.Client, msvcRef.Namespace, msvcRef.Name)
+	if err != nil {
+		return plugin.Values{}, err
+	}
+	return plugin.ResourceValues(obj, msvcValues)
+}
+
+// applyPlugin creates the output secret of obj, and the helm chart, and manifests of its plugin resource
+func (r *Reconciler) applyPlugin(ctx context.Context, obj *crdsv1.ManagedResource, pres *crdsv1.PluginResource) ([]rApi.ResourceRef, error) {
+	values, err := r.pluginValues(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := fn.AsOwner(obj, true)
+
+	values.Output, err = plugin.EnsureOutput(ctx, r.Client, &pres.PluginTemplate, obj.Output.CredentialsRef.Name, values, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := plugin.RenderObjects(&pres.PluginTemplate, values, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []rApi.ResourceRef
+	for i := range objects {
+		rr, err := r.yamlClient.Apply(ctx, objects[i])
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, rr...)
+	}
+	return refs, nil
+}
+
+func (r *Reconciler) ensureRealMresCreated(req *rApi.Request[*crdsv1.ManagedResource], pres *crdsv1.PluginResource) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.Check{Generation: obj.Generation, State: rApi.RunningState}
+
+	checkName := UnderlyingManagedResourceCreated
+
+	req.LogPreCheck(checkName)
+	defer req.LogPostCheck(checkName)
+
+	fail := func(err error) stepResult.Result {
+		return req.CheckFailed(checkName, check, err.Error())
 	}
 
-	rr, err := r.yamlClient.ApplyYAML(ctx, b)
+	var rr []rApi.ResourceRef
+	var err error
+	if pres != nil {
+		rr, err = r.applyPlugin(ctx, obj, pres)
+	} else {
+		rr, err = r.applyResourceTemplate(ctx, obj)
+	}
 	if err != nil {
 		return fail(err)
 	}
@@ -289,7 +333,7 @@ func (r *Reconciler) ensureRealMresCreated(req *rApi.Request[*crdsv1.ManagedReso
 	return req.Next()
 }
 
-func (r *Reconciler) ensureRealMresReady(req *rApi.Request[*crdsv1.ManagedResource]) stepResult.Result {
+func (r *Reconciler) ensureRealMresReady(req *rApi.Request[*crdsv1.ManagedResource], pres *crdsv1.PluginResource) stepResult.Result {
 	ctx, obj := req.Context(), req.Object
 	check := rApi.Check{Generation: obj.Generation, State: rApi.RunningState}
 
@@ -302,6 +346,44 @@ func (r *Reconciler) ensureRealMresReady(req *rApi.Request[*crdsv1.ManagedResour
 		return req.CheckFailed(checkName, check, err.Error())
 	}
 
+	if pres != nil {
+		values, err := r.pluginValues(ctx, obj)
+		if err != nil {
+			return fail(err)
+		}
+
+		msg, err := plugin.NotReady(ctx, r.Client, &pres.PluginTemplate, values)
+		if err != nil {
+			return fail(err)
+		}
+		if msg != "" {
+			return req.CheckFailed(checkName, check, msg).Err(nil).RequeueAfter(plugin.ProbeInterval)
+		}
+	} else if sr := r.checkRealMresReady(req, check); !sr.ShouldProceed() {
+		return sr
+	}
+
+	check.Status = true
+	if check != obj.Status.Checks[checkName] {
+		fn.MapSet(&obj.Status.Checks, checkName, check)
+		if sr := req.UpdateStatus(); !sr.ShouldProceed() {
+			return sr
+		}
+	}
+
+	return req.Next()
+}
+
+// checkRealMresReady waits for the real managed resource to report itself ready, through its status
+func (r *Reconciler) checkRealMresReady(req *rApi.Request[*crdsv1.ManagedResource], check rApi.Check) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+
+	checkName := UnderlyingManagedResourceReady
+
+	fail := func(err error) stepResult.Result {
+		return req.CheckFailed(checkName, check, err.Error())
+	}
+
 	uobj := unstructured.Unstructured{
 		Object: map[string]any{
 			"apiVersion": obj.Spec.ResourceTemplate.APIVersion,
@@ -309,7 +391,7 @@ func (r *Reconciler) ensureRealMresReady(req *rApi.Request[*crdsv1.ManagedResour
 		},
 	}
 
-	if err := r.Get(ctx, fn.NN(obj.GetNamespace(), getRealResourceName(obj)), &uobj); err != nil {
+	if err := r.Get(ctx, fn.NN(obj.GetNamespace(), obj.RealResourceName()), &uobj); err != nil {
 		return fail(err)
 	}
 
@@ -334,14 +416,6 @@ func (r *Reconciler) ensureRealMresReady(req *rApi.Request[*crdsv1.ManagedResour
 		return fail(fmt.Errorf("%s", b)).Err(nil)
 	}
 
-	check.Status = true
-	if check != obj.Status.Checks[checkName] {
-		fn.MapSet(&obj.Status.Checks, checkName, check)
-		if sr := req.UpdateStatus(); !sr.ShouldProceed() {
-			return sr
-		}
-	}
-
 	return req.Next()
 }
 
@@ -375,10 +449,20 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) e
 		&mysqlMsvcv1.StandaloneService{},
 		&mysqlMsvcv1.Database{},
 
+		&postgresMsvcv1.ClusterService{},
+		&postgresMsvcv1.StandaloneService{},
+		&postgresMsvcv1.Database{},
+
 		&influxdbMsvcv1.Bucket{},
 		&influxdbMsvcv1.Service{},
 	}
 
+	// resources, plugins provision managed resources with
+	builder.Owns(&crdsv1.HelmChart{})
+	builder.Owns(&appsv1.Deployment{})
+	builder.Owns(&appsv1.StatefulSet{})
+	builder.Owns(&batchv1.Job{})
+
 	for _, obj := range children {
 		builder.Watches(
 			obj,
@@ -13,10 +13,12 @@ import (
 	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
 	mysqlMsvcv1 "github.com/kloudlite/operator/apis/mysql.msvc/v1"
 	neo4jmsvcv1 "github.com/kloudlite/operator/apis/neo4j.msvc/v1"
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
 	redisMsvcv1 "github.com/kloudlite/operator/apis/redis.msvc/v1"
 	redpandamsvcv1 "github.com/kloudlite/operator/apis/redpanda.msvc/v1"
 	zookeeperMsvcv1 "github.com/kloudlite/operator/apis/zookeeper.msvc/v1"
 	"github.com/kloudlite/operator/operators/msvc-n-mres/internal/env"
+	"github.com/kloudlite/operator/operators/msvc-n-mres/internal/plugin"
 	"github.com/kloudlite/operator/operators/msvc-n-mres/internal/templates"
 	"github.com/kloudlite/operator/pkg/constants"
 	fn "github.com/kloudlite/operator/pkg/functions"
@@ -24,6 +26,8 @@ import (
 	"github.com/kloudlite/operator/pkg/logging"
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -74,7 +78,7 @@ var DeleteCheckList = []rApi.CheckMeta{
 // +kubebuilder:rbac:groups=crds.kloudlite.io,resources=crds/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &crdsv1.ManagedService{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &crdsv1.ManagedService{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -109,11 +113,17 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
-	if step := r.ensureRealMsvcCreated(req); !step.ShouldProceed() {
+	// services of kinds, declared by a plugin, are provisioned from it, instead of a real managed service
+	p, err := plugin.ForService(ctx, r.Client, req.Object.Spec.ServiceTemplate.APIVersion, req.Object.Spec.ServiceTemplate.Kind)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if step := r.ensureRealMsvcCreated(req, p); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
-	if step := r.ensureRealMsvcReady(req); !step.ShouldProceed() {
+	if step := r.ensureRealMsvcReady(req, p); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
@@ -176,19 +186,7 @@ func (r *Reconciler) finalize(req *rApi.Request[*crdsv1.ManagedService]) stepRes
 	return req.Finalize()
 }
 
-func (r *Reconciler) ensureRealMsvcCreated(req *rApi.Request[*crdsv1.ManagedService]) stepResult.Result {
-	ctx, obj := req.Context(), req.Object
-	check := rApi.Check{Generation: obj.Generation, State: rApi.RunningState}
-
-	checkName := ManagedServiceApplied
-
-	req.LogPreCheck(checkName)
-	defer req.LogPostCheck(checkName)
-
-	fail := func(err error) stepResult.Result {
-		return req.CheckFailed(checkName, check, err.Error())
-	}
-
+func (r *Reconciler) applyServiceTemplate(ctx context.Context, obj *crdsv1.ManagedService) ([]rApi.ResourceRef, error) {
 	b, err := templates.ParseBytes(r.templateCommonMsvc, map[string]any{
 		"api-version": obj.Spec.ServiceTemplate.APIVersion,
 		"kind":        obj.Spec.ServiceTemplate.Kind,
@@ -207,10 +205,62 @@ func (r *Reconciler) ensureRealMsvcCreated(req *rApi.Request[*crdsv1.ManagedServ
 		"output": obj.Output,
 	})
 	if err != nil {
-		return fail(err).Err(nil)
+		return nil, err
 	}
 
-	rr, err := r.yamlClient.ApplyYAML(ctx, b)
+	return r.yamlClient.ApplyYAML(ctx, b)
+}
+
+// applyPlugin creates the output secret of obj, and the helm chart, and manifests of its plugin
+func (r *Reconciler) applyPlugin(ctx context.Context, obj *crdsv1.ManagedService, p *crdsv1.ManagedServicePlugin) ([]rApi.ResourceRef, error) {
+	values, err := plugin.ServiceValues(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := fn.AsOwner(obj, true)
+
+	values.Output, err = plugin.EnsureOutput(ctx, r.Client, &p.Spec.PluginTemplate, obj.Output.CredentialsRef.Name, values, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := plugin.RenderObjects(&p.Spec.PluginTemplate, values, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []rApi.ResourceRef
+	for i := range objects {
+		rr, err := r.yamlClient.Apply(ctx, objects[i])
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, rr...)
+	}
+	return refs, nil
+}
+
+func (r *Reconciler) ensureRealMsvcCreated(req *rApi.Request[*crdsv1.ManagedService], p *crdsv1.ManagedServicePlugin) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.Check{Generation: obj.Generation, State: rApi.RunningState}
+
+	checkName := ManagedServiceApplied
+
+	req.LogPreCheck(checkName)
+	defer req.LogPostCheck(checkName)
+
+	fail := func(err error) stepResult.Result {
+		return req.CheckFailed(checkName, check, err.Error())
+	}
+
+	var rr []rApi.ResourceRef
+	var err error
+	if p != nil {
+		rr, err = r.applyPlugin(ctx, obj, p)
+	} else {
+		rr, err = r.applyServiceTemplate(ctx, obj)
+	}
 	if err != nil {
 		return fail(err)
 	}
@@ -227,7 +277,7 @@ func (r *Reconciler) ensureRealMsvcCreated(req *rApi.Request[*crdsv1.ManagedServ
 	return req.Next()
 }
 
-func (r *Reconciler) ensureRealMsvcReady(req *rApi.Request[*crdsv1.ManagedService]) stepResult.Result {
+func (r *Reconciler) ensureRealMsvcReady(req *rApi.Request[*crdsv1.ManagedService], p *crdsv1.ManagedServicePlugin) stepResult.Result {
 	ctx, obj := req.Context(), req.Object
 	check := rApi.Check{Generation: obj.Generation, State: rApi.RunningState}
 
@@ -240,6 +290,44 @@ func (r *Reconciler) ensureRealMsvcReady(req *rApi.Request[*crdsv1.ManagedServic
 		return req.CheckFailed(checkName, check, err.Error())
 	}
 
+	if p != nil {
+		values, err := plugin.ServiceValues(obj)
+		if err != nil {
+			return fail(err).Err(nil)
+		}
+
+		msg, err := plugin.NotReady(ctx, r.Client, &p.Spec.PluginTemplate, values)
+		if err != nil {
+			return fail(err)
+		}
+		if msg != "" {
+			return req.CheckFailed(checkName, check, msg).Err(nil).RequeueAfter(plugin.ProbeInterval)
+		}
+	} else if sr := r.checkRealMsvcReady(req, check); !sr.ShouldProceed() {
+		return sr
+	}
+
+	check.Status = true
+	if check != obj.Status.Checks[checkName] {
+		fn.MapSet(&obj.Status.Checks, checkName, check)
+		if sr := req.UpdateStatus(); !sr.ShouldProceed() {
+			return sr
+		}
+	}
+
+	return req.Next()
+}
+
+// checkRealMsvcReady waits for the real managed service to report itself ready, through its status
+func (r *Reconciler) checkRealMsvcReady(req *rApi.Request[*crdsv1.ManagedService], check rApi.Check) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+
+	checkName := ManagedServiceReady
+
+	fail := func(err error) stepResult.Result {
+		return req.CheckFailed(checkName, check, err.Error())
+	}
+
 	uobj := fn.NewUnstructured(metav1.TypeMeta{APIVersion: obj.Spec.ServiceTemplate.APIVersion, Kind: obj.Spec.ServiceTemplate.Kind})
 
 	realMsvc, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), uobj)
@@ -270,14 +358,6 @@ func (r *Reconciler) ensureRealMsvcReady(req *rApi.Request[*crdsv1.ManagedServic
 		return fail(fmt.Errorf("%s", b)).Err(nil)
 	}
 
-	check.Status = true
-	if check != obj.Status.Checks[checkName] {
-		fn.MapSet(&obj.Status.Checks, checkName, check)
-		if sr := req.UpdateStatus(); !sr.ShouldProceed() {
-			return sr
-		}
-	}
-
 	return req.Next()
 }
 
@@ -299,6 +379,8 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) e
 		&mongodbMsvcv1.ClusterService{},
 		&mysqlMsvcv1.StandaloneService{},
 		&mysqlMsvcv1.ClusterService{},
+		&postgresMsvcv1.StandaloneService{},
+		&postgresMsvcv1.ClusterService{},
 		&redisMsvcv1.StandaloneService{},
 		&redisMsvcv1.ClusterService{},
 		&elasticsearchmsvcv1.Service{},
@@ -321,6 +403,35 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) e
 		builder.Owns(obj)
 	}
 
+	// resources, plugins provision services with
+	builder.Owns(&crdsv1.HelmChart{})
+	builder.Owns(&appsv1.Deployment{})
+	builder.Owns(&appsv1.StatefulSet{})
+	builder.Owns(&batchv1.Job{})
+
+	builder.Watches(
+		&crdsv1.ManagedServicePlugin{},
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			p, ok := obj.(*crdsv1.ManagedServicePlugin)
+			if !ok {
+				return nil
+			}
+
+			var msvcList crdsv1.ManagedServiceList
+			if err := r.List(ctx, &msvcList); err != nil {
+				return nil
+			}
+
+			var reqs []reconcile.Request
+			for i := range msvcList.Items {
+				st := msvcList.Items[i].Spec.ServiceTemplate
+				if st.APIVersion == p.Spec.APIVersion && st.Kind == p.Spec.Kind {
+					reqs = append(reqs, reconcile.Request{NamespacedName: fn.NN(msvcList.Items[i].Namespace, msvcList.Items[i].Name)})
+				}
+			}
+			return reqs
+		}))
+
 	builder.WithOptions(controller.Options{MaxConcurrentReconciles: r.Env.MaxConcurrentReconciles})
 	builder.WithEventFilter(rApi.ReconcileFilter())
 	return builder.Complete(r)
@@ -0,0 +1,71 @@
+package msvc
+
+import (
+	"context"
+	"testing"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/operators/msvc-n-mres/internal/plugin"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnsureRealMsvcReadyRequeuesPendingProbes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, crdsv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msvc := &crdsv1.ManagedService{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "env"},
+		Spec:       crdsv1.ManagedServiceSpec{ServiceTemplate: crdsv1.ServiceTemplate{APIVersion: "plugins.example.com/v1", Kind: "Cache"}},
+	}
+	p := &crdsv1.ManagedServicePlugin{
+		Spec: crdsv1.ManagedServicePluginSpec{
+			APIVersion: "plugins.example.com/v1",
+			Kind:       "Cache",
+			PluginTemplate: crdsv1.PluginTemplate{
+				ReadinessProbes: []crdsv1.PluginReadinessProbe{{APIVersion: "batch/v1", Kind: "Job", Name: "{{.Name}}-init"}},
+			},
+		},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(msvc).WithStatusSubresource(msvc).Build()
+
+	logger, err := logging.New(&logging.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Reconciler{Client: cli, Name: "msvc", logger: logger}
+
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(context.TODO(), logger, r.Name), cli, client.ObjectKeyFromObject(msvc), &crdsv1.ManagedService{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := r.ensureRealMsvcReady(req, p)
+	if step.ShouldProceed() {
+		t.Fatal("ensureRealMsvcReady() should not proceed, while readiness probes are pending")
+	}
+	// probed resources are not watched, so the check has to be requeued
+	if result, _ := step.ReconcilerResponse(); result.RequeueAfter != plugin.ProbeInterval {
+		t.Fatalf("ensureRealMsvcReady() requeues after %s, want %s", result.RequeueAfter, plugin.ProbeInterval)
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "cache-init", Namespace: "env"}, Status: batchv1.JobStatus{Succeeded: 1}}
+	if err := cli.Create(context.TODO(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	if step := r.ensureRealMsvcReady(req, p); !step.ShouldProceed() {
+		t.Fatal("ensureRealMsvcReady() should proceed, once readiness probes pass")
+	}
+}
@@ -0,0 +1,298 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	"github.com/kloudlite/operator/pkg/templates"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Values are what templates of a plugin are rendered with
+type Values struct {
+	Name         string
+	Namespace    string
+	Spec         map[string]any
+	NodeSelector map[string]string
+	Tolerations  []corev1.Toleration
+
+	// Output is the data of the output secret, once it exists
+	Output map[string]string
+
+	// Msvc is the managed service, a managed resource is created on
+	Msvc *Values
+}
+
+// ServiceValues are the values, templates of managed service msvc are rendered with
+func ServiceValues(msvc *crdsv1.ManagedService) (Values, error) {
+	spec, err := fn.JsonConvert[map[string]any](msvc.Spec.ServiceTemplate.Spec)
+	if err != nil {
+		return Values{}, err
+	}
+	return Values{
+		Name:         msvc.Name,
+		Namespace:    msvc.Namespace,
+		Spec:         spec,
+		NodeSelector: msvc.Spec.NodeSelector,
+		Tolerations:  msvc.Spec.Tolerations,
+	}, nil
+}
+
+// MsvcValues are the values of managed service namespace/name, along with data of its output secret, that templates of resources
+// created on it get as .Msvc
+func MsvcValues(ctx context.Context, cli client.Client, namespace string, name string) (Values, error) {
+	msvc, err := rApi.Get(ctx, cli, fn.NN(namespace, name), &crdsv1.ManagedService{})
+	if err != nil {
+		return Values{}, err
+	}
+
+	values, err := ServiceValues(msvc)
+	if err != nil {
+		return Values{}, err
+	}
+
+	scrt, err := rApi.Get(ctx, cli, fn.NN(namespace, msvc.Output.CredentialsRef.Name), &corev1.Secret{})
+	if err != nil {
+		return Values{}, err
+	}
+	values.Output = secretData(scrt)
+	return values, nil
+}
+
+// ResourceValues are the values, templates of managed resource mres, created on managed service msvc, are rendered with
+func ResourceValues(mres *crdsv1.ManagedResource, msvc Values) (Values, error) {
+	spec, err := fn.JsonConvert[map[string]any](mres.Spec.ResourceTemplate.Spec)
+	if err != nil {
+		return Values{}, err
+	}
+	return Values{
+		Name:         mres.RealResourceName(),
+		Namespace:    mres.Namespace,
+		Spec:         spec,
+		NodeSelector: msvc.NodeSelector,
+		Tolerations:  msvc.Tolerations,
+		Msvc:         &msvc,
+	}, nil
+}
+
+func list(ctx context.Context, cli client.Client) ([]crdsv1.ManagedServicePlugin, error) {
+	var plugins crdsv1.ManagedServicePluginList
+	if err := cli.List(ctx, &plugins); err != nil {
+		return nil, err
+	}
+	return plugins.Items, nil
+}
+
+// ForService finds the plugin provisioning services of apiVersion and kind. It is nil, when there is none
+func ForService(ctx context.Context, cli client.Client, apiVersion string, kind string) (*crdsv1.ManagedServicePlugin, error) {
+	plugins, err := list(ctx, cli)
+	if err != nil {
+		return nil, err
+	}
+	for i := range plugins {
+		if plugins[i].Spec.APIVersion == apiVersion && plugins[i].Spec.Kind == kind {
+			return &plugins[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ForResource finds the plugin, and its resource, provisioning resources of apiVersion and kind. They are nil, when there is none
+func ForResource(ctx context.Context, cli client.Client, apiVersion string, kind string) (*crdsv1.ManagedServicePlugin, *crdsv1.PluginResource, error) {
+	plugins, err := list(ctx, cli)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range plugins {
+		if plugins[i].Spec.APIVersion != apiVersion {
+			continue
+		}
+		if res := plugins[i].Spec.Resource(kind); res != nil {
+			return &plugins[i], res, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func render(tpl string, values Values) ([]byte, error) {
+	return templates.ParseBytes([]byte(tpl), values)
+}
+
+// RenderOutput renders data of the output secret
+func RenderOutput(t *crdsv1.PluginTemplate, values Values) (map[string]string, error) {
+	out := make(map[string]string, len(t.Output))
+	for k, tpl := range t.Output {
+		b, err := render(tpl, values)
+		if err != nil {
+			return nil, fmt.Errorf("rendering output key %s: %w", k, err)
+		}
+		out[k] = string(b)
+	}
+	return out, nil
+}
+
+func secretData(scrt *corev1.Secret) map[string]string {
+	data := make(map[string]string, len(scrt.Data))
+	for k, v := range scrt.Data {
+		data[k] = string(v)
+	}
+	return data
+}
+
+// EnsureOutput creates the output secret, when it does not exist, and returns its data. Existing secrets are left as is, so that
+// generated values are not rotated on every reconcile
+func EnsureOutput(ctx context.Context, cli client.Client, t *crdsv1.PluginTemplate, secretName string, values Values, owner metav1.OwnerReference) (map[string]string, error) {
+	scrt, err := rApi.Get(ctx, cli, fn.NN(values.Namespace, secretName), &corev1.Secret{})
+	if err == nil {
+		return secretData(scrt), nil
+	}
+	if !apiErrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	data, err := RenderOutput(t, values)
+	if err != nil {
+		return nil, err
+	}
+
+	scrt = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: values.Namespace, OwnerReferences: []metav1.OwnerReference{owner}},
+		StringData: data,
+	}
+	if err := cli.Create(ctx, scrt); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RenderObjects renders the helm chart and manifests of t. Namespaced objects default to the namespace of values, and all of them are
+// owned by owner
+func RenderObjects(t *crdsv1.PluginTemplate, values Values, owner metav1.OwnerReference) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+
+	if t.HelmChart != nil {
+		helmValues := map[string]apiextensionsv1.JSON{}
+		if t.HelmChart.Values != "" {
+			b, err := render(t.HelmChart.Values, values)
+			if err != nil {
+				return nil, fmt.Errorf("rendering helm values: %w", err)
+			}
+			if err := yaml.Unmarshal(b, &helmValues); err != nil {
+				return nil, fmt.Errorf("parsing helm values: %w", err)
+			}
+		}
+
+		hc, err := fn.JsonConvert[map[string]any](crdsv1.HelmChart{
+			TypeMeta:   metav1.TypeMeta{APIVersion: crdsv1.GroupVersion.String(), Kind: "HelmChart"},
+			ObjectMeta: metav1.ObjectMeta{Name: values.Name},
+			Spec: crdsv1.HelmChartSpec{
+				ChartRepoURL: t.HelmChart.ChartRepoURL,
+				ChartName:    t.HelmChart.ChartName,
+				ChartVersion: t.HelmChart.ChartVersion,
+				JobVars:      crdsv1.JobVars{NodeSelector: values.NodeSelector, Tolerations: values.Tolerations},
+				Values:       helmValues,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: hc})
+	}
+
+	if t.Manifests != "" {
+		b, err := render(t.Manifests, values)
+		if err != nil {
+			return nil, fmt.Errorf("rendering manifests: %w", err)
+		}
+
+		decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(b), 100)
+		for {
+			var obj unstructured.Unstructured
+			if err := decoder.Decode(&obj); err != nil {
+				if err != io.EOF {
+					return nil, err
+				}
+				break
+			}
+			if obj.Object == nil {
+				continue
+			}
+			objects = append(objects, &obj)
+		}
+	}
+
+	for i := range objects {
+		if objects[i].GetNamespace() == "" {
+			objects[i].SetNamespace(values.Namespace)
+		}
+		objects[i].SetOwnerReferences([]metav1.OwnerReference{owner})
+	}
+
+	return objects, nil
+}
+
+// IsReady tells whether obj is ready. Deployments and StatefulSets are, when all their replicas are, Jobs when they succeed, and
+// anything else when its .status.isReady is true
+func IsReady(obj *unstructured.Unstructured) bool {
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet":
+		replicas, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !ok {
+			replicas = 1
+		}
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		return ready >= replicas
+	case "Job":
+		succeeded, _, _ := unstructured.NestedInt64(obj.Object, "status", "succeeded")
+		return succeeded > 0
+	default:
+		ready, _, _ := unstructured.NestedBool(obj.Object, "status", "isReady")
+		return ready
+	}
+}
+
+// ProbeInterval is how often readiness probes are checked again, while they are pending, as probed resources are not watched
+const ProbeInterval = 5 * time.Second
+
+// NotReady checks readiness probes of t, along with its helm chart. It tells what is still being waited for, and is empty, once
+// everything is ready
+func NotReady(ctx context.Context, cli client.Client, t *crdsv1.PluginTemplate, values Values) (string, error) {
+	probes := t.ReadinessProbes
+	if t.HelmChart != nil {
+		probes = append([]crdsv1.PluginReadinessProbe{{APIVersion: crdsv1.GroupVersion.String(), Kind: "HelmChart", Name: values.Name}}, probes...)
+	}
+
+	for _, probe := range probes {
+		name, err := render(probe.Name, values)
+		if err != nil {
+			return "", fmt.Errorf("rendering readiness probe name: %w", err)
+		}
+
+		obj, err := rApi.Get(ctx, cli, fn.NN(values.Namespace, string(name)), fn.NewUnstructured(metav1.TypeMeta{APIVersion: probe.APIVersion, Kind: probe.Kind}))
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				return fmt.Sprintf("waiting for %s %s to be created", probe.Kind, name), nil
+			}
+			return "", err
+		}
+
+		if !IsReady(obj) {
+			return fmt.Sprintf("waiting for %s %s to be ready", probe.Kind, name), nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"testing"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRenderOutput(t *testing.T) {
+	tpl := &crdsv1.PluginTemplate{
+		Output: map[string]string{
+			"HOST": `{{.Name}}.{{.Namespace}}.svc.cluster.local`,
+			"DB":   `{{.Spec.database}}`,
+		},
+	}
+
+	out, err := RenderOutput(tpl, Values{Name: "pg", Namespace: "env", Spec: map[string]any{"database": "app"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["HOST"] != "pg.env.svc.cluster.local" || out["DB"] != "app" {
+		t.Fatalf("RenderOutput() = %v", out)
+	}
+
+	if _, err := RenderOutput(tpl, Values{Name: "pg", Namespace: "env", Spec: map[string]any{}}); err == nil {
+		t.Fatalf("RenderOutput() with a missing spec key, should fail")
+	}
+}
+
+func TestRenderObjects(t *testing.T) {
+	tpl := &crdsv1.PluginTemplate{
+		HelmChart: &crdsv1.PluginHelmChart{
+			ChartRepoURL: "https://charts.bitnami.com/bitnami",
+			ChartName:    "bitnami/postgresql",
+			ChartVersion: "13.2.0",
+			Values:       "auth:\n  existingSecret: {{.Name}}-creds\n",
+		},
+		Manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}-headless
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: {{.Name}}-reader
+  namespace: elsewhere
+`,
+	}
+
+	owner := metav1.OwnerReference{APIVersion: "crds.kloudlite.io/v1", Kind: "ManagedService", Name: "pg"}
+	objects, err := RenderObjects(tpl, Values{Name: "pg", Namespace: "env"}, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("RenderObjects() = %d objects, want 3", len(objects))
+	}
+
+	hc := objects[0]
+	if hc.GetKind() != "HelmChart" || hc.GetName() != "pg" || hc.GetNamespace() != "env" {
+		t.Fatalf("helm chart = %s %s/%s", hc.GetKind(), hc.GetNamespace(), hc.GetName())
+	}
+	if v, _, _ := unstructured.NestedString(hc.Object, "spec", "values", "auth", "existingSecret"); v != "pg-creds" {
+		t.Fatalf("helm chart values, auth.existingSecret = %q", v)
+	}
+
+	if objects[1].GetNamespace() != "env" || objects[2].GetNamespace() != "elsewhere" {
+		t.Fatalf("manifests are in namespaces %q and %q", objects[1].GetNamespace(), objects[2].GetNamespace())
+	}
+	for _, obj := range objects {
+		if refs := obj.GetOwnerReferences(); len(refs) != 1 || refs[0].Name != "pg" {
+			t.Fatalf("%s %s is not owned by the managed service", obj.GetKind(), obj.GetName())
+		}
+	}
+}
+
+func TestIsReady(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]any
+		want bool
+	}{
+		{
+			name: "statefulset, with all replicas ready",
+			obj:  map[string]any{"kind": "StatefulSet", "spec": map[string]any{"replicas": int64(2)}, "status": map[string]any{"readyReplicas": int64(2)}},
+			want: true,
+		},
+		{
+			name: "deployment, with replicas still starting",
+			obj:  map[string]any{"kind": "Deployment", "spec": map[string]any{"replicas": int64(2)}, "status": map[string]any{"readyReplicas": int64(1)}},
+			want: false,
+		},
+		{
+			name: "job, that succeeded",
+			obj:  map[string]any{"kind": "Job", "status": map[string]any{"succeeded": int64(1)}},
+			want: true,
+		},
+		{
+			name: "kloudlite resource, that is not ready",
+			obj:  map[string]any{"kind": "HelmChart", "status": map[string]any{"isReady": false}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsReady(&unstructured.Unstructured{Object: tt.obj}); got != tt.want {
+				t.Fatalf("IsReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
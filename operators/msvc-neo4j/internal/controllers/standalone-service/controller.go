@@ -58,7 +58,7 @@ const (
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
 	req, err := rApi.NewRequest(
-		rApi.NewReconcilerCtx(ctx, r.logger),
+		rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()),
 		r.Client,
 		request.NamespacedName,
 		&neo4jMsvcv1.StandaloneService{},
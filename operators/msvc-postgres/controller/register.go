@@ -0,0 +1,21 @@
+package controller
+
+import (
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
+	"github.com/kloudlite/operator/operator"
+	clusterService "github.com/kloudlite/operator/operators/msvc-postgres/internal/controllers/cluster-service"
+	"github.com/kloudlite/operator/operators/msvc-postgres/internal/controllers/database"
+	standaloneService "github.com/kloudlite/operator/operators/msvc-postgres/internal/controllers/standalone-service"
+	"github.com/kloudlite/operator/operators/msvc-postgres/internal/env"
+)
+
+func RegisterInto(mgr operator.Operator) {
+	ev := env.GetEnvOrDie()
+	mgr.AddToSchemes(postgresMsvcv1.AddToScheme, crdsv1.AddToScheme)
+	mgr.RegisterControllers(
+		&clusterService.Reconciler{Name: "msvc-postgres:cluster-service", Env: ev},
+		&standaloneService.Reconciler{Name: "msvc-postgres:standalone-svc", Env: ev},
+		&database.Reconciler{Name: "msvc-postgres:database", Env: ev},
+	)
+}
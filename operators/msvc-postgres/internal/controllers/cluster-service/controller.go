@@ -0,0 +1,353 @@
+package cluster_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-postgres/internal/env"
+	"github.com/kloudlite/operator/operators/msvc-postgres/internal/templates"
+	"github.com/kloudlite/operator/operators/msvc-postgres/internal/types"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	"github.com/kloudlite/operator/pkg/kubectl"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	libPostgres "github.com/kloudlite/operator/pkg/postgres"
+	"github.com/kloudlite/operator/pkg/resize"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiLabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type Reconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	logger     logging.Logger
+	Name       string
+	Env        *env.Env
+	yamlClient kubectl.YAMLClient
+
+	templateHelmPostgres []byte
+}
+
+func (r *Reconciler) GetName() string {
+	return r.Name
+}
+
+const (
+	AccessCredsGenerated      string = "access-creds-generated"
+	PostgresHelmApplied       string = "postgres-helm-applied"
+	PostgresHelmReady         string = "postgres-helm-ready"
+	PostgresStatefulSetsReady string = "postgres-statefulsets-ready"
+)
+
+// +kubebuilder:rbac:groups=postgres.msvc.kloudlite.io,resources=clusterservices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgres.msvc.kloudlite.io,resources=clusterservices/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=postgres.msvc.kloudlite.io,resources=clusterservices/finalizers,verbs=update
+
+func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &postgresMsvcv1.ClusterService{})
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	req.PreReconcile()
+	defer req.PostReconcile()
+
+	if req.Object.GetDeletionTimestamp() != nil {
+		if x := r.finalize(req); !x.ShouldProceed() {
+			return x.ReconcilerResponse()
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if step := req.ClearStatusIfAnnotated(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureLabelsAndAnnotations(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureFinalizers(constants.ForegroundFinalizer, constants.CommonFinalizer); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.generateAccessCredentials(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := resize.ExpandStorage(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := resize.RecreateStatefulSets(req, r.Client, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.applyPostgresClusterHelm(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.checkHelmReady(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.reconSts(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := resize.RollPods(req, r.Client); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	req.Object.Status.IsReady = true
+	return ctrl.Result{}, nil
+}
+
+func (r *Reconciler) finalize(req *rApi.Request[*postgresMsvcv1.ClusterService]) stepResult.Result {
+	check := "finalizing"
+
+	req.LogPreCheck(check)
+	defer req.LogPostCheck(check)
+
+	if result := req.CleanupOwnedResources(); !result.ShouldProceed() {
+		return result
+	}
+
+	return req.Finalize()
+}
+
+func getHelmSecretName(name string) string {
+	return fmt.Sprintf("helm-%s-creds", name)
+}
+
+func (r *Reconciler) generateAccessCredentials(req *rApi.Request[*postgresMsvcv1.ClusterService]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(AccessCredsGenerated, req)
+
+	rootPassword := fn.CleanerNanoid(40)
+
+	msvcOutput := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: obj.Output.CredentialsRef.Name, Namespace: obj.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, msvcOutput, func() error {
+		msvcOutput.SetLabels(obj.GetLabels())
+		msvcOutput.SetOwnerReferences([]metav1.OwnerReference{fn.AsOwner(obj, true)})
+
+		if msvcOutput.Data == nil {
+			// secret does not already exists
+			host := fmt.Sprintf("%s-primary.%s.svc.%s:5432", obj.Name, obj.Namespace, r.Env.ClusterInternalDNS)
+			rootUsername := "postgres"
+
+			output := types.MsvcOutput{
+				RootUsername: rootUsername,
+				RootPassword: rootPassword,
+				Hosts:        host,
+				URI:          libPostgres.ConnectionURI(host, "postgres", rootUsername, rootPassword),
+				DSN:          libPostgres.DSN(host, "postgres", rootUsername, rootPassword),
+				ReadHosts:    fmt.Sprintf("%s-read.%s.svc.%s:5432", obj.Name, obj.Namespace, r.Env.ClusterInternalDNS),
+			}
+
+			m, err := fn.JsonConvert[map[string]string](output)
+			if err != nil {
+				return err
+			}
+
+			msvcOutput.StringData = m
+		}
+		return nil
+	}); err != nil {
+		return check.Failed(err)
+	}
+
+	req.AddToOwnedResources(rApi.ParseResourceRef(msvcOutput))
+
+	helmSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: getHelmSecretName(obj.Name), Namespace: obj.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, helmSecret, func() error {
+		helmSecret.SetLabels(obj.GetLabels())
+		helmSecret.SetOwnerReferences([]metav1.OwnerReference{fn.AsOwner(obj, true)})
+
+		if helmSecret.Data == nil {
+			// secret does not already exists
+			helmSecret.StringData = map[string]string{
+				"postgres-password":    rootPassword,
+				"replication-password": fn.CleanerNanoid(40),
+			}
+		}
+		return nil
+	}); err != nil {
+		return check.Failed(err)
+	}
+
+	req.AddToOwnedResources(rApi.ParseResourceRef(helmSecret))
+
+	return check.Completed()
+}
+
+func (r *Reconciler) applyPostgresClusterHelm(req *rApi.Request[*postgresMsvcv1.ClusterService]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(PostgresHelmApplied, req)
+
+	// replicas, past the primary, are read replicas
+	readReplicas := obj.Spec.Replicas - 1
+	if readReplicas < 0 {
+		readReplicas = 0
+	}
+
+	b, err := templates.ParseBytes(r.templateHelmPostgres, map[string]any{
+		"name":          obj.Name,
+		"namespace":     obj.Namespace,
+		"labels":        obj.GetLabels(),
+		"owner-refs":    []metav1.OwnerReference{fn.AsOwner(obj, true)},
+		"node-selector": obj.Spec.NodeSelector,
+		"tolerations":   obj.Spec.Tolerations,
+
+		"pod-labels":      obj.GetLabels(),
+		"pod-annotations": fn.FilterObservabilityAnnotations(obj.GetAnnotations()),
+
+		"topology-spread-constraints": obj.Spec.TopologySpreadConstraints,
+
+		"storage-class": obj.Spec.Resources.Storage.StorageClass,
+		"storage-size":  obj.Spec.Resources.Storage.Size,
+
+		"read-replicas":   readReplicas,
+		"existing-secret": getHelmSecretName(obj.Name),
+
+		"cpu-min":    obj.Spec.Resources.Cpu.Min,
+		"cpu-max":    obj.Spec.Resources.Cpu.Max,
+		"memory-min": obj.Spec.Resources.Memory.Min,
+		"memory-max": obj.Spec.Resources.Memory.Max,
+	})
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+
+	rr, err := r.yamlClient.ApplyYAML(ctx, b)
+	if err != nil {
+		return check.Failed(err)
+	}
+
+	req.AddToOwnedResources(rr...)
+
+	return check.Completed()
+}
+
+func (r *Reconciler) checkHelmReady(req *rApi.Request[*postgresMsvcv1.ClusterService]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(PostgresHelmReady, req)
+
+	hc, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &crdsv1.HelmChart{})
+	if err != nil {
+		return check.Failed(err)
+	}
+
+	if !hc.Status.IsReady {
+		return check.Failed(fmt.Errorf("waiting for helm installation to complete"))
+	}
+
+	return check.Completed()
+}
+
+func (r *Reconciler) reconSts(req *rApi.Request[*postgresMsvcv1.ClusterService]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(PostgresStatefulSetsReady, req)
+
+	var stsList appsv1.StatefulSetList
+	if err := r.List(
+		ctx, &stsList, &client.ListOptions{
+			LabelSelector: apiLabels.SelectorFromValidatedSet(
+				map[string]string{constants.MsvcNameKey: obj.Name},
+			),
+			Namespace: obj.Namespace,
+		},
+	); err != nil {
+		return check.Failed(err)
+	}
+
+	if len(stsList.Items) == 0 {
+		return check.Failed(fmt.Errorf("no statefulset pods found, waiting for helm controller to reconcile the resource"))
+	}
+
+	for i := range stsList.Items {
+		item := stsList.Items[i]
+		if item.Status.AvailableReplicas != item.Status.Replicas {
+			var podsList corev1.PodList
+			if err := r.List(
+				ctx, &podsList, &client.ListOptions{
+					LabelSelector: apiLabels.SelectorFromValidatedSet(
+						map[string]string{constants.MsvcNameKey: obj.Name},
+					),
+					Namespace: obj.Namespace,
+				},
+			); err != nil {
+				return check.Failed(err)
+			}
+
+			messages := rApi.GetMessagesFromPods(podsList.Items...)
+			if len(messages) > 0 {
+				b, err := json.Marshal(messages)
+				if err != nil {
+					return check.Failed(err).Err(nil)
+				}
+				return check.Failed(fmt.Errorf("%s", b)).Err(nil)
+			}
+
+			return check.StillRunning(fmt.Errorf("waiting for statefulset pods to start"))
+		}
+	}
+
+	return check.Completed()
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+	r.logger = logger.WithName(r.Name)
+	r.yamlClient = kubectl.NewYAMLClientOrDie(mgr.GetConfig(), kubectl.YAMLClientOpts{Logger: r.logger})
+
+	var err error
+	r.templateHelmPostgres, err = templates.Read(templates.HelmPostgresCluster)
+	if err != nil {
+		return err
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).For(&postgresMsvcv1.ClusterService{})
+	builder.Owns(&corev1.Secret{})
+	builder.Owns(&crdsv1.HelmChart{})
+
+	watchList := []client.Object{
+		&appsv1.StatefulSet{},
+	}
+
+	for _, obj := range watchList {
+		builder.Watches(
+			obj,
+			handler.EnqueueRequestsFromMapFunc(
+				func(_ context.Context, obj client.Object) []reconcile.Request {
+					value, ok := obj.GetLabels()[constants.MsvcNameKey]
+					if !ok {
+						return nil
+					}
+					return []reconcile.Request{
+						{NamespacedName: fn.NN(obj.GetNamespace(), value)},
+					}
+				},
+			),
+		)
+	}
+
+	builder.WithOptions(controller.Options{MaxConcurrentReconciles: r.Env.MaxConcurrentReconciles})
+	builder.WithEventFilter(rApi.ReconcileFilter())
+	return builder.Complete(r)
+}
@@ -0,0 +1,347 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-postgres/internal/env"
+	"github.com/kloudlite/operator/operators/msvc-postgres/internal/types"
+	"github.com/kloudlite/operator/pkg/constants"
+	"github.com/kloudlite/operator/pkg/errors"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	"github.com/kloudlite/operator/pkg/kubectl"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	libPostgres "github.com/kloudlite/operator/pkg/postgres"
+	"github.com/kloudlite/operator/pkg/templates"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type Reconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	logger     logging.Logger
+	Name       string
+	Env        *env.Env
+	yamlClient kubectl.YAMLClient
+}
+
+func (r *Reconciler) GetName() string {
+	return r.Name
+}
+
+const (
+	AccessCredsReady string = "access-creds"
+	DBUserReady      string = "db-user-ready"
+)
+
+const (
+	KeyMsvcOutput string = "msvc-output"
+	KeyMresOutput string = "mres-output"
+)
+
+func (r *Reconciler) newPostgresContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if r.Env.IsDev {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, 5*time.Second)
+}
+
+// +kubebuilder:rbac:groups=postgres.msvc.kloudlite.io,resources=databases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgres.msvc.kloudlite.io,resources=databases/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=postgres.msvc.kloudlite.io,resources=databases/finalizers,verbs=update
+
+func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &postgresMsvcv1.Database{})
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	req.PreReconcile()
+	defer req.PostReconcile()
+
+	if req.Object.GetDeletionTimestamp() != nil {
+		if x := r.finalize(req); !x.ShouldProceed() {
+			return x.ReconcilerResponse()
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if step := req.ClearStatusIfAnnotated(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureLabelsAndAnnotations(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureFinalizers(constants.ForegroundFinalizer, constants.CommonFinalizer); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.reconDBCreds(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.reconDBUser(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	req.Object.Status.IsReady = true
+	return ctrl.Result{}, nil
+}
+
+// sanitizeName makes name usable as an unquoted postgres identifier
+func sanitizeName(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+func (r *Reconciler) newPostgresClient(ctx context.Context, msvcOutput *types.MsvcOutput) (*libPostgres.Client, error) {
+	pgCli, err := libPostgres.NewClient(msvcOutput.Hosts, "postgres", msvcOutput.RootUsername, msvcOutput.RootPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pgCli.Connect(ctx); err != nil {
+		pgCli.Close()
+		return nil, err
+	}
+	return pgCli, nil
+}
+
+func (r *Reconciler) finalize(req *rApi.Request[*postgresMsvcv1.Database]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+
+	check := rApi.NewRunningCheck("finalizing", req)
+
+	msvcOutput, err := r.getMsvcOutput(ctx, obj)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return req.Finalize()
+		}
+		return check.Failed(err)
+	}
+
+	pctx, cancel := r.newPostgresContext(ctx)
+	defer cancel()
+
+	pgCli, err := r.newPostgresClient(pctx, msvcOutput)
+	if err != nil {
+		return check.Failed(err)
+	}
+	defer pgCli.Close()
+
+	name := sanitizeName(obj.DatabaseName())
+
+	// user owns the database, and can be dropped only after it
+	if err := pgCli.DropDatabase(pctx, name); err != nil {
+		return check.Failed(err)
+	}
+
+	if err := pgCli.DropUser(pctx, name); err != nil {
+		return check.Failed(err)
+	}
+
+	return req.Finalize()
+}
+
+func (r *Reconciler) getMsvcOutput(ctx context.Context, obj *postgresMsvcv1.Database) (*types.MsvcOutput, error) {
+	var secretName string
+
+	switch obj.Spec.MsvcRef.Kind {
+	case "StandaloneService":
+		{
+			msvc, err := rApi.Get(ctx, r.Client, fn.NN(obj.Spec.MsvcRef.Namespace, obj.Spec.MsvcRef.Name), &postgresMsvcv1.StandaloneService{})
+			if err != nil {
+				return nil, err
+			}
+			secretName = msvc.Output.CredentialsRef.Name
+		}
+	case "ClusterService":
+		{
+			msvc, err := rApi.Get(ctx, r.Client, fn.NN(obj.Spec.MsvcRef.Namespace, obj.Spec.MsvcRef.Name), &postgresMsvcv1.ClusterService{})
+			if err != nil {
+				return nil, err
+			}
+			secretName = msvc.Output.CredentialsRef.Name
+		}
+	default:
+		return nil, fmt.Errorf("unknown msvc kind: %s", obj.Spec.MsvcRef.Kind)
+	}
+
+	s, err := rApi.Get(ctx, r.Client, fn.NN(obj.Spec.MsvcRef.Namespace, secretName), &corev1.Secret{})
+	if err != nil {
+		return nil, err
+	}
+
+	return fn.ParseFromSecret[types.MsvcOutput](s)
+}
+
+func newMresOutput(msvcOutput *types.MsvcOutput, dbName string, username string, password string) types.MresOutput {
+	return types.MresOutput{
+		Username: username,
+		Password: password,
+		Hosts:    msvcOutput.Hosts,
+		DbName:   dbName,
+		DSN:      libPostgres.DSN(msvcOutput.Hosts, dbName, username, password),
+		URI:      libPostgres.ConnectionURI(msvcOutput.Hosts, dbName, username, password),
+	}
+}
+
+func (r *Reconciler) reconDBCreds(req *rApi.Request[*postgresMsvcv1.Database]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(AccessCredsReady, req)
+
+	secretName := obj.Output.CredentialsRef.Name
+
+	msvcOutput, err := r.getMsvcOutput(ctx, obj)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+
+	scrt, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, secretName), &corev1.Secret{})
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return check.Failed(err)
+		}
+		req.Logger.Infof("access credentials %s/%s does not exist, will be creating it now...", obj.Namespace, secretName)
+		scrt = nil
+	}
+
+	name := sanitizeName(obj.DatabaseName())
+
+	password := fn.CleanerNanoid(40)
+	if scrt != nil {
+		mresOutput, err := fn.ParseFromSecret[types.MresOutput](scrt)
+		if err != nil {
+			return check.Failed(err).Err(nil)
+		}
+		if mresOutput.Password != "" {
+			password = mresOutput.Password
+		}
+	}
+
+	mresOutput := newMresOutput(msvcOutput, name, name, password)
+
+	b, err := templates.Parse(
+		templates.Secret, map[string]any{
+			"name":        secretName,
+			"namespace":   obj.Namespace,
+			"owner-refs":  obj.GetOwnerReferences(),
+			"string-data": mresOutput,
+		},
+	)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+
+	if _, err := r.yamlClient.ApplyYAML(ctx, b); err != nil {
+		return check.Failed(err)
+	}
+
+	rApi.SetLocal(req, KeyMsvcOutput, *msvcOutput)
+	rApi.SetLocal(req, KeyMresOutput, mresOutput)
+	return check.Completed()
+}
+
+// reconDBUser ensures the database, and its owning user with the password from the output secret
+func (r *Reconciler) reconDBUser(req *rApi.Request[*postgresMsvcv1.Database]) stepResult.Result {
+	ctx := req.Context()
+	check := rApi.NewRunningCheck(DBUserReady, req)
+
+	msvcOutput, ok := rApi.GetLocal[types.MsvcOutput](req, KeyMsvcOutput)
+	if !ok {
+		return check.Failed(errors.NotInLocals(KeyMsvcOutput)).Err(nil)
+	}
+
+	mresOutput, ok := rApi.GetLocal[types.MresOutput](req, KeyMresOutput)
+	if !ok {
+		return check.Failed(errors.NotInLocals(KeyMresOutput)).Err(nil)
+	}
+
+	pctx, cancel := r.newPostgresContext(ctx)
+	defer cancel()
+
+	pgCli, err := r.newPostgresClient(pctx, &msvcOutput)
+	if err != nil {
+		return check.Failed(err).Err(nil).RequeueAfter(5 * time.Second)
+	}
+	defer pgCli.Close()
+
+	if err := pgCli.UpsertUser(pctx, mresOutput.Username, mresOutput.Password); err != nil {
+		return check.Failed(err)
+	}
+
+	if err := pgCli.UpsertDatabase(pctx, mresOutput.DbName, mresOutput.Username); err != nil {
+		return check.Failed(err)
+	}
+
+	if err := pgCli.GrantPrivileges(pctx, mresOutput.DbName, mresOutput.Username); err != nil {
+		return check.Failed(err)
+	}
+
+	return check.Completed()
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+	r.logger = logger.WithName(r.Name)
+	r.yamlClient = kubectl.NewYAMLClientOrDie(mgr.GetConfig(), kubectl.YAMLClientOpts{Logger: r.logger})
+
+	builder := ctrl.NewControllerManagedBy(mgr).For(&postgresMsvcv1.Database{})
+	builder.Owns(&corev1.Secret{})
+
+	watchList := []client.Object{
+		&postgresMsvcv1.StandaloneService{},
+		&postgresMsvcv1.ClusterService{},
+	}
+
+	for _, obj := range watchList {
+		builder.Watches(
+			obj,
+			handler.EnqueueRequestsFromMapFunc(
+				func(ctx context.Context, obj client.Object) []reconcile.Request {
+					msvcName, ok := obj.GetLabels()[constants.MsvcNameKey]
+					if !ok {
+						return nil
+					}
+
+					var dbList postgresMsvcv1.DatabaseList
+					if err := r.List(ctx, &dbList, &client.ListOptions{
+						LabelSelector: labels.SelectorFromValidatedSet(
+							map[string]string{constants.MsvcNameKey: msvcName},
+						),
+						Namespace: obj.GetNamespace(),
+					},
+					); err != nil {
+						return nil
+					}
+
+					reqs := make([]reconcile.Request, 0, len(dbList.Items))
+					for j := range dbList.Items {
+						reqs = append(reqs, reconcile.Request{NamespacedName: fn.NN(dbList.Items[j].GetNamespace(), dbList.Items[j].GetName())})
+					}
+
+					return reqs
+				},
+			),
+		)
+	}
+
+	builder.WithEventFilter(rApi.ReconcileFilter())
+	builder.WithOptions(controller.Options{MaxConcurrentReconciles: r.Env.MaxConcurrentReconciles})
+	return builder.Complete(r)
+}
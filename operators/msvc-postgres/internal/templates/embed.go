@@ -0,0 +1,24 @@
+package templates
+
+import (
+	"embed"
+	"path/filepath"
+
+	"github.com/kloudlite/operator/pkg/templates"
+)
+
+//go:embed *
+var templatesDir embed.FS
+
+type templateFile string
+
+const (
+	HelmPostgresCluster    templateFile = "./helm-postgres-cluster.yml.tpl"
+	HelmPostgresStandalone templateFile = "./helm-postgres-standalone.yml.tpl"
+)
+
+func Read(t templateFile) ([]byte, error) {
+	return templatesDir.ReadFile(filepath.Join(string(t)))
+}
+
+var ParseBytes = templates.ParseBytes
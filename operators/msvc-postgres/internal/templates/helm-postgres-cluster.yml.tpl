@@ -0,0 +1,96 @@
+{{- $name := get . "name" }}
+{{- $namespace := get . "namespace" }}
+{{- $labels := get . "labels" | default dict }}
+{{- $annotations := get . "annotations" | default dict}}
+{{- $nodeSelector := get . "node-selector" | default dict }}
+{{- $tolerations := get . "tolerations" | default list }}
+{{- $priorityClassName := get . "priority-class-name" | default "stateful" }}
+
+{{- $podLabels := get . "pod-labels" }}
+{{- $podAnnotations := get . "pod-annotations" }}
+
+{{- $topologySpreadConstraints := get . "topology-spread-constraints" | default list }}
+
+{{- $ownerRefs := get . "owner-refs" | default list }}
+
+{{- $storageClass := get . "storage-class" }}
+{{- $storageSize := get . "storage-size" }}
+
+{{- $readReplicas := get . "read-replicas" }}
+{{- $existingSecret := get . "existing-secret" }}
+
+{{- $cpuMin := get . "cpu-min" }}
+{{- $cpuMax := get . "cpu-max" }}
+{{- $memoryMin := get . "memory-min" }}
+{{- $memoryMax := get . "memory-max" }}
+
+---
+
+apiVersion: crds.kloudlite.io/v1
+kind: HelmChart
+metadata:
+  name: {{$name}}
+  namespace: {{$namespace}}
+  labels: {{$labels | toYAML | nindent 4}}
+  annotations: {{$annotations | toYAML | nindent 4}}
+  ownerReferences: {{$ownerRefs | toYAML | nindent 4}}
+spec:
+  chartRepoURL: https://charts.bitnami.com/bitnami
+  chartName: postgresql
+  chartVersion: 13.2.0
+
+  jobVars:
+    tolerations: {{$tolerations | toYAML | nindent 6 }}
+    nodeSelector: {{$nodeSelector | toYAML | nindent 6 }}
+
+  values:
+    # source: https://github.com/bitnami/charts/tree/main/bitnami/postgresql/
+    global:
+      {{- if $storageClass }}
+      storageClass: {{$storageClass}}
+      {{- end }}
+
+    fullnameOverride: {{$name}}
+
+    architecture: replication
+    replication:
+      synchronousCommit: "off"
+      numSynchronousReplicas: 0
+
+    commonLabels: {{$labels | toYAML | nindent 6}}
+
+    auth:
+      enablePostgresUser: true
+      existingSecret: {{$existingSecret}}
+
+    {{- range $component := list "primary" "readReplicas" }}
+    {{$component}}:
+      {{- if eq $component "readReplicas" }}
+      replicaCount: {{ $readReplicas | int64 }}
+      {{- end }}
+      podLabels: {{$podLabels | toYAML | nindent 8}}
+      podAnnotations: {{$podAnnotations | toYAML | nindent 8}}
+      nodeSelector: {{$nodeSelector | toYAML | nindent 8 }}
+      tolerations: {{$tolerations | toYAML | nindent 8 }}
+      topologySpreadConstraints: {{$topologySpreadConstraints | toYAML | nindent 8 }}
+
+      priorityClassName: "{{$priorityClassName}}"
+
+      persistence:
+        enabled: true
+        size: {{$storageSize}}
+
+      resources:
+        requests:
+          cpu: {{$cpuMin}}
+          memory: {{$memoryMin}}
+        limits:
+          cpu: {{$cpuMax}}
+          memory: {{$memoryMax}}
+    {{- end }}
+
+    volumePermissions:
+      enabled: true
+
+    metrics:
+      enabled: true
@@ -0,0 +1,83 @@
+{{- $name := get . "name" }}
+{{- $namespace := get . "namespace" }}
+{{- $labels := get . "labels" | default dict }}
+{{- $annotations := get . "annotations" | default dict}}
+{{- $tolerations := get . "tolerations" | default list }}
+
+{{- $podLabels := get . "pod-labels" }}
+{{- $podAnnotations := get . "pod-annotations" }}
+
+{{- $ownerRefs := get . "owner-refs" | default list }}
+
+{{- $nodeSelector := get . "node-selector" | default dict }}
+
+{{- $storageSize := get . "storage-size" }}
+{{- $storageClass := get . "storage-class" }}
+
+{{- $requestsCpu := get . "requests-cpu" }}
+{{- $requestsMem := get . "requests-mem" }}
+{{- $limitsCpu := get . "limits-cpu" }}
+{{- $limitsMem := get . "limits-mem" }}
+
+{{- $priorityClassName := get . "priority-class-name"  | default "stateful" -}}
+{{- $existingSecret := get . "existing-secret" -}}
+
+
+apiVersion: crds.kloudlite.io/v1
+kind: HelmChart
+metadata:
+  name: {{$name}}
+  namespace: {{$namespace}}
+  labels: {{ $labels | toYAML | nindent 4 }}
+  annotations: {{$annotations | toYAML | nindent 4}}
+  ownerReferences: {{ $ownerRefs | toYAML | nindent 4 }}
+spec:
+  chartRepoURL: https://charts.bitnami.com/bitnami
+  chartVersion: 13.2.0
+  chartName: postgresql
+
+  jobVars:
+    nodeSelector: {{$nodeSelector | toYAML | nindent 6 }}
+    tolerations: {{$tolerations | toYAML | nindent 6 }}
+
+  values:
+    # source: https://github.com/bitnami/charts/tree/main/bitnami/postgresql/
+    global:
+      {{- if $storageClass }}
+      storageClass: {{$storageClass}}
+      {{- end }}
+    fullnameOverride: {{$name}}
+
+    architecture: standalone
+
+    commonLabels: {{$labels | toYAML | nindent 6}}
+
+    auth:
+      enablePostgresUser: true
+      existingSecret: {{$existingSecret}}
+
+    primary:
+      podLabels: {{$podLabels | toYAML | nindent 8}}
+      podAnnotations: {{$podAnnotations | toYAML | nindent 8 }}
+      nodeSelector: {{$nodeSelector | toYAML | nindent 8 }}
+      tolerations: {{$tolerations | toYAML | nindent 8 }}
+
+      priorityClassName: "{{$priorityClassName}}"
+
+      persistence:
+        enabled: true
+        size: {{$storageSize}}
+
+      resources:
+        requests:
+          cpu: {{$requestsCpu}}
+          memory: {{$requestsMem}}
+        limits:
+          cpu: {{$limitsCpu}}
+          memory: {{$limitsMem}}
+
+    volumePermissions:
+      enabled: true
+
+    metrics:
+      enabled: true
@@ -0,0 +1,21 @@
+package types
+
+type MsvcOutput struct {
+	RootUsername string `json:"ROOT_USERNAME"`
+	RootPassword string `json:"ROOT_PASSWORD"`
+	Hosts        string `json:"HOSTS"`
+	URI          string `json:"URI"`
+	DSN          string `json:"DSN"`
+
+	// ReadHosts are hosts of read replicas, of a cluster service
+	ReadHosts string `json:"READ_HOSTS,omitempty"`
+}
+
+type MresOutput struct {
+	Username string `json:"USERNAME"`
+	Password string `json:"PASSWORD"`
+	Hosts    string `json:"HOSTS"`
+	DbName   string `json:"DB_NAME"`
+	DSN      string `json:"DSN"`
+	URI      string `json:"URI"`
+}
@@ -17,6 +17,7 @@ import (
 	rApi "github.com/kloudlite/operator/pkg/operator"
 	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
 	libRedis "github.com/kloudlite/operator/pkg/redis"
+	"github.com/kloudlite/operator/pkg/rotation"
 	"github.com/kloudlite/operator/pkg/templates"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,6 +37,8 @@ type Reconciler struct {
 	Name       string
 	Env        *env.Env
 	yamlClient kubectl.YAMLClient
+
+	newACLClient func(addr string, rootPassword string) (aclClient, error)
 }
 
 func (r *Reconciler) GetName() string {
@@ -48,6 +51,8 @@ const (
 	IsOwnedByMsvc    string = "is-owned-by-msvc"
 
 	ACLUserDeleted string = "acl-user-deleted"
+
+	CredentialsRotated string = "credentials-rotated"
 )
 
 const (
@@ -60,7 +65,7 @@ const (
 // +kubebuilder:rbac:groups=redis.msvc.kloudlite.io,resources=aclaccounts/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &redisMsvcv1.ACLAccount{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &redisMsvcv1.ACLAccount{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -103,8 +108,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.rotateCredentials(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: rotation.RequeueAfter(req.Object, req.Object.Spec.Rotation, time.Now())}, nil
 }
 
 func (r *Reconciler) finalize(req *rApi.Request[*redisMsvcv1.ACLAccount]) stepResult.Result {
@@ -123,7 +132,7 @@ func (r *Reconciler) finalize(req *rApi.Request[*redisMsvcv1.ACLAccount]) stepRe
 		return req.CheckFailed(AccessCredsReady, check, err.Error()).Err(nil)
 	}
 
-	redisCli, err := libRedis.NewClient(msvcOutput.Hosts, "", msvcOutput.RootPassword)
+	redisCli, err := libRedis.NewClient(msvcOutput.Addr, "", msvcOutput.RootPassword)
 	if err != nil {
 		return req.CheckFailed(ACLUserReady, check, err.Error())
 	}
@@ -187,6 +196,24 @@ func (r *Reconciler) reconOwnership(req *rApi.Request[*redisMsvcv1.ACLAccount])
 	return req.Next()
 }
 
+func mresLabels(obj *redisMsvcv1.ACLAccount) map[string]string {
+	return map[string]string{
+		constants.MsvcNamespaceKey: obj.Spec.MsvcRef.Namespace,
+		constants.MsvcNameKey:      obj.Spec.MsvcRef.Name,
+		constants.IsMresOutput:     "true",
+	}
+}
+
+func newMresOutput(obj *redisMsvcv1.ACLAccount, msvcOutput *types.MsvcOutput, passwd string) types.MresOutput {
+	return types.MresOutput{
+		Hosts:    msvcOutput.Addr,
+		Password: passwd,
+		Username: obj.Name,
+		Prefix:   obj.Spec.KeyPrefix,
+		Uri:      fmt.Sprintf("redis://%s:%s@%s?allowUsernameInURI=true", obj.Name, passwd, msvcOutput.Addr),
+	}
+}
+
 func (r *Reconciler) reconAccessCreds(req *rApi.Request[*redisMsvcv1.ACLAccount]) stepResult.Result {
 	ctx, obj := req.Context(), req.Object
 	check := rApi.Check{Generation: obj.Generation}
@@ -216,21 +243,11 @@ func (r *Reconciler) reconAccessCreds(req *rApi.Request[*redisMsvcv1.ACLAccount]
 		passwd := fn.CleanerNanoid(40)
 		b, err := templates.Parse(
 			templates.Secret, map[string]any{
-				"name":       secretName,
-				"namespace":  obj.Namespace,
-				"owner-refs": obj.GetOwnerReferences(),
-				"labels": map[string]string{
-					constants.MsvcNamespaceKey: obj.Spec.MsvcRef.Namespace,
-					constants.MsvcNameKey:      obj.Spec.MsvcRef.Name,
-					constants.IsMresOutput:     "true",
-				},
-				"string-data": types.MresOutput{
-					Hosts:    msvcOutput.Hosts,
-					Password: passwd,
-					Username: obj.Name,
-					Prefix:   obj.Spec.KeyPrefix,
-					Uri:      fmt.Sprintf("redis://%s:%s@%s?allowUsernameInURI=true", obj.Name, passwd, msvcOutput.Hosts),
-				},
+				"name":        secretName,
+				"namespace":   obj.Namespace,
+				"owner-refs":  obj.GetOwnerReferences(),
+				"labels":      mresLabels(obj),
+				"string-data": newMresOutput(obj, msvcOutput, passwd),
 			},
 		)
 		if err != nil {
@@ -278,7 +295,7 @@ func (r *Reconciler) reconACLUser(req *rApi.Request[*redisMsvcv1.ACLAccount]) st
 		return req.CheckFailed(ACLUserReady, check, errors.NotInLocals(KeyMresOutput).Error())
 	}
 
-	redisCli, err := libRedis.NewClient(msvcOutput.Hosts, "", msvcOutput.RootPassword)
+	redisCli, err := libRedis.NewClient(msvcOutput.Addr, "", msvcOutput.RootPassword)
 	if err != nil {
 		return req.CheckFailed(ACLUserReady, check, err.Error())
 	}
@@ -311,6 +328,7 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) e
 	r.Scheme = mgr.GetScheme()
 	r.logger = logger.WithName(r.Name)
 	r.yamlClient = kubectl.NewYAMLClientOrDie(mgr.GetConfig(), kubectl.YAMLClientOpts{Logger: r.logger})
+	r.newACLClient = newACLClient
 
 	builder := ctrl.NewControllerManagedBy(mgr).For(&redisMsvcv1.ACLAccount{})
 	builder.Owns(&corev1.Secret{})
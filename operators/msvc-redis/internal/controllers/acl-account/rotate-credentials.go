@@ -0,0 +1,114 @@
+package aclaccount
+
+import (
+	"context"
+	"time"
+
+	redisMsvcv1 "github.com/kloudlite/operator/apis/redis.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-redis/internal/types"
+	"github.com/kloudlite/operator/pkg/errors"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	libRedis "github.com/kloudlite/operator/pkg/redis"
+	"github.com/kloudlite/operator/pkg/rotation"
+	"github.com/kloudlite/operator/pkg/templates"
+)
+
+// aclClient is the part of a redis client, that rotating credentials needs
+type aclClient interface {
+	UpsertUser(ctx context.Context, prefix, username, password string) error
+	AddUserPassword(ctx context.Context, username, password string) error
+	Close() error
+}
+
+func newACLClient(addr string, rootPassword string) (aclClient, error) {
+	return libRedis.NewClient(addr, "", rootPassword)
+}
+
+// rotateCredentials adds a new password to the acl user, and resets it to just that one, once the grace period ends, and consumers have
+// restarted
+func (r *Reconciler) rotateCredentials(req *rApi.Request[*redisMsvcv1.ACLAccount]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.Check{Generation: obj.Generation}
+
+	req.LogPreCheck(CredentialsRotated)
+	defer req.LogPostCheck(CredentialsRotated)
+
+	if err := rotation.EnsureConsumersRestarted(ctx, r.Client, obj, "mres-"+obj.Name); err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error())
+	}
+
+	now := time.Now()
+	_, revokePending := rotation.RevokeAt(obj)
+	if (revokePending && !rotation.CanRevoke(obj, now)) || (!revokePending && !rotation.IsDue(obj, obj.Spec.Rotation, now)) {
+		check.Status = true
+		if check != obj.Status.Checks[CredentialsRotated] {
+			obj.Status.Checks[CredentialsRotated] = check
+			req.UpdateStatus()
+		}
+		return req.Next()
+	}
+
+	msvcOutput, ok := rApi.GetLocal[types.MsvcOutput](req, KeyMsvcOutput)
+	if !ok {
+		return req.CheckFailed(CredentialsRotated, check, errors.NotInLocals(KeyMsvcOutput).Error())
+	}
+
+	output, ok := rApi.GetLocal[types.MresOutput](req, KeyMresOutput)
+	if !ok {
+		return req.CheckFailed(CredentialsRotated, check, errors.NotInLocals(KeyMresOutput).Error())
+	}
+
+	redisCli, err := r.newACLClient(msvcOutput.Addr, msvcOutput.RootPassword)
+	if err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error())
+	}
+	defer redisCli.Close()
+
+	tCtx, cancelFn := context.WithTimeout(ctx, 3*time.Second)
+	defer cancelFn()
+
+	if revokePending {
+		if err := redisCli.UpsertUser(tCtx, output.Prefix, output.Username, output.Password); err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error())
+		}
+		rotation.MarkRevoked(obj)
+	} else {
+		passwd := fn.CleanerNanoid(40)
+		if err := redisCli.AddUserPassword(tCtx, output.Username, passwd); err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error())
+		}
+
+		b, err := templates.Parse(
+			templates.Secret, map[string]any{
+				"name":        "mres-" + obj.Name,
+				"namespace":   obj.Namespace,
+				"owner-refs":  obj.GetOwnerReferences(),
+				"labels":      mresLabels(obj),
+				"string-data": newMresOutput(obj, &msvcOutput, passwd),
+			},
+		)
+		if err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error())
+		}
+
+		if _, err := r.yamlClient.ApplyYAML(ctx, b); err != nil {
+			return req.CheckFailed(CredentialsRotated, check, err.Error())
+		}
+
+		rotation.MarkRotated(obj, obj.Spec.Rotation, now)
+	}
+
+	if err := rApi.UpdatePreservingStatus(ctx, r.Client, obj); err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error())
+	}
+
+	if err := rotation.EnsureConsumersRestarted(ctx, r.Client, obj, "mres-"+obj.Name); err != nil {
+		return req.CheckFailed(CredentialsRotated, check, err.Error())
+	}
+
+	check.Status = true
+	obj.Status.Checks[CredentialsRotated] = check
+	return req.UpdateStatus()
+}
@@ -0,0 +1,144 @@
+package aclaccount
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	redisMsvcv1 "github.com/kloudlite/operator/apis/redis.msvc/v1"
+	"github.com/kloudlite/operator/operators/msvc-redis/internal/types"
+	"github.com/kloudlite/operator/pkg/constants"
+	"github.com/kloudlite/operator/pkg/kubectl"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	"github.com/kloudlite/operator/pkg/rotation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeACLClient struct {
+	passwords map[string][]string
+}
+
+func (f *fakeACLClient) UpsertUser(_ context.Context, _ string, username, password string) error {
+	f.passwords[username] = []string{password}
+	return nil
+}
+
+func (f *fakeACLClient) AddUserPassword(_ context.Context, username, password string) error {
+	f.passwords[username] = append(f.passwords[username], password)
+	return nil
+}
+
+func (f *fakeACLClient) Close() error {
+	return nil
+}
+
+type fakeYAMLClient struct {
+	kubectl.YAMLClient
+	applied []string
+}
+
+func (f *fakeYAMLClient) ApplyYAML(_ context.Context, yamls ...[]byte) ([]rApi.ResourceRef, error) {
+	for i := range yamls {
+		f.applied = append(f.applied, string(yamls[i]))
+	}
+	return nil, nil
+}
+
+func TestRotateCredentials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, crdsv1.AddToScheme, redisMsvcv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	obj := &redisMsvcv1.ACLAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cache",
+			Namespace:   "env",
+			Annotations: map[string]string{constants.RotateCredentialsKey: "true"},
+		},
+		Spec: redisMsvcv1.ACLAccountSpec{
+			KeyPrefix: "cache",
+			MsvcRef:   ct.MsvcRef{Name: "redis", Namespace: "env"},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(obj).WithStatusSubresource(obj).Build()
+
+	logger, err := logging.New(&logging.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redisCli := &fakeACLClient{passwords: map[string][]string{"cache": {"old-password"}}}
+	yamlCli := &fakeYAMLClient{}
+	r := &Reconciler{
+		Client:     cli,
+		Name:       "acl-account",
+		logger:     logger,
+		yamlClient: yamlCli,
+		newACLClient: func(string, string) (aclClient, error) {
+			return redisCli, nil
+		},
+	}
+
+	reconcileRotation := func(password string) *redisMsvcv1.ACLAccount {
+		t.Helper()
+		req, err := rApi.NewRequest(rApi.NewReconcilerCtx(context.TODO(), logger, r.Name), cli, client.ObjectKeyFromObject(obj), &redisMsvcv1.ACLAccount{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rApi.SetLocal(req, KeyMsvcOutput, types.MsvcOutput{Addr: "redis.env.svc:6379", RootPassword: "root"})
+		rApi.SetLocal(req, KeyMresOutput, types.MresOutput{Username: "cache", Password: password, Prefix: "cache"})
+
+		if _, err := r.rotateCredentials(req).ReconcilerResponse(); err != nil {
+			t.Fatalf("rotateCredentials() failed: %v", err)
+		}
+		if !req.Object.Status.Checks[CredentialsRotated].Status {
+			t.Fatalf("rotateCredentials() did not complete check %q", CredentialsRotated)
+		}
+		return req.Object
+	}
+
+	rotated := reconcileRotation("old-password")
+
+	if got := redisCli.passwords["cache"]; len(got) != 2 || got[0] != "old-password" {
+		t.Fatalf("rotation should add a password, and keep the old one, got %v", got)
+	}
+	newPassword := redisCli.passwords["cache"][1]
+	if len(yamlCli.applied) != 1 || !strings.Contains(yamlCli.applied[0], newPassword) {
+		t.Fatalf("rotation should write the new password into the mres output secret")
+	}
+	if _, ok := rotation.RevokeAt(rotated); !ok {
+		t.Fatalf("rotation should schedule revocation of the old password")
+	}
+	if rotation.ConsumersRestartPending(rotated) {
+		t.Fatalf("rotation should restart consumers")
+	}
+	if _, ok := rotated.GetAnnotations()[constants.RotateCredentialsKey]; ok {
+		t.Fatalf("rotation should clear the on-demand request")
+	}
+
+	// grace period ends
+	rotated.Annotations[rotation.AnnotationRevokeAt] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	if err := cli.Update(context.TODO(), rotated); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := reconcileRotation(newPassword)
+
+	if got := redisCli.passwords["cache"]; len(got) != 1 || got[0] != newPassword {
+		t.Fatalf("revocation should reset the acl user to a single password, got %v", got)
+	}
+	if _, ok := rotation.RevokeAt(revoked); ok {
+		t.Fatalf("revocation should be recorded")
+	}
+}
@@ -58,7 +58,7 @@ var DeleteCheckList = []rApi.CheckMeta{
 // +kubebuilder:rbac:groups=redis.msvc.kloudlite.io,resources=prefixes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=redis.msvc.kloudlite.io,resources=prefixes/finalizers,verbs=update
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &redisMsvcV1.Prefix{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &redisMsvcV1.Prefix{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
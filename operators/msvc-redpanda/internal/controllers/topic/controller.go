@@ -45,7 +45,7 @@ const (
 // +kubebuilder:rbac:groups=redpanda.msvc.kloudlite.io,resources=topics/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.Logger), r.Client, request.NamespacedName, &redpandaMsvcv1.Topic{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.Logger, r.GetName()), r.Client, request.NamespacedName, &redpandaMsvcv1.Topic{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -15,4 +15,5 @@ func RegisterInto(mgr operator.Operator) {
 		&project.Reconciler{Name: "project", Env: ev},
 		&environment.Reconciler{Name: "workspace", Env: ev},
 	)
+	mgr.RegisterWebhooks(&crdsv1.Environment{})
 }
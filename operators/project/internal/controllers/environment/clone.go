@@ -0,0 +1,353 @@
+package environment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// annotationClonedFrom marks an environment, that has been cloned, and seeded, so that it never gets cloned again
+	annotationClonedFrom = "kloudlite.io/environment.cloned-from"
+
+	labelEnvironmentSeed = "kloudlite.io/environment-seed"
+)
+
+// annotations, that controllers maintain on their resources, and must not be carried over to clones
+var controllerAnnotations = []string{
+	constants.LastAppliedKey,
+	constants.AnnotationResourceReady,
+	constants.AnnotationResourceChecks,
+	constants.RouterDNSRecordsKey,
+}
+
+// app annotations, that drive, or record its rollout, and idling, in the source environment
+var appAnnotations = []string{
+	constants.AppRolloutPromoteKey,
+	constants.AppRolloutAbortKey,
+	constants.AppRolloutRevisionKey,
+	constants.AppLastRequestAtKey,
+}
+
+func isCloned(obj *crdsv1.Environment) bool {
+	_, ok := obj.GetAnnotations()[annotationClonedFrom]
+	return obj.Spec.CloneFrom == nil || ok
+}
+
+type domainTemplateVars struct {
+	Domain            string
+	Name              string
+	Parent            string
+	Environment       string
+	SourceEnvironment string
+}
+
+// rewriteDomain renders domain of a cloned router through the environment's domain template
+func rewriteDomain(obj *crdsv1.Environment, domain string) (string, error) {
+	tplStr := obj.Spec.CloneFrom.DomainTemplate
+	if tplStr == "" {
+		tplStr = crdsv1.DefaultEnvironmentDomainTemplate
+	}
+
+	tpl, err := template.New("domain").Option("missingkey=error").Parse(tplStr)
+	if err != nil {
+		return "", err
+	}
+
+	wildcard := strings.HasPrefix(domain, "*.")
+	base := strings.TrimPrefix(domain, "*.")
+
+	name, parent, _ := strings.Cut(base, ".")
+
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, domainTemplateVars{
+		Domain:            base,
+		Name:              name,
+		Parent:            parent,
+		Environment:       obj.Name,
+		SourceEnvironment: obj.Spec.CloneFrom.EnvironmentName,
+	}); err != nil {
+		return "", err
+	}
+
+	rewritten := strings.Trim(buf.String(), ".")
+	if wildcard {
+		return "*." + rewritten, nil
+	}
+	return rewritten, nil
+}
+
+// cloneMeta carries name, labels and annotations of a source resource over to its clone in namespace
+func cloneMeta(obj *crdsv1.Environment, src metav1.ObjectMeta, namespace string) metav1.ObjectMeta {
+	labels := make(map[string]string, len(src.Labels))
+	for k, v := range src.Labels {
+		labels[k] = v
+	}
+	if _, ok := labels[constants.EnvironmentNameKey]; ok {
+		labels[constants.EnvironmentNameKey] = obj.Name
+	}
+
+	annotations := make(map[string]string, len(src.Annotations))
+	for k, v := range src.Annotations {
+		annotations[k] = v
+	}
+	for _, k := range controllerAnnotations {
+		delete(annotations, k)
+	}
+
+	return metav1.ObjectMeta{Name: src.Name, Namespace: namespace, Labels: labels, Annotations: annotations}
+}
+
+// cloneApp leaves out the intercept, and annotations of the rollout, and idling state of src
+func cloneApp(obj *crdsv1.Environment, src *crdsv1.App, namespace string) *crdsv1.App {
+	spec := src.Spec.DeepCopy()
+	// intercepts route traffic to a device of the source environment
+	spec.Intercept = nil
+
+	meta := cloneMeta(obj, src.ObjectMeta, namespace)
+	for _, k := range appAnnotations {
+		delete(meta.Annotations, k)
+	}
+
+	return &crdsv1.App{ObjectMeta: meta, Spec: *spec}
+}
+
+// cloneManagedResource prefixes the real resource name with the environment, as the clone is created on the same managed service as
+// src, and must neither take over, nor delete, the database, or user of src
+func cloneManagedResource(obj *crdsv1.Environment, src *crdsv1.ManagedResource, namespace string) *crdsv1.ManagedResource {
+	spec := src.Spec.DeepCopy()
+
+	prefix := obj.Name
+	if spec.ResourceNamePrefix != nil {
+		prefix = fmt.Sprintf("%s-%s", *spec.ResourceNamePrefix, obj.Name)
+	}
+	spec.ResourceNamePrefix = &prefix
+
+	return &crdsv1.ManagedResource{ObjectMeta: cloneMeta(obj, src.ObjectMeta, namespace), Spec: *spec, Enabled: src.Enabled}
+}
+
+// isGenerated tells whether a configmap, or a secret, is generated by a controller in the source namespace, which then generates it for the clone too
+func isGenerated(meta metav1.ObjectMeta) bool {
+	if len(meta.OwnerReferences) > 0 {
+		return true
+	}
+	if _, ok := meta.Annotations[constants.SecretClonedByKey]; ok {
+		return true
+	}
+	if _, ok := meta.Labels[constants.RouterTCPUDPServicesKey]; ok {
+		return true
+	}
+	return false
+}
+
+// createIfNotExists leaves resources, that already exist in the environment, as they are
+func (r *Reconciler) createIfNotExists(ctx context.Context, obj client.Object) error {
+	if err := r.Create(ctx, obj); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *Reconciler) cloneResources(ctx context.Context, obj *crdsv1.Environment, srcNamespace string) error {
+	ns := obj.Spec.TargetNamespace
+
+	var configs corev1.ConfigMapList
+	if err := r.List(ctx, &configs, client.InNamespace(srcNamespace)); err != nil {
+		return err
+	}
+	for i := range configs.Items {
+		src := &configs.Items[i]
+		if src.Name == "kube-root-ca.crt" || isGenerated(src.ObjectMeta) {
+			continue
+		}
+		if err := r.createIfNotExists(ctx, &corev1.ConfigMap{ObjectMeta: cloneMeta(obj, src.ObjectMeta, ns), Data: src.Data, BinaryData: src.BinaryData}); err != nil {
+			return err
+		}
+	}
+
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets, client.InNamespace(srcNamespace)); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		src := &secrets.Items[i]
+		if src.Type == corev1.SecretTypeServiceAccountToken || src.Type == "helm.sh/release.v1" || isGenerated(src.ObjectMeta) {
+			continue
+		}
+		if err := r.createIfNotExists(ctx, &corev1.Secret{ObjectMeta: cloneMeta(obj, src.ObjectMeta, ns), Type: src.Type, Data: src.Data}); err != nil {
+			return err
+		}
+	}
+
+	var mresList crdsv1.ManagedResourceList
+	if err := r.List(ctx, &mresList, client.InNamespace(srcNamespace)); err != nil {
+		return err
+	}
+	for i := range mresList.Items {
+		if err := r.createIfNotExists(ctx, cloneManagedResource(obj, &mresList.Items[i], ns)); err != nil {
+			return err
+		}
+	}
+
+	var apps crdsv1.AppList
+	if err := r.List(ctx, &apps, client.InNamespace(srcNamespace)); err != nil {
+		return err
+	}
+	for i := range apps.Items {
+		if err := r.createIfNotExists(ctx, cloneApp(obj, &apps.Items[i], ns)); err != nil {
+			return err
+		}
+	}
+
+	var routers crdsv1.RouterList
+	if err := r.List(ctx, &routers, client.InNamespace(srcNamespace)); err != nil {
+		return err
+	}
+	for i := range routers.Items {
+		src := &routers.Items[i]
+		spec := src.Spec.DeepCopy()
+		spec.IngressClass = obj.GetIngressClassName()
+		for j := range spec.Domains {
+			domain, err := rewriteDomain(obj, spec.Domains[j])
+			if err != nil {
+				return err
+			}
+			spec.Domains[j] = domain
+		}
+		if err := r.createIfNotExists(ctx, &crdsv1.Router{ObjectMeta: cloneMeta(obj, src.ObjectMeta, ns), Spec: *spec}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) cloneEnvironment(req *rApi.Request[*crdsv1.Environment]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(cloneEnvironment, req)
+
+	if isCloned(obj) {
+		return check.Completed()
+	}
+
+	src, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Spec.CloneFrom.EnvironmentName), &crdsv1.Environment{})
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	if src.Spec.TargetNamespace == "" || src.Spec.TargetNamespace == obj.Spec.TargetNamespace {
+		return check.Failed(fmt.Errorf("source environment %s has no namespace of its own, to clone from", src.Name)).Err(nil)
+	}
+
+	if err := r.cloneResources(ctx, obj, src.Spec.TargetNamespace); err != nil {
+		return check.StillRunning(err)
+	}
+
+	return check.Completed()
+}
+
+func seedJobName(seed crdsv1.EnvironmentSeed) string {
+	return fmt.Sprintf("seed-%s", seed.Name)
+}
+
+func seedJob(obj *crdsv1.Environment, seed crdsv1.EnvironmentSeed, credentialsSecret string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      seedJobName(seed),
+			Namespace: obj.Spec.TargetNamespace,
+			Labels:    map[string]string{labelEnvironmentSeed: seed.Name, constants.EnvironmentNameKey: obj.Name},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: fn.New(int32(3)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{labelEnvironmentSeed: seed.Name}},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "seed",
+							Image:   seed.Image,
+							Command: seed.Command,
+							Args:    seed.Args,
+							Env:     []corev1.EnvVar{{Name: "SNAPSHOT", Value: seed.Snapshot}},
+							EnvFrom: []corev1.EnvFromSource{
+								{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: credentialsSecret}}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// seedDatabases runs seed jobs, once their managed resources are ready, and marks the environment cloned, when all of them have succeeded
+func (r *Reconciler) seedDatabases(req *rApi.Request[*crdsv1.Environment]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(seedDatabases, req)
+
+	if isCloned(obj) {
+		return check.Completed()
+	}
+
+	for _, seed := range obj.Spec.CloneFrom.Seeds {
+		mres, err := rApi.Get(ctx, r.Client, fn.NN(obj.Spec.TargetNamespace, seed.ManagedResourceName), &crdsv1.ManagedResource{})
+		if err != nil {
+			return check.StillRunning(err)
+		}
+		if !mres.Status.IsReady || mres.Output.CredentialsRef.Name == "" {
+			return check.StillRunning(fmt.Errorf("waiting for managed resource %s to be ready, for seed %s", seed.ManagedResourceName, seed.Name))
+		}
+
+		job, err := rApi.Get(ctx, r.Client, fn.NN(obj.Spec.TargetNamespace, seedJobName(seed)), &batchv1.Job{})
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return check.StillRunning(err)
+			}
+			if err := r.createIfNotExists(ctx, seedJob(obj, seed, mres.Output.CredentialsRef.Name)); err != nil {
+				return check.StillRunning(err)
+			}
+			return check.StillRunning(fmt.Errorf("waiting for seed %s to complete", seed.Name))
+		}
+
+		for _, c := range job.Status.Conditions {
+			if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+				return check.Failed(fmt.Errorf("seed %s failed: %s", seed.Name, c.Message)).Err(nil)
+			}
+		}
+		if job.Status.Succeeded == 0 {
+			return check.StillRunning(fmt.Errorf("waiting for seed %s to complete", seed.Name))
+		}
+	}
+
+	ann := obj.GetAnnotations()
+	if ann == nil {
+		ann = make(map[string]string, 1)
+	}
+	ann[annotationClonedFrom] = obj.Spec.CloneFrom.EnvironmentName
+	obj.SetAnnotations(ann)
+
+	if err := rApi.UpdatePreservingStatus(ctx, r.Client, obj); err != nil {
+		return check.StillRunning(err)
+	}
+
+	return check.Completed()
+}
+
+// deleteSeedJobs removes seed jobs, along with the environment, as they live in its namespace, unowned
+func (r *Reconciler) deleteSeedJobs(ctx context.Context, obj *crdsv1.Environment) error {
+	return r.DeleteAllOf(ctx, &batchv1.Job{}, client.InNamespace(obj.Spec.TargetNamespace), client.HasLabels{labelEnvironmentSeed}, client.PropagationPolicy(metav1.DeletePropagationBackground))
+}
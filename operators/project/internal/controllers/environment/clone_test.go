@@ -0,0 +1,98 @@
+package environment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRewriteDomain(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		domain   string
+		want     string
+	}{
+		{name: "default template", domain: "app.example.com", want: "app-pr-42.example.com"},
+		{name: "wildcard domain", domain: "*.staging.example.com", want: "*.staging-pr-42.example.com"},
+		{name: "custom template", template: "{{.Environment}}.{{.Domain}}", domain: "app.example.com", want: "pr-42.app.example.com"},
+		{name: "source environment", template: "{{.Name}}.{{.Environment}}.preview.example.com", domain: "app.staging.example.com", want: "app.pr-42.preview.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &crdsv1.Environment{
+				ObjectMeta: metav1.ObjectMeta{Name: "pr-42"},
+				Spec:       crdsv1.EnvironmentSpec{CloneFrom: &crdsv1.EnvironmentCloneFrom{EnvironmentName: "staging", DomainTemplate: tt.template}},
+			}
+			got, err := rewriteDomain(obj, tt.domain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("rewriteDomain(%q) = %q, want %q", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloneApp(t *testing.T) {
+	obj := &crdsv1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}}
+	src := &crdsv1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "staging",
+			Labels:    map[string]string{constants.EnvironmentNameKey: "staging"},
+			Annotations: map[string]string{
+				"team":                          "payments",
+				constants.LastAppliedKey:        "{}",
+				constants.AppRolloutPromoteKey:  "true",
+				constants.AppRolloutAbortKey:    "true",
+				constants.AppRolloutRevisionKey: "abc",
+				constants.AppLastRequestAtKey:   "2023-07-01T00:00:00Z",
+			},
+		},
+		Spec: crdsv1.AppSpec{Intercept: &crdsv1.Intercept{Enabled: true, ToDevice: "laptop"}},
+	}
+
+	got := cloneApp(obj, src, "pr-42")
+
+	if got.Namespace != "pr-42" || got.Labels[constants.EnvironmentNameKey] != "pr-42" {
+		t.Fatalf("cloneApp() = %s/%s, with labels %v, want it in the cloned environment", got.Namespace, got.Name, got.Labels)
+	}
+	if want := map[string]string{"team": "payments"}; !reflect.DeepEqual(got.Annotations, want) {
+		t.Fatalf("cloneApp() annotations = %v, want %v", got.Annotations, want)
+	}
+	if got.Spec.Intercept != nil {
+		t.Fatalf("cloneApp() carried over the intercept")
+	}
+	if src.Spec.Intercept == nil || len(src.Annotations) != 6 {
+		t.Fatalf("cloneApp() modified its source")
+	}
+}
+
+func TestCloneManagedResource(t *testing.T) {
+	obj := &crdsv1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}}
+
+	prefix := "staging"
+	for _, src := range []*crdsv1.ManagedResource{
+		{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "staging"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "staging"}, Spec: crdsv1.ManagedResourceSpec{ResourceNamePrefix: &prefix}},
+	} {
+		got := cloneManagedResource(obj, src, "pr-42")
+		if got.RealResourceName() == src.RealResourceName() {
+			t.Errorf("cloneManagedResource() real resource name = %q, same as the source's", got.RealResourceName())
+		}
+		if !strings.Contains(got.RealResourceName(), "pr-42") {
+			t.Errorf("cloneManagedResource() real resource name = %q, want it to name the environment", got.RealResourceName())
+		}
+	}
+
+	if prefix != "staging" {
+		t.Fatalf("cloneManagedResource() modified its source")
+	}
+}
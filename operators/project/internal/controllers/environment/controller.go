@@ -48,6 +48,8 @@ const (
 	ensureNamespaceRBACs string = "ensure-namespace-rbac"
 	setupEnvIngress      string = "setup-env-ingress"
 	updateRouterIngress  string = "update-router-ingress"
+	cloneEnvironment     string = "clone-environment"
+	seedDatabases        string = "seed-databases"
 
 	envFinalizing string = "env-finalizing"
 )
@@ -58,7 +60,9 @@ var (
 		{Name: ensureNamespace, Title: "Ensuring Namespace"},
 		{Name: ensureNamespaceRBACs, Title: "Ensuring Namespace RBACs"},
 		{Name: setupEnvIngress, Title: "Setting up Environment Ingress"},
+		{Name: cloneEnvironment, Title: "Cloning from Source Environment"},
 		{Name: updateRouterIngress, Title: "Updating Router Ingress"},
+		{Name: seedDatabases, Title: "Seeding Databases"},
 	}
 
 	DestroyChecklist = []rApi.CheckMeta{
@@ -71,7 +75,7 @@ var (
 // +kubebuilder:rbac:groups=crds.kloudlite.io,resources=envs/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &crdsv1.Environment{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &crdsv1.Environment{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -122,10 +126,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.cloneEnvironment(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	if step := r.updateRouterIngressClasses(req); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.seedDatabases(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
 	return ctrl.Result{}, nil
 }
@@ -157,6 +169,10 @@ func (r *Reconciler) finalize(req *rApi.Request[*crdsv1.Environment]) stepResult
 		return check.StillRunning(err)
 	}
 
+	if err := r.deleteSeedJobs(ctx, obj); err != nil {
+		return check.StillRunning(err)
+	}
+
 	// routers
 	var routersList crdsv1.RouterList
 	if err := findResourceBelongingToEnvironment(ctx, r.Client, &routersList, obj.Spec.TargetNamespace); err != nil {
@@ -227,30 +243,14 @@ func (r *Reconciler) patchDefaults(req *rApi.Request[*crdsv1.Environment]) stepR
 	ctx, obj := req.Context(), req.Object
 	check := rApi.NewRunningCheck(patchDefaults, req)
 
-	hasUpdated := false
-
-	if obj.Spec.Routing == nil {
-		hasUpdated = true
-		obj.Spec.Routing = &crdsv1.EnvironmentRouting{}
-	}
-
-	if obj.Spec.Routing.Mode == "" {
-		hasUpdated = true
-		obj.Spec.Routing.Mode = crdsv1.EnvironmentRoutingModePrivate
-	}
+	changed := crdsv1.ApplyDefaults(obj)
 
 	if obj.Spec.Routing.PublicIngressClass == "" {
-		hasUpdated = true
 		obj.Spec.Routing.PublicIngressClass = r.Env.DefaultIngressClass
+		changed = true
 	}
 
-	if obj.Spec.Routing.PrivateIngressClass == "" {
-		hasUpdated = true
-		// obj.Spec.Routing.PrivateIngressClass = fmt.Sprintf("%s-env-%s", obj.Spec.TargetNamespace, obj.Name)
-		obj.Spec.Routing.PrivateIngressClass = fmt.Sprintf("k-%s", fn.Md5([]byte(fmt.Sprintf("%s-env-%s", obj.Spec.TargetNamespace, obj.Name))))
-	}
-
-	if hasUpdated {
+	if changed {
 		if err := r.Update(ctx, obj); err != nil {
 			return check.StillRunning(err)
 		}
@@ -435,6 +435,7 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) e
 		&crdsv1.Router{},
 		&networkingv1.Ingress{},
 		&corev1.Secret{},
+		&crdsv1.ManagedResource{},
 	}
 
 	for i := range watchList {
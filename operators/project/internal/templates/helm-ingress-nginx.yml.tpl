@@ -31,6 +31,9 @@ spec:
     metadata:
       name: {{$envIngress}}
       namespace: {{$releaseNamespace}}
+      labels:
+        app.kubernetes.io/component: controller
+        app.kubernetes.io/instance: {{$releaseName}}
     spec:
       ports:
       - appProtocol: http
@@ -77,6 +80,11 @@ spec:
         name: {{$ingressClassName}}
         controllerValue: "k8s.io/{{$ingressClassName}}"
 
+      # tcp/udp ports, exposed by routers of this environment. Router operator adds them to controller services, too
+      extraArgs:
+        tcp-services-configmap: {{$releaseNamespace}}/{{$ingressClassName}}-tcp-services
+        udp-services-configmap: {{$releaseNamespace}}/{{$ingressClassName}}-udp-services
+
       resources:
         requests:
           cpu: 50m
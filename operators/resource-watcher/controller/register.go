@@ -12,6 +12,7 @@ import (
 	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
 	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
 	mysqlMsvcv1 "github.com/kloudlite/operator/apis/mysql.msvc/v1"
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
 	redisMsvcv1 "github.com/kloudlite/operator/apis/redis.msvc/v1"
 	serverlessv1 "github.com/kloudlite/operator/apis/serverless/v1"
 	wireguardv1 "github.com/kloudlite/operator/apis/wireguard/v1"
@@ -32,7 +33,7 @@ func RegisterInto(mgr operator.Operator) {
 
 	mgr.AddToSchemes(
 		crdsv1.AddToScheme,
-		mongodbMsvcv1.AddToScheme, mysqlMsvcv1.AddToScheme, redisMsvcv1.AddToScheme,
+		mongodbMsvcv1.AddToScheme, mysqlMsvcv1.AddToScheme, postgresMsvcv1.AddToScheme, redisMsvcv1.AddToScheme,
 		serverlessv1.AddToScheme,
 		clustersv1.AddToScheme,
 		wireguardv1.AddToScheme,
@@ -50,6 +51,44 @@ func RegisterInto(mgr operator.Operator) {
 		watchAndUpdateReconciler,
 	)
 
+	var outbox *watchAndUpdate.OutboxMsgSender
+	if ev.OutboxDir != "" {
+		outbox, err = watchAndUpdate.NewOutboxMessageSender(ev.OutboxDir, ev.OutboxMaxEntries, logger.WithKV("component", "outbox"))
+		if err != nil {
+			panic(err)
+		}
+		watchAndUpdateReconciler.MsgSender = outbox
+		go outbox.Start(context.TODO())
+	}
+
+	setMsgSender := func(msgSender watchAndUpdate.MessageSender) {
+		if outbox != nil {
+			outbox.SetSender(msgSender)
+			return
+		}
+		watchAndUpdateReconciler.MsgSender = msgSender
+	}
+
+	if ev.MessageSender == env.MessageSenderKafka {
+		go func() {
+			logger := logger.WithKV("component", "kafka-producer")
+			for {
+				ctx, cf := context.WithTimeout(context.TODO(), 5*time.Second)
+				msgSender, err := watchAndUpdate.NewKafkaMessageSender(ctx, ev, logger)
+				cf()
+				if err != nil {
+					logger.Infof("failed to create kafka message sender, retrying: %v", err)
+					<-time.After(2 * time.Second)
+					continue
+				}
+				logger.Infof("successfully connected to kafka brokers at %s", ev.KafkaBrokers)
+				setMsgSender(msgSender)
+				return
+			}
+		}()
+		return
+	}
+
 	ping := func(cc *grpc.ClientConn) error {
 		ctx, cf := context.WithTimeout(context.TODO(), 500*time.Millisecond)
 		defer cf()
@@ -99,11 +138,11 @@ func RegisterInto(mgr operator.Operator) {
 			return err
 		}
 
-		watchAndUpdateReconciler.MsgSender = msgSender
+		setMsgSender(msgSender)
 
 		defer func() {
 			cc.Close()
-			watchAndUpdateReconciler.MsgSender = nil
+			setMsgSender(nil)
 			logger.Infof("closed grpc connection")
 		}()
 
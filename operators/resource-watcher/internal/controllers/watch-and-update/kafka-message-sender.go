@@ -1,96 +1,123 @@
 package watch_and_update
 
-// import (
-// 	"context"
-// 	"encoding/json"
-// 	"errors"
-// 	"fmt"
-// 	"time"
-//
-// 	"github.com/kloudlite/operator/operators/resource-watcher/internal/env"
-// 	t "github.com/kloudlite/operator/operators/resource-watcher/types"
-// 	"github.com/kloudlite/operator/pkg/logging"
-// 	"github.com/kloudlite/operator/pkg/redpanda"
-// )
-//
-// type kafkaMsgSender struct {
-// 	errCh                chan error
-// 	kp                   redpanda.Producer
-// 	resourceUpdatesTopic string
-// 	infraUpdatesTopic    string
-// 	logger               logging.Logger
-// }
-//
-// // DispatchInfraUpdates implements MessageSender.
-// func (k *kafkaMsgSender) DispatchInfraUpdates(ctx context.Context, ru t.ResourceUpdate) error {
-// 	b, err := json.Marshal(ru)
-// 	if err != nil {
-// 		return err
-// 	}
-//
-// 	tctx, cf := context.WithTimeout(ctx, 2*time.Second)
-// 	defer cf()
-//
-// 	if _, err := k.kp.Produce(tctx, k.infraUpdatesTopic, ru.ClusterName, b); err != nil {
-// 		if errors.Is(err, context.DeadlineExceeded) {
-// 			k.logger.Infof("conxtext deadline exceeded, took more than 2 seconds")
-// 		}
-// 		k.errCh <- err
-// 		return err
-// 	}
-//
-// 	k.logger.WithKV("timestamp", time.Now()).Infof("dispatched update to kakfa topic: %s", k.infraUpdatesTopic)
-// 	return nil
-// }
-//
-// // DispatchResourceUpdates implements MessageSender.
-// func (k *kafkaMsgSender) DispatchResourceUpdates(ctx context.Context, ru t.ResourceUpdate) error {
-// 	b, err := json.Marshal(ru)
-// 	if err != nil {
-// 		return err
-// 	}
-//
-// 	tctx, cf := context.WithTimeout(ctx, 2*time.Second)
-// 	defer cf()
-//
-// 	if _, err := k.kp.Produce(tctx, k.resourceUpdatesTopic, ru.ClusterName, b); err != nil {
-// 		if err == context.DeadlineExceeded {
-// 			k.logger.Infof("conxtext deadline exceeded, took more than 2 seconds")
-// 			return nil
-// 		}
-// 		k.errCh <- err
-// 		return err
-// 	}
-//
-// 	k.logger.WithKV("timestamp", time.Now()).Infof("dispatched update to kakfa topic: %s", k.resourceUpdatesTopic)
-// 	return nil
-// }
-//
-// type ErrConnect struct {
-// 	Err error
-// }
-//
-// func (e ErrConnect) Error() string {
-// 	return fmt.Sprintf("failed to connect to kafka, as this error occurred: %v", e.Err)
-// }
-//
-// func NewKafkaMessageSender(ctx context.Context, ev *env.Env, logger logging.Logger) (MessageSender, error) {
-// 	kp, err := redpanda.NewProducer(ev.KafkaBrokers, redpanda.ProducerOpts{
-// 		Logger:   logger,
-// 		SASLAuth: nil,
-// 	})
-// 	if err != nil {
-// 		return nil, err
-// 	}
-//
-// 	if err := kp.Ping(ctx); err != nil {
-// 		return nil, ErrConnect{Err: err}
-// 	}
-//
-// 	return &kafkaMsgSender{
-// 		kp:                   kp,
-// 		logger:               logger,
-// 		resourceUpdatesTopic: ev.KafkaResourceUpdatesTopic,
-// 		infraUpdatesTopic:    ev.KafkaInfraUpdatesTopic,
-// 	}, nil
-// }
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kloudlite/operator/operators/resource-watcher/internal/env"
+	t "github.com/kloudlite/operator/operators/resource-watcher/types"
+	"github.com/kloudlite/operator/pkg/logging"
+	"github.com/kloudlite/operator/pkg/redpanda"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type kafkaMsgSender struct {
+	kp     redpanda.Producer
+	logger logging.Logger
+
+	consoleTopic           string
+	infraTopic             string
+	containerRegistryTopic string
+
+	// kindTopics overrides dispatch target topic, for specific resource kinds
+	kindTopics map[string]string
+}
+
+// kafkaMsgKey keeps all updates of an object on the same partition, so that they are consumed in order
+func kafkaMsgKey(ru t.ResourceUpdate) string {
+	obj := unstructured.Unstructured{Object: ru.Object}
+	return fmt.Sprintf("account=%s/cluster=%s/kind=%s/namespace=%s/name=%s", ru.AccountName, ru.ClusterName, obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+func (k *kafkaMsgSender) topicFor(defaultTopic string, ru t.ResourceUpdate) string {
+	obj := unstructured.Unstructured{Object: ru.Object}
+	if topic, ok := k.kindTopics[obj.GetKind()]; ok {
+		return topic
+	}
+	return defaultTopic
+}
+
+func (k *kafkaMsgSender) dispatch(ctx context.Context, topic string, ru t.ResourceUpdate) error {
+	b, err := json.Marshal(ru)
+	if err != nil {
+		return err
+	}
+
+	tctx, cf := context.WithTimeout(ctx, 2*time.Second)
+	defer cf()
+
+	topic = k.topicFor(topic, ru)
+	if _, err := k.kp.Produce(tctx, topic, kafkaMsgKey(ru), b); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			k.logger.Infof("context deadline exceeded, took more than 2 seconds")
+		}
+		return err
+	}
+
+	k.logger.WithKV("timestamp", time.Now()).Infof("dispatched update to kafka topic: %s", topic)
+	return nil
+}
+
+// DispatchConsoleResourceUpdates implements MessageSender.
+func (k *kafkaMsgSender) DispatchConsoleResourceUpdates(ctx context.Context, ru t.ResourceUpdate) error {
+	return k.dispatch(ctx, k.consoleTopic, ru)
+}
+
+// DispatchInfraResourceUpdates implements MessageSender.
+func (k *kafkaMsgSender) DispatchInfraResourceUpdates(ctx context.Context, ru t.ResourceUpdate) error {
+	return k.dispatch(ctx, k.infraTopic, ru)
+}
+
+// DispatchContainerRegistryResourceUpdates implements MessageSender.
+func (k *kafkaMsgSender) DispatchContainerRegistryResourceUpdates(ctx context.Context, ru t.ResourceUpdate) error {
+	return k.dispatch(ctx, k.containerRegistryTopic, ru)
+}
+
+type ErrConnect struct {
+	Err error
+}
+
+func (e ErrConnect) Error() string {
+	return fmt.Sprintf("failed to connect to kafka, as this error occurred: %v", e.Err)
+}
+
+func NewKafkaMessageSender(ctx context.Context, ev *env.Env, logger logging.Logger) (MessageSender, error) {
+	var saslAuth *redpanda.KafkaSASLAuth
+	if ev.KafkaSASLUsername != "" {
+		saslAuth = &redpanda.KafkaSASLAuth{
+			SASLMechanism: redpanda.SASLMechanism(ev.KafkaSASLMechanism),
+			User:          ev.KafkaSASLUsername,
+			Password:      ev.KafkaSASLPassword,
+		}
+	}
+
+	kindTopics, err := ev.ParseKafkaKindTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	kp, err := redpanda.NewProducer(ev.KafkaBrokers, redpanda.ProducerOpts{
+		Logger:   logger,
+		SASLAuth: saslAuth,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := kp.Ping(ctx); err != nil {
+		kp.Close()
+		return nil, ErrConnect{Err: err}
+	}
+
+	return &kafkaMsgSender{
+		kp:                     kp,
+		logger:                 logger,
+		consoleTopic:           ev.KafkaConsoleTopic,
+		infraTopic:             ev.KafkaInfraTopic,
+		containerRegistryTopic: ev.KafkaContainerRegistryTopic,
+		kindTopics:             kindTopics,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package watch_and_update
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kloudlite/operator/operators/resource-watcher/internal/env"
+	rwTypes "github.com/kloudlite/operator/operators/resource-watcher/types"
+)
+
+func resourceUpdate(kind string, namespace string, name string) rwTypes.ResourceUpdate {
+	return rwTypes.ResourceUpdate{
+		AccountName: "acc",
+		ClusterName: "cluster",
+		Object: map[string]any{
+			"apiVersion": "crds.kloudlite.io/v1",
+			"kind":       kind,
+			"metadata":   map[string]any{"name": name, "namespace": namespace},
+		},
+	}
+}
+
+func TestKafkaMsgKey(t *testing.T) {
+	tests := []struct {
+		name string
+		ru   rwTypes.ResourceUpdate
+		want string
+	}{
+		{
+			name: "namespaced object",
+			ru:   resourceUpdate("App", "sample", "web"),
+			want: "account=acc/cluster=cluster/kind=App/namespace=sample/name=web",
+		},
+		{
+			name: "cluster scoped object",
+			ru:   resourceUpdate("Namespace", "", "sample"),
+			want: "account=acc/cluster=cluster/kind=Namespace/namespace=/name=sample",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kafkaMsgKey(tt.ru); got != tt.want {
+				t.Errorf("kafkaMsgKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("key is stable across updates of an object", func(t *testing.T) {
+		ru := resourceUpdate("App", "sample", "web")
+		updated := resourceUpdate("App", "sample", "web")
+		updated.Object["spec"] = map[string]any{"replicas": 3}
+		updated.Object["status"] = map[string]any{"isReady": true}
+
+		if kafkaMsgKey(ru) != kafkaMsgKey(updated) {
+			t.Errorf("kafkaMsgKey() differs across updates of the same object: %q, %q", kafkaMsgKey(ru), kafkaMsgKey(updated))
+		}
+		if kafkaMsgKey(ru) == kafkaMsgKey(resourceUpdate("App", "sample", "api")) {
+			t.Errorf("kafkaMsgKey() is the same, for different objects")
+		}
+	})
+}
+
+func TestTopicFor(t *testing.T) {
+	k := &kafkaMsgSender{kindTopics: map[string]string{"App": "apps", "Router": "routers"}}
+
+	tests := []struct {
+		name string
+		ru   rwTypes.ResourceUpdate
+		want string
+	}{
+		{name: "kind with its own topic", ru: resourceUpdate("App", "sample", "web"), want: "apps"},
+		{name: "another kind with its own topic", ru: resourceUpdate("Router", "sample", "web"), want: "routers"},
+		{name: "kind without an override", ru: resourceUpdate("Environment", "sample", "dev"), want: "console"},
+		{name: "kinds are case sensitive", ru: resourceUpdate("app", "sample", "web"), want: "console"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := k.topicFor("console", tt.ru); got != tt.want {
+				t.Errorf("topicFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no overrides", func(t *testing.T) {
+		if got := (&kafkaMsgSender{}).topicFor("infra", resourceUpdate("App", "sample", "web")); got != "infra" {
+			t.Errorf("topicFor() = %q, want infra", got)
+		}
+	})
+}
+
+func TestParseKafkaKindTopics(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "not set", value: "", want: map[string]string{}},
+		{name: "single kind", value: "App=apps", want: map[string]string{"App": "apps"}},
+		{name: "many kinds, with spaces", value: "App=apps, Router=routers", want: map[string]string{"App": "apps", "Router": "routers"}},
+		{name: "topic with an equals sign", value: "App=apps=v2", want: map[string]string{"App": "apps=v2"}},
+		{name: "later override of a kind wins", value: "App=apps,App=apps-v2", want: map[string]string{"App": "apps-v2"}},
+		{name: "missing topic", value: "App", wantErr: true},
+		{name: "empty topic", value: "App=", wantErr: true},
+		{name: "empty kind", value: "=apps", wantErr: true},
+		{name: "empty entry", value: "App=apps,,Router=routers", wantErr: true},
+		{name: "trailing comma", value: "App=apps,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := &env.Env{KafkaKindTopics: tt.value}
+			got, err := ev.ParseKafkaKindTopics()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKafkaKindTopics() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKafkaKindTopics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
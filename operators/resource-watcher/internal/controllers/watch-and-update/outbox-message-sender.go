@@ -0,0 +1,569 @@
+package watch_and_update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	t "github.com/kloudlite/operator/operators/resource-watcher/types"
+	"github.com/kloudlite/operator/pkg/errors"
+	"github.com/kloudlite/operator/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type dispatchTarget string
+
+const (
+	targetConsole           dispatchTarget = "console"
+	targetInfra             dispatchTarget = "infra"
+	targetContainerRegistry dispatchTarget = "container-registry"
+)
+
+const (
+	outboxFileName           = "outbox.log"
+	outboxDeadLetterFileName = "outbox.dead-letter.log"
+)
+
+// an update, that fails to dispatch, is retried with exponential backoff, and is moved to the dead letter log, once it has failed
+// outboxMaxAttempts times in a row
+const (
+	outboxRetryBaseDelay = 1 * time.Second
+	outboxRetryMaxDelay  = 1 * time.Minute
+	outboxMaxAttempts    = 20
+)
+
+type outboxOp string
+
+const (
+	outboxOpPut outboxOp = "put"
+	outboxOpAck outboxOp = "ack"
+)
+
+// outboxRecord is a line of the outbox log. A put queues an update, replacing one queued earlier for the same object, while an ack
+// removes an update, once it has been dispatched
+type outboxRecord struct {
+	Op    outboxOp    `json:"op"`
+	Entry outboxEntry `json:"entry"`
+}
+
+type outboxEntry struct {
+	Key      string           `json:"key"`
+	Seq      uint64           `json:"seq"`
+	Target   dispatchTarget   `json:"target"`
+	Update   t.ResourceUpdate `json:"update"`
+	QueuedAt time.Time        `json:"queuedAt"`
+	// Redacted entries had secrets stripped off their update, when they were written to disk
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// secretKeys are keys of a resource update, that carry secrets, fetched along with its object
+var secretKeys = []string{t.KeyManagedResSecret, t.KeyProjectManagedSvcSecret, t.KeyClusterManagedSvcSecret, t.KeyVPNDeviceConfig}
+
+// redact strips secrets off entry, before it is written to disk. Those are data of a Secret, and secrets fetched along with an object,
+// like output secrets of managed resources. Entry in memory keeps them, so that they are still dispatched
+func redact(entry outboxEntry) outboxEntry {
+	obj := entry.Update.Object
+
+	var keys []string
+	if obj["apiVersion"] == "v1" && obj["kind"] == "Secret" {
+		keys = append(keys, "data", "stringData")
+	}
+	keys = append(keys, secretKeys...)
+
+	redacted := make(map[string]any, len(obj))
+	for k, v := range obj {
+		redacted[k] = v
+	}
+	for _, k := range keys {
+		if _, ok := redacted[k]; ok {
+			delete(redacted, k)
+			entry.Redacted = true
+		}
+	}
+
+	if entry.Redacted {
+		entry.Update.Object = redacted
+	}
+	return entry
+}
+
+// isDeletion tells whether update reports its object as deleted, which is all that's left of it, once it is gone from the cluster
+func isDeletion(ru t.ResourceUpdate) bool {
+	return fmt.Sprint(ru.Object[t.ResourceStatusKey]) == t.ResourceStatusDeleted.String()
+}
+
+func marshalRecord(rec outboxRecord) ([]byte, error) {
+	if rec.Op == outboxOpPut {
+		rec.Entry = redact(rec.Entry)
+	}
+	return json.Marshal(rec)
+}
+
+// outboxRetry tracks failed dispatches of a queued update. It is kept only in memory, so updates get retried right away after a restart
+type outboxRetry struct {
+	seq           uint64
+	attempts      int
+	nextAttemptAt time.Time
+}
+
+// deadLetterRecord is a line of the dead letter log, an update, that was given up on
+type deadLetterRecord struct {
+	Entry    outboxEntry `json:"entry"`
+	Attempts int         `json:"attempts"`
+	Error    string      `json:"error"`
+	FailedAt time.Time   `json:"failedAt"`
+}
+
+var ErrOutboxFull error = fmt.Errorf("outbox is full")
+
+// OutboxMsgSender persists every resource update on disk, before handing it over to the underlying MessageSender.
+// Updates for the same object are coalesced, so that only the latest one is dispatched, and the rest are drained in order
+// once the underlying sender is available again.
+//
+// Updates, and their dispatches are appended to a log, which is compacted into the pending updates, once it has grown by
+// maxEntries records beyond them. Queued updates in memory change only after they are written to the log.
+//
+// An update, that keeps failing to dispatch, does not hold back the others. It is retried with backoff, and eventually moved
+// to a dead letter log, next to the outbox log. Secrets are never written to disk.
+type OutboxMsgSender struct {
+	sync.Mutex
+	logger             logging.Logger
+	filePath           string
+	deadLetterFilePath string
+	maxEntries         int
+
+	logFile    *os.File
+	logSize    int64
+	logRecords int
+
+	sender  MessageSender
+	entries []outboxEntry
+	seq     uint64
+	retries map[string]outboxRetry
+
+	notifyCh chan struct{}
+}
+
+func outboxKey(target dispatchTarget, ru t.ResourceUpdate) string {
+	obj := unstructured.Unstructured{Object: ru.Object}
+	return fmt.Sprintf("%s/%s/%s/%s/%s", target, obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// SetSender swaps the underlying MessageSender, nil means, it is currently unavailable. Updates, that failed with the previous
+// sender, are retried right away
+func (o *OutboxMsgSender) SetSender(sender MessageSender) {
+	o.Lock()
+	o.sender = sender
+	o.retries = map[string]outboxRetry{}
+	o.Unlock()
+	o.notify()
+}
+
+func (o *OutboxMsgSender) Len() int {
+	o.Lock()
+	defer o.Unlock()
+	return len(o.entries)
+}
+
+func (o *OutboxMsgSender) notify() {
+	select {
+	case o.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+func (o *OutboxMsgSender) enqueue(target dispatchTarget, ru t.ResourceUpdate) error {
+	o.Lock()
+	defer o.Unlock()
+
+	entry := outboxEntry{Key: outboxKey(target, ru), Seq: o.seq + 1, Target: target, Update: ru, QueuedAt: time.Now()}
+
+	// coalescing: older update for the same object would anyway be overridden by this one
+	if indexOfKey(o.entries, entry.Key) == -1 && len(o.entries) >= o.maxEntries {
+		return ErrOutboxFull
+	}
+
+	if err := o.appendRecord(outboxRecord{Op: outboxOpPut, Entry: entry}); err != nil {
+		return err
+	}
+
+	o.seq = entry.Seq
+	o.entries = applyRecord(o.entries, outboxRecord{Op: outboxOpPut, Entry: entry})
+	o.compactIfNeeded()
+
+	o.notify()
+	return nil
+}
+
+func indexOfKey(entries []outboxEntry, key string) int {
+	for i := range entries {
+		if entries[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyRecord replays a record of the outbox log onto entries
+func applyRecord(entries []outboxEntry, rec outboxRecord) []outboxEntry {
+	idx := indexOfKey(entries, rec.Entry.Key)
+
+	switch rec.Op {
+	case outboxOpPut:
+		if idx != -1 {
+			entries[idx] = rec.Entry
+			return entries
+		}
+		return append(entries, rec.Entry)
+	case outboxOpAck:
+		// entry might have been coalesced with a newer update, while it was being dispatched
+		if idx != -1 && entries[idx].Seq == rec.Entry.Seq {
+			return append(entries[:idx], entries[idx+1:]...)
+		}
+	}
+	return entries
+}
+
+// appendRecord must be called with lock held. A record, that is written only partially, or fails to sync, is truncated off the log,
+// so that later records still follow a complete line, and queued updates in memory match the log
+func (o *OutboxMsgSender) appendRecord(rec outboxRecord) error {
+	b, err := marshalRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := o.logFile.Write(append(b, '\n')); err != nil {
+		if terr := o.logFile.Truncate(o.logSize); terr != nil {
+			o.logger.Errorf(terr, "failed to truncate partially written outbox record")
+		}
+		return errors.NewEf(err, "failed to write outbox log")
+	}
+	if err := o.logFile.Sync(); err != nil {
+		if terr := o.logFile.Truncate(o.logSize); terr != nil {
+			// record stays in the log, so it is accounted for, to keep later truncates from cutting it in half
+			o.logger.Errorf(terr, "failed to truncate unsynced outbox record")
+			o.logSize += int64(len(b) + 1)
+			o.logRecords++
+		}
+		return errors.NewEf(err, "failed to sync outbox log")
+	}
+
+	o.logSize += int64(len(b) + 1)
+	o.logRecords++
+	return nil
+}
+
+// compactIfNeeded must be called with lock held. Failing to compact leaves the log as it was, so it is only logged
+func (o *OutboxMsgSender) compactIfNeeded() {
+	if o.logRecords-len(o.entries) < o.maxEntries {
+		return
+	}
+	if err := o.compact(); err != nil {
+		o.logger.Errorf(err, "failed to compact outbox log, will retry")
+	}
+}
+
+// compact must be called with lock held. It rewrites the log with only the pending entries, and swaps it in atomically
+func (o *OutboxMsgSender) compact() error {
+	buf := new(bytes.Buffer)
+	for i := range o.entries {
+		b, err := marshalRecord(outboxRecord{Op: outboxOpPut, Entry: o.entries[i]})
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	tmpFile := o.filePath + ".tmp"
+	if err := writeFileSync(tmpFile, buf.Bytes()); err != nil {
+		return errors.NewEf(err, "failed to write outbox log")
+	}
+	if err := os.Rename(tmpFile, o.filePath); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(o.filePath, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if o.logFile != nil {
+		o.logFile.Close()
+	}
+	o.logFile = f
+	o.logSize = int64(buf.Len())
+	o.logRecords = len(o.entries)
+	return nil
+}
+
+func writeFileSync(name string, b []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// load replays the outbox log, and compacts it. A last line, that does not parse, is a record, that was being written, when the
+// process died, and is dropped
+func (o *OutboxMsgSender) load() error {
+	b, err := os.ReadFile(o.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var pending *outboxRecord
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), len(b)+1)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		if pending != nil {
+			return errors.Newf("failed to parse outbox log (%s), at line %d", o.filePath, line-1)
+		}
+
+		var rec outboxRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			pending = &rec
+			continue
+		}
+
+		o.entries = applyRecord(o.entries, rec)
+		if rec.Entry.Seq > o.seq {
+			o.seq = rec.Entry.Seq
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return errors.NewEf(err, "failed to read outbox log (%s)", o.filePath)
+	}
+
+	if pending != nil {
+		o.logger.Infof("dropping partially written record, at the end of outbox log (%s)", o.filePath)
+	}
+
+	// redacted updates would overwrite secrets with nothing. Their objects are dispatched again, in full, as the watcher lists them on
+	// start, so only deletions, of objects that are gone, are kept
+	kept := o.entries[:0]
+	for _, entry := range o.entries {
+		if entry.Redacted && !isDeletion(entry.Update) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if dropped := len(o.entries) - len(kept); dropped > 0 {
+		o.logger.Infof("dropping %d redacted resource updates, from outbox log (%s), their objects get dispatched again", dropped, o.filePath)
+	}
+	o.entries = kept
+
+	return o.compact()
+}
+
+func dispatch(ctx context.Context, sender MessageSender, entry outboxEntry) error {
+	switch entry.Target {
+	case targetConsole:
+		return sender.DispatchConsoleResourceUpdates(ctx, entry.Update)
+	case targetInfra:
+		return sender.DispatchInfraResourceUpdates(ctx, entry.Update)
+	case targetContainerRegistry:
+		return sender.DispatchContainerRegistryResourceUpdates(ctx, entry.Update)
+	default:
+		return fmt.Errorf("unknown dispatch target (%s)", entry.Target)
+	}
+}
+
+// Drain dispatches queued updates in order. An update, that fails to dispatch, is retried with backoff, while the ones after it
+// keep draining
+func (o *OutboxMsgSender) Drain(ctx context.Context) error {
+	o.Lock()
+	now := time.Now()
+	due := make([]outboxEntry, 0, len(o.entries))
+	for _, entry := range o.entries {
+		if r, ok := o.retries[entry.Key]; ok && r.seq == entry.Seq && now.Before(r.nextAttemptAt) {
+			continue
+		}
+		due = append(due, entry)
+	}
+	o.Unlock()
+
+	var failed int
+	var lastErr error
+	for _, entry := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		o.Lock()
+		sender := o.sender
+		o.Unlock()
+		if sender == nil {
+			break
+		}
+
+		dctx, cf := context.WithTimeout(ctx, 2*time.Second)
+		err := dispatch(dctx, sender, entry)
+		cf()
+		if err != nil {
+			failed++
+			lastErr = err
+			o.retryLater(entry, err)
+			continue
+		}
+
+		if err := o.ack(entry); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return errors.NewEf(lastErr, "failed to dispatch %d resource updates", failed)
+	}
+	return nil
+}
+
+// ack removes a dispatched entry from outbox, unless it has been coalesced with a newer update, while it was being dispatched
+func (o *OutboxMsgSender) ack(entry outboxEntry) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if idx := indexOfKey(o.entries, entry.Key); idx == -1 || o.entries[idx].Seq != entry.Seq {
+		return nil
+	}
+
+	ack := outboxRecord{Op: outboxOpAck, Entry: outboxEntry{Key: entry.Key, Seq: entry.Seq}}
+	if err := o.appendRecord(ack); err != nil {
+		return err
+	}
+	o.entries = applyRecord(o.entries, ack)
+	delete(o.retries, entry.Key)
+	o.compactIfNeeded()
+	return nil
+}
+
+// retryLater backs off dispatching entry, and moves it to the dead letter log, once it has failed outboxMaxAttempts times
+func (o *OutboxMsgSender) retryLater(entry outboxEntry, dispatchErr error) {
+	o.Lock()
+	r, ok := o.retries[entry.Key]
+	if !ok || r.seq != entry.Seq {
+		r = outboxRetry{seq: entry.Seq}
+	}
+	r.attempts++
+
+	delay := outboxRetryBaseDelay
+	for i := 1; i < r.attempts && delay < outboxRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	r.nextAttemptAt = time.Now().Add(min(delay, outboxRetryMaxDelay))
+	o.retries[entry.Key] = r
+	o.Unlock()
+
+	if r.attempts < outboxMaxAttempts {
+		return
+	}
+
+	if err := o.deadLetter(entry, r.attempts, dispatchErr); err != nil {
+		o.logger.Errorf(err, "failed to move resource update (%s) to dead letter log, will retry", entry.Key)
+		return
+	}
+	if err := o.ack(entry); err != nil {
+		o.logger.Errorf(err, "failed to remove dead lettered resource update (%s) from outbox", entry.Key)
+		return
+	}
+	o.logger.Warnf("gave up on dispatching resource update (%s), after %d attempts, moved it to dead letter log (%s)", entry.Key, r.attempts, o.deadLetterFilePath)
+}
+
+// deadLetter appends entry to the dead letter log, which is only ever appended to, for updates to be inspected, or replayed by hand
+func (o *OutboxMsgSender) deadLetter(entry outboxEntry, attempts int, dispatchErr error) error {
+	b, err := json.Marshal(deadLetterRecord{Entry: redact(entry), Attempts: attempts, Error: dispatchErr.Error(), FailedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(o.deadLetterFilePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Start keeps draining outbox, whenever something new is queued, or the underlying sender changes
+func (o *OutboxMsgSender) Start(ctx context.Context) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.notifyCh:
+		case <-ticker.C:
+		}
+
+		if err := o.Drain(ctx); err != nil {
+			o.logger.Infof("failed to drain outbox (pending: %d), will retry, as: %v", o.Len(), err)
+		}
+	}
+}
+
+// DispatchConsoleResourceUpdates implements MessageSender.
+func (o *OutboxMsgSender) DispatchConsoleResourceUpdates(ctx context.Context, ru t.ResourceUpdate) error {
+	return o.enqueue(targetConsole, ru)
+}
+
+// DispatchInfraResourceUpdates implements MessageSender.
+func (o *OutboxMsgSender) DispatchInfraResourceUpdates(ctx context.Context, ru t.ResourceUpdate) error {
+	return o.enqueue(targetInfra, ru)
+}
+
+// DispatchContainerRegistryResourceUpdates implements MessageSender.
+func (o *OutboxMsgSender) DispatchContainerRegistryResourceUpdates(ctx context.Context, ru t.ResourceUpdate) error {
+	return o.enqueue(targetContainerRegistry, ru)
+}
+
+func NewOutboxMessageSender(dir string, maxEntries int, logger logging.Logger) (*OutboxMsgSender, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.NewEf(err, "failed to create outbox dir (%s)", dir)
+	}
+
+	o := &OutboxMsgSender{
+		logger:             logger,
+		filePath:           filepath.Join(dir, outboxFileName),
+		deadLetterFilePath: filepath.Join(dir, outboxDeadLetterFileName),
+		maxEntries:         maxEntries,
+		retries:            map[string]outboxRetry{},
+		notifyCh:           make(chan struct{}, 1),
+	}
+
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+
+	if len(o.entries) > 0 {
+		logger.Infof("loaded %d pending resource updates from outbox", len(o.entries))
+	}
+
+	return o, nil
+}
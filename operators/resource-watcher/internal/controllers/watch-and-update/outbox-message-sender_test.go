@@ -0,0 +1,306 @@
+package watch_and_update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	rwTypes "github.com/kloudlite/operator/operators/resource-watcher/types"
+	"github.com/kloudlite/operator/pkg/logging"
+)
+
+type fakeSender struct {
+	fail bool
+	// failing are names of objects, whose updates fail to dispatch
+	failing    map[string]bool
+	dispatched []string
+}
+
+func (f *fakeSender) record(target dispatchTarget, ru rwTypes.ResourceUpdate) error {
+	md := ru.Object["metadata"].(map[string]any)
+	if f.fail || f.failing[fmt.Sprint(md["name"])] {
+		return fmt.Errorf("unavailable")
+	}
+	f.dispatched = append(f.dispatched, fmt.Sprintf("%s:%s:%s", target, md["name"], ru.Object["generation"]))
+	return nil
+}
+
+func (f *fakeSender) DispatchConsoleResourceUpdates(_ context.Context, ru rwTypes.ResourceUpdate) error {
+	return f.record(targetConsole, ru)
+}
+
+func (f *fakeSender) DispatchInfraResourceUpdates(_ context.Context, ru rwTypes.ResourceUpdate) error {
+	return f.record(targetInfra, ru)
+}
+
+func (f *fakeSender) DispatchContainerRegistryResourceUpdates(_ context.Context, ru rwTypes.ResourceUpdate) error {
+	return f.record(targetContainerRegistry, ru)
+}
+
+func newUpdate(name string, generation string) rwTypes.ResourceUpdate {
+	return rwTypes.ResourceUpdate{
+		Object: map[string]any{
+			"apiVersion": "crds.kloudlite.io/v1",
+			"kind":       "App",
+			"metadata":   map[string]any{"name": name, "namespace": "sample"},
+			"generation": generation,
+		},
+	}
+}
+
+func TestOutboxMsgSender(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	logger := logging.NewOrDie(&logging.Options{Dev: true})
+
+	outbox, err := NewOutboxMessageSender(dir, 2, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no sender, so everything stays in outbox
+	for _, ru := range []rwTypes.ResourceUpdate{newUpdate("app-1", "1"), newUpdate("app-2", "1"), newUpdate("app-1", "2")} {
+		if err := outbox.DispatchConsoleResourceUpdates(ctx, ru); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := outbox.Len(); got != 2 {
+		t.Fatalf("updates for same object should be coalesced, got %d entries, want 2", got)
+	}
+
+	if err := outbox.DispatchConsoleResourceUpdates(ctx, newUpdate("app-3", "1")); err != ErrOutboxFull {
+		t.Fatalf("expected ErrOutboxFull, got %v", err)
+	}
+
+	// outbox must survive a restart
+	outbox, err = NewOutboxMessageSender(dir, 2, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failing := &fakeSender{fail: true}
+	outbox.SetSender(failing)
+	if err := outbox.Drain(ctx); err == nil {
+		t.Fatalf("expected drain to fail, when sender is failing")
+	}
+	if got := outbox.Len(); got != 2 {
+		t.Fatalf("failed dispatches must not be dropped, got %d entries, want 2", got)
+	}
+
+	sender := &fakeSender{}
+	outbox.SetSender(sender)
+	if err := outbox.Drain(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"console:app-1:2", "console:app-2:1"}
+	if !reflect.DeepEqual(sender.dispatched, want) {
+		t.Errorf("dispatched = %v, want %v", sender.dispatched, want)
+	}
+
+	if got := outbox.Len(); got != 0 {
+		t.Errorf("outbox should be empty after drain, got %d entries", got)
+	}
+}
+
+func TestOutboxLog(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	logger := logging.NewOrDie(&logging.Options{Dev: true})
+
+	outbox, err := NewOutboxMessageSender(dir, 2, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox.SetSender(&fakeSender{})
+
+	// log is compacted, once it grows by maxEntries records beyond pending updates
+	for i := 0; i < 10; i++ {
+		if err := outbox.DispatchConsoleResourceUpdates(ctx, newUpdate("app-1", fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+		if err := outbox.Drain(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, outboxFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(b), "\n"); lines > 2 {
+		t.Fatalf("outbox log has %d records, with nothing pending, want it compacted", lines)
+	}
+
+	// a failed write leaves queued updates as they were
+	outbox.SetSender(nil)
+	if err := outbox.DispatchConsoleResourceUpdates(ctx, newUpdate("app-1", "10")); err != nil {
+		t.Fatal(err)
+	}
+	outbox.logFile.Close()
+	if err := outbox.DispatchConsoleResourceUpdates(ctx, newUpdate("app-2", "1")); err == nil {
+		t.Fatalf("expected enqueue to fail, when outbox log can not be written")
+	}
+	if got := outbox.Len(); got != 1 {
+		t.Fatalf("failed enqueue must not change queued updates, got %d entries, want 1", got)
+	}
+
+	// a record, that was being written, when the process died, is dropped on restart
+	f, err := os.OpenFile(filepath.Join(dir, outboxFileName), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"op":"put","entry":{"key":`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	outbox, err = NewOutboxMessageSender(dir, 2, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := &fakeSender{}
+	outbox.SetSender(sender)
+	if err := outbox.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"console:app-1:10"}; !reflect.DeepEqual(sender.dispatched, want) {
+		t.Errorf("dispatched = %v, want %v", sender.dispatched, want)
+	}
+}
+
+func TestOutboxRetries(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	logger := logging.NewOrDie(&logging.Options{Dev: true})
+
+	outbox, err := NewOutboxMessageSender(dir, 4, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ru := range []rwTypes.ResourceUpdate{newUpdate("app-1", "1"), newUpdate("app-2", "1"), newUpdate("app-3", "1")} {
+		if err := outbox.DispatchConsoleResourceUpdates(ctx, ru); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// an update, that keeps failing, must not hold back the ones after it
+	sender := &fakeSender{failing: map[string]bool{"app-1": true}}
+	outbox.SetSender(sender)
+	if err := outbox.Drain(ctx); err == nil {
+		t.Fatal("expected drain to report the failed dispatch")
+	}
+	if want := []string{"console:app-2:1", "console:app-3:1"}; !reflect.DeepEqual(sender.dispatched, want) {
+		t.Fatalf("dispatched = %v, want %v", sender.dispatched, want)
+	}
+
+	// failed update backs off
+	if err := outbox.Drain(ctx); err != nil {
+		t.Fatalf("expected failed update to be skipped, while it backs off, got %v", err)
+	}
+	if r := outbox.retries[outboxKey(targetConsole, newUpdate("app-1", "1"))]; r.attempts != 1 || !r.nextAttemptAt.After(time.Now()) {
+		t.Fatalf("retry = %+v, want 1 attempt, backing off", r)
+	}
+
+	for i := 1; i < outboxMaxAttempts; i++ {
+		for k, r := range outbox.retries {
+			r.nextAttemptAt = time.Time{}
+			outbox.retries[k] = r
+		}
+		outbox.Drain(ctx)
+	}
+
+	if got := outbox.Len(); got != 0 {
+		t.Fatalf("update should be dead lettered, after %d attempts, got %d entries in outbox", outboxMaxAttempts, got)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, outboxDeadLetterFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec deadLetterRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Entry.Key != outboxKey(targetConsole, newUpdate("app-1", "1")) || rec.Attempts != outboxMaxAttempts {
+		t.Errorf("dead letter record = %+v, want app-1, after %d attempts", rec, outboxMaxAttempts)
+	}
+
+	// dead lettered update stays out of outbox, across restarts
+	outbox, err = NewOutboxMessageSender(dir, 4, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outbox.Len(); got != 0 {
+		t.Errorf("outbox has %d entries after restart, want 0", got)
+	}
+}
+
+func TestOutboxRedactsSecrets(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	logger := logging.NewOrDie(&logging.Options{Dev: true})
+
+	outbox, err := NewOutboxMessageSender(dir, 4, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := func(name string, status rwTypes.ResourceStatus) rwTypes.ResourceUpdate {
+		return rwTypes.ResourceUpdate{Object: map[string]any{
+			"apiVersion":              "v1",
+			"kind":                    "Secret",
+			"metadata":                map[string]any{"name": name, "namespace": "sample"},
+			"generation":              "1",
+			"data":                    map[string]any{"password": "c3VwZXItc2VjcmV0"},
+			"stringData":              map[string]any{"password": "super-secret"},
+			rwTypes.ResourceStatusKey: status,
+		}}
+	}
+
+	mres := newUpdate("db", "1")
+	mres.Object["kind"] = "ManagedResource"
+	mres.Object[rwTypes.KeyManagedResSecret] = map[string]any{"data": map[string]any{"password": "c3VwZXItc2VjcmV0"}}
+
+	for _, ru := range []rwTypes.ResourceUpdate{secret("creds", rwTypes.ResourceStatusUpdated), secret("old-creds", rwTypes.ResourceStatusDeleted), mres} {
+		if err := outbox.DispatchConsoleResourceUpdates(ctx, ru); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, outboxFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "super-secret") || strings.Contains(string(b), "c3VwZXItc2VjcmV0") {
+		t.Fatalf("outbox log has secrets written to disk: %s", b)
+	}
+
+	// updates in memory still carry their secrets
+	if _, ok := outbox.entries[0].Update.Object["data"]; !ok {
+		t.Fatal("queued secret update lost its data")
+	}
+
+	// after a restart, only deletions of redacted updates are left, as the rest get dispatched again
+	outbox, err = NewOutboxMessageSender(dir, 4, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := &fakeSender{}
+	outbox.SetSender(sender)
+	if err := outbox.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"console:old-creds:1"}; !reflect.DeepEqual(sender.dispatched, want) {
+		t.Errorf("dispatched = %v, want %v", sender.dispatched, want)
+	}
+}
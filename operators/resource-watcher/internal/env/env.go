@@ -1,23 +1,81 @@
 package env
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/codingconcepts/env"
 )
 
+type MessageSenderType string
+
+const (
+	MessageSenderGrpc  MessageSenderType = "grpc"
+	MessageSenderKafka MessageSenderType = "kafka"
+)
+
 type Env struct {
 	IsDev                   bool   `env:"IS_DEV"`
 	MaxConcurrentReconciles int    `env:"MAX_CONCURRENT_RECONCILES" required:"true"`
 	AccountName             string `env:"ACCOUNT_NAME" required:"true"`
 	ClusterName             string `env:"CLUSTER_NAME" required:"true"`
 
-	GrpcAddr          string `env:"GRPC_ADDR" required:"true"`
+	MessageSender MessageSenderType `env:"MESSAGE_SENDER" default:"grpc"`
+
+	GrpcAddr          string `env:"GRPC_ADDR"`
 	GrpcSecureConnect bool   `env:"GRPC_SECURE_CONNECT" default:"true"`
 
-	AccessToken                    string `env:"ACCESS_TOKEN" required:"true"`
+	AccessToken                    string `env:"ACCESS_TOKEN"`
 	ClusterIdentitySecretName      string `env:"CLUSTER_IDENTITY_SECRET_NAME" required:"true"`
 	ClusterIdentitySecretNamespace string `env:"CLUSTER_IDENTITY_SECRET_NAMESPACE" required:"true"`
 
 	DeviceNamespace string `env:"DEVICE_NAMESPACE" required:"true"`
+
+	// when set, resource updates are buffered on disk under this directory, while grpc server is unreachable
+	OutboxDir        string `env:"OUTBOX_DIR"`
+	OutboxMaxEntries int    `env:"OUTBOX_MAX_ENTRIES" default:"5000"`
+
+	KafkaBrokers                string `env:"KAFKA_BROKERS"`
+	KafkaSASLMechanism          string `env:"KAFKA_SASL_MECHANISM" default:"SCRAM-SHA-256"`
+	KafkaSASLUsername           string `env:"KAFKA_SASL_USERNAME"`
+	KafkaSASLPassword           string `env:"KAFKA_SASL_PASSWORD"`
+	KafkaConsoleTopic           string `env:"KAFKA_CONSOLE_TOPIC"`
+	KafkaInfraTopic             string `env:"KAFKA_INFRA_TOPIC"`
+	KafkaContainerRegistryTopic string `env:"KAFKA_CONTAINER_REGISTRY_TOPIC"`
+	// KafkaKindTopics routes specific resource kinds to their own topics, format: `App=topic-a,Router=topic-b`
+	KafkaKindTopics string `env:"KAFKA_KIND_TOPICS"`
+}
+
+func (ev *Env) ParseKafkaKindTopics() (map[string]string, error) {
+	m := map[string]string{}
+	if ev.KafkaKindTopics == "" {
+		return m, nil
+	}
+
+	for _, item := range strings.Split(ev.KafkaKindTopics, ",") {
+		sp := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(sp) != 2 || sp[0] == "" || sp[1] == "" {
+			return nil, fmt.Errorf("invalid KAFKA_KIND_TOPICS entry (%s), must be of format <Kind>=<topic>", item)
+		}
+		m[sp[0]] = sp[1]
+	}
+	return m, nil
+}
+
+func (ev *Env) validate() error {
+	switch ev.MessageSender {
+	case MessageSenderGrpc:
+		if ev.GrpcAddr == "" || ev.AccessToken == "" {
+			return fmt.Errorf("GRPC_ADDR and ACCESS_TOKEN are required, when MESSAGE_SENDER is %q", MessageSenderGrpc)
+		}
+	case MessageSenderKafka:
+		if ev.KafkaBrokers == "" || ev.KafkaConsoleTopic == "" || ev.KafkaInfraTopic == "" || ev.KafkaContainerRegistryTopic == "" {
+			return fmt.Errorf("KAFKA_BROKERS, KAFKA_CONSOLE_TOPIC, KAFKA_INFRA_TOPIC and KAFKA_CONTAINER_REGISTRY_TOPIC are required, when MESSAGE_SENDER is %q", MessageSenderKafka)
+		}
+	default:
+		return fmt.Errorf("unknown MESSAGE_SENDER (%s), must be one of [%s, %s]", ev.MessageSender, MessageSenderGrpc, MessageSenderKafka)
+	}
+	return nil
 }
 
 func GetEnv() (*Env, error) {
@@ -25,5 +83,8 @@ func GetEnv() (*Env, error) {
 	if err := env.Set(&ev); err != nil {
 		return nil, err
 	}
+	if err := ev.validate(); err != nil {
+		return nil, err
+	}
 	return &ev, nil
 }
@@ -15,4 +15,5 @@ func RegisterInto(mgr operator.Operator) {
 	mgr.RegisterControllers(
 		&router_controller.Reconciler{Name: "router", Env: ev},
 	)
+	mgr.RegisterWebhooks(&crdsv1.Router{})
 }
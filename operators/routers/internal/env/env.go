@@ -14,6 +14,28 @@ type Env struct {
 	DefaultClusterIssuer string `env:"DEFAULT_CLUSTER_ISSUER" required:"true"`
 
 	CertificateNamespace string `env:"CERTIFICATE_NAMESPACE" required:"true"`
+
+	// IngressControllerNamespace is where the default ingress class's controller reads its tcp/udp services configmaps from.
+	// Environment's ingress controllers read them from the environment's namespace
+	IngressControllerNamespace string `env:"INGRESS_CONTROLLER_NAMESPACE"`
+
+	Oauth2ProxyImage string `env:"OAUTH2_PROXY_IMAGE"`
+
+	// DNSProvider publishes records, for domains of routers under DNSZone, when set. It is one of route53, or rfc2136
+	DNSProvider string `env:"DNS_PROVIDER"`
+	DNSZone     string `env:"DNS_ZONE"`
+	// DNSOwnerID identifies this cluster in ownership records, so that clusters sharing a zone never override each other's records
+	DNSOwnerID   string `env:"DNS_OWNER_ID"`
+	DNSRecordTTL int64  `env:"DNS_RECORD_TTL"`
+
+	Route53AccessKey string `env:"ROUTE53_ACCESS_KEY"`
+	Route53SecretKey string `env:"ROUTE53_SECRET_KEY"`
+
+	// RFC2136Server is host:port of the zone's primary name server, accepting dynamic updates
+	RFC2136Server        string `env:"RFC2136_SERVER"`
+	RFC2136TSIGKeyName   string `env:"RFC2136_TSIG_KEY_NAME"`
+	RFC2136TSIGSecret    string `env:"RFC2136_TSIG_SECRET"`
+	RFC2136TSIGAlgorithm string `env:"RFC2136_TSIG_ALGORITHM"`
 }
 
 func GetEnvOrDie() *Env {
@@ -24,5 +46,11 @@ func GetEnvOrDie() *Env {
 	if ev.MaxConcurrentReconciles == 0 {
 		ev.MaxConcurrentReconciles = 5
 	}
+	if ev.Oauth2ProxyImage == "" {
+		ev.Oauth2ProxyImage = "quay.io/oauth2-proxy/oauth2-proxy:v7.5.1"
+	}
+	if ev.DNSRecordTTL == 0 {
+		ev.DNSRecordTTL = 300
+	}
 	return &ev
 }
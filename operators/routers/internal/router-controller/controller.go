@@ -21,6 +21,7 @@ import (
 	"github.com/kloudlite/operator/operators/routers/internal/env"
 	"github.com/kloudlite/operator/operators/routers/internal/templates"
 	"github.com/kloudlite/operator/pkg/constants"
+	"github.com/kloudlite/operator/pkg/dns"
 	fn "github.com/kloudlite/operator/pkg/functions"
 	"github.com/kloudlite/operator/pkg/kubectl"
 	"github.com/kloudlite/operator/pkg/logging"
@@ -36,7 +37,10 @@ type Reconciler struct {
 	Env        *env.Env
 	yamlClient kubectl.YAMLClient
 
-	templateIngress []byte
+	dnsProvider dns.Provider
+
+	templateIngress     []byte
+	templateOauth2Proxy []byte
 }
 
 func (r *Reconciler) GetName() string {
@@ -53,7 +57,12 @@ const (
 
 	EnsuringHttpsCertsIfEnabled string = "ensuring-https-certs-if-enabled"
 	SettingUpBasicAuthIfEnabled string = "setting-up-basic-auth-if-enabled"
-	CreatingIngressResources    string = "creating-ingress-resources"
+	SettingUpMTLSIfEnabled      string = "setting-up-mtls-if-enabled"
+
+	SettingUpExternalAuthIfEnabled string = "setting-up-external-auth-if-enabled"
+	CreatingIngressResources       string = "creating-ingress-resources"
+	ExposingPortsIfAny             string = "exposing-ports-if-any"
+	PublishingDNSRecordsIfEnabled  string = "publishing-dns-records-if-enabled"
 
 	CleaningUpResources string = "cleaning-up-resourcess"
 
@@ -65,6 +74,10 @@ var (
 		{Name: DefaultsPatched, Title: "Defaults Patched"},
 		{Name: EnsuringHttpsCertsIfEnabled, Title: "Ensuring HTTPS Cert if enabled"},
 		{Name: SettingUpBasicAuthIfEnabled, Title: "Setting Up Basic Auth if enabled"},
+		{Name: SettingUpExternalAuthIfEnabled, Title: "Setting Up External Auth if enabled"},
+		{Name: SettingUpMTLSIfEnabled, Title: "Setting Up mTLS if enabled"},
+		{Name: ExposingPortsIfAny, Title: "Exposing TCP/UDP Ports if any"},
+		{Name: PublishingDNSRecordsIfEnabled, Title: "Publishing DNS Records if enabled"},
 	}
 
 	DeleteChecklist = []rApi.CheckMeta{
@@ -77,7 +90,7 @@ var (
 // +kubebuilder:rbac:groups=crds.kloudlite.io,resources=crds/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &crdsv1.Router{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &crdsv1.Router{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -124,10 +137,26 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.reconExternalAuth(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.reconMTLS(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	if step := r.ensureIngresses(req); !step.ShouldProceed() {
 		return step.ReconcilerResponse()
 	}
 
+	if step := r.ensureExposedPorts(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.reconDNSRecords(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
 	req.Object.Status.IsReady = true
 	return ctrl.Result{}, nil
 }
@@ -136,14 +165,7 @@ func (r *Reconciler) patchDefaults(req *rApi.Request[*crdsv1.Router]) stepResult
 	ctx, obj := req.Context(), req.Object
 	check := rApi.NewRunningCheck(DefaultsPatched, req)
 
-	hasUpdated := false
-
-	if obj.Spec.BasicAuth != nil && obj.Spec.BasicAuth.Enabled && obj.Spec.BasicAuth.SecretName == "" {
-		hasUpdated = true
-		obj.Spec.BasicAuth.SecretName = obj.Name + "-basic-auth"
-	}
-
-	if hasUpdated {
+	if crdsv1.ApplyDefaults(obj) {
 		if err := r.Update(ctx, obj); err != nil {
 			return check.Failed(err)
 		}
@@ -159,6 +181,20 @@ func (r *Reconciler) finalize(req *rApi.Request[*crdsv1.Router]) stepResult.Resu
 	req.LogPreCheck(checkName)
 	defer req.LogPostCheck(checkName)
 
+	// tcp/udp services configmaps are shared among routers, so only ports of this router are released
+	if _, err := r.syncServicesConfigMaps(req.Context(), req.Object); err != nil {
+		return req.Done().Err(err)
+	}
+
+	// oauth2-proxy is shared among routers too, and is removed along with the last router using it
+	if _, err := r.syncOauth2Proxies(req.Context(), req.Object.Namespace); err != nil {
+		return req.Done().Err(err)
+	}
+
+	if err := r.finalizeDNSRecords(req.Context(), req.Object); err != nil {
+		return req.Done().Err(err)
+	}
+
 	if step := req.CleanupOwnedResources(); !step.ShouldProceed() {
 		return step
 	}
@@ -255,7 +291,7 @@ func (r *Reconciler) EnsuringHttpsCerts(req *rApi.Request[*crdsv1.Router]) stepR
 			if copyTLSSecret.Annotations == nil {
 				copyTLSSecret.Annotations = make(map[string]string, 1)
 			}
-			copyTLSSecret.Annotations["kloudlite.io/secret.cloned-by"] = "router"
+			copyTLSSecret.Annotations[constants.SecretClonedByKey] = "router"
 
 			copyTLSSecret.Data = certSecret.Data
 			copyTLSSecret.StringData = certSecret.StringData
@@ -314,28 +350,21 @@ func (r *Reconciler) ensureIngresses(req *rApi.Request[*crdsv1.Router]) stepResu
 		return check.Failed(err).Err(nil)
 	}
 
-	nginxIngressAnnotations := GenNginxIngressAnnotations(obj)
-
-	if len(obj.Spec.Routes) > 0 {
-		b, err := templates.ParseBytes(
+	renderIngress := func(name string, labels map[string]string, annotations map[string]string, routes []crdsv1.Route) ([]byte, error) {
+		return templates.ParseBytes(
 			r.templateIngress, map[string]any{
-				"name":      obj.Name,
+				"name":      name,
 				"namespace": obj.Namespace,
 
 				"owner-refs":  []metav1.OwnerReference{fn.AsOwner(obj, true)},
-				"labels":      obj.GetLabels(),
-				"annotations": nginxIngressAnnotations,
+				"labels":      labels,
+				"annotations": annotations,
 
 				"non-wildcard-domains": nonWcDomains,
 				"wildcard-domains":     wcDomains,
 				"router-domains":       obj.Spec.Domains,
 
-				"ingress-class": func() string {
-					if obj.Spec.IngressClass != "" {
-						return obj.Spec.IngressClass
-					}
-					return r.Env.DefaultIngressClass
-				}(),
+				"ingress-class": r.ingressClass(obj),
 				"cluster-issuer": func() string {
 					if obj.Spec.Https != nil && obj.Spec.Https.ClusterIssuer != "" {
 						return obj.Spec.Https.ClusterIssuer
@@ -343,11 +372,19 @@ func (r *Reconciler) ensureIngresses(req *rApi.Request[*crdsv1.Router]) stepResu
 					return r.Env.DefaultClusterIssuer
 				}(),
 
-				"routes": obj.Spec.Routes,
+				"routes": routes,
 
 				"is-https-enabled": isHttpsEnabled(obj),
 			},
 		)
+	}
+
+	// routes with settings of their own are split into ingresses of their own, as ingress-nginx applies annotations per ingress
+	expected := map[string]struct{}{}
+	for _, group := range groupRoutes(obj) {
+		labels := fn.MapMerge(obj.GetLabels(), map[string]string{constants.RouteIngressOfKey: obj.Name})
+
+		b, err := renderIngress(group.name, labels, group.annotations, group.routes)
 		if err != nil {
 			return check.Failed(err).Err(nil)
 		}
@@ -358,6 +395,52 @@ func (r *Reconciler) ensureIngresses(req *rApi.Request[*crdsv1.Router]) stepResu
 		}
 
 		req.AddToOwnedResources(rr...)
+		expected[group.name] = struct{}{}
+	}
+
+	if err := r.deleteStaleIngresses(ctx, obj, constants.RouteIngressOfKey, expected); err != nil {
+		return check.StillRunning(err)
+	}
+
+	if _, ok := expected[obj.Name]; !ok {
+		// ingress named after the router predates labelling of generated ingresses
+		ing, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Name), &networkingv1.Ingress{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return check.StillRunning(err)
+		}
+		if ing != nil && fn.IsOwner(ing, fn.AsOwner(obj, true)) {
+			if err := r.Delete(ctx, ing); err != nil && !apiErrors.IsNotFound(err) {
+				return check.StillRunning(err)
+			}
+		}
+	}
+
+	// route backends are served by ingress-nginx canary ingresses, one per route, mirroring its host and path
+	expectedBackends := map[string]struct{}{}
+	for i, route := range obj.Spec.Routes {
+		for _, backend := range route.Backends {
+			name := routeBackendIngressName(obj.Name, i)
+
+			labels := fn.MapMerge(obj.GetLabels(), map[string]string{labelRouteBackendOf: obj.Name})
+			annotations := fn.MapMerge(GenRouteNginxIngressAnnotations(obj, route), GenCanaryAnnotations(backend))
+
+			b, err := renderIngress(name, labels, annotations, []crdsv1.Route{{App: backend.App, Port: backend.Port, Path: route.Path, Rewrite: route.Rewrite}})
+			if err != nil {
+				return check.Failed(err).Err(nil)
+			}
+
+			rr, err := r.yamlClient.ApplyYAML(ctx, b)
+			if err != nil {
+				return check.StillRunning(err)
+			}
+
+			req.AddToOwnedResources(rr...)
+			expectedBackends[name] = struct{}{}
+		}
+	}
+
+	if err := r.deleteStaleIngresses(ctx, obj, labelRouteBackendOf, expectedBackends); err != nil {
+		return check.StillRunning(err)
 	}
 
 	return check.Completed()
@@ -375,6 +458,16 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) e
 		return err
 	}
 
+	r.templateOauth2Proxy, err = templates.ReadOauth2ProxyTemplate()
+	if err != nil {
+		return err
+	}
+
+	r.dnsProvider, err = newDNSProvider(r.Env)
+	if err != nil {
+		return err
+	}
+
 	builder := ctrl.NewControllerManagedBy(mgr).For(&crdsv1.Router{})
 	builder.Owns(&networkingv1.Ingress{})
 	// builder.Owns(&certmanagerv1.Certificate{})
@@ -0,0 +1,246 @@
+package router_controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/operators/routers/internal/env"
+	"github.com/kloudlite/operator/pkg/constants"
+	"github.com/kloudlite/operator/pkg/dns"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newDNSProvider(ev *env.Env) (dns.Provider, error) {
+	if ev.DNSProvider == "" {
+		return nil, nil
+	}
+	if ev.DNSZone == "" || ev.DNSOwnerID == "" {
+		return nil, fmt.Errorf("env vars DNS_ZONE, and DNS_OWNER_ID are required, along with DNS_PROVIDER")
+	}
+
+	switch ev.DNSProvider {
+	case "route53":
+		return dns.NewRoute53Provider(ev.Route53AccessKey, ev.Route53SecretKey, ev.DNSZone)
+	case "rfc2136":
+		return dns.NewRFC2136Provider(dns.RFC2136Config{
+			Server:        ev.RFC2136Server,
+			Zone:          ev.DNSZone,
+			TSIGKeyName:   ev.RFC2136TSIGKeyName,
+			TSIGSecret:    ev.RFC2136TSIGSecret,
+			TSIGAlgorithm: ev.RFC2136TSIGAlgorithm,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported dns provider %q, must be one of route53, or rfc2136", ev.DNSProvider)
+	}
+}
+
+// dnsOwner claims domains, per ingress class of this cluster, as routers sharing a domain on an ingress class point it to the same load balancer
+func (r *Reconciler) dnsOwner(obj *crdsv1.Router) string {
+	return fmt.Sprintf("%s/%s", r.Env.DNSOwnerID, r.ingressClass(obj))
+}
+
+// dnsDomains are domains of the router, that lie in the managed zone
+func (r *Reconciler) dnsDomains(obj *crdsv1.Router) []string {
+	var domains []string
+	for _, d := range obj.Spec.Domains {
+		if dns.InZone(r.dnsProvider.Zone(), d) {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+func publishedDomains(obj *crdsv1.Router) []string {
+	v := obj.GetAnnotations()[constants.RouterDNSRecordsKey]
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// loadBalancerAddresses are addresses, that the ingress controller publishes on ingresses of the router
+func (r *Reconciler) loadBalancerAddresses(ctx context.Context, obj *crdsv1.Router) ([]string, error) {
+	var ingList networkingv1.IngressList
+	if err := r.List(ctx, &ingList, client.InNamespace(obj.Namespace), client.MatchingLabels{constants.RouteIngressOfKey: obj.Name}); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]struct{}{}
+	var addrs []string
+	for i := range ingList.Items {
+		for _, lb := range ingList.Items[i].Status.LoadBalancer.Ingress {
+			addr := lb.IP
+			if addr == "" {
+				addr = lb.Hostname
+			}
+			if _, ok := seen[addr]; ok || addr == "" {
+				continue
+			}
+			seen[addr] = struct{}{}
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+// isDomainShared tells whether another router, on the same ingress class, still serves domain, in which case its records must stay
+func (r *Reconciler) isDomainShared(ctx context.Context, obj *crdsv1.Router, domain string) (bool, error) {
+	var routers crdsv1.RouterList
+	if err := r.List(ctx, &routers); err != nil {
+		return false, err
+	}
+
+	for i := range routers.Items {
+		router := &routers.Items[i]
+		if (router.Namespace == obj.Namespace && router.Name == obj.Name) || router.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if r.ingressClass(router) != r.ingressClass(obj) {
+			continue
+		}
+		for _, d := range router.Spec.Domains {
+			if d == domain {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// releaseDNSRecords removes records of published domains, except the ones in keep, and returns the domains, that are still published
+func (r *Reconciler) releaseDNSRecords(ctx context.Context, obj *crdsv1.Router, keep map[string]struct{}) ([]string, error) {
+	var remaining []string
+	for _, domain := range publishedDomains(obj) {
+		if _, ok := keep[domain]; ok {
+			remaining = append(remaining, domain)
+			continue
+		}
+
+		shared, err := r.isDomainShared(ctx, obj, domain)
+		if err != nil {
+			return nil, err
+		}
+		if !shared {
+			if err := dns.Release(ctx, r.dnsProvider, domain, r.dnsOwner(obj)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// setPublishedDomains records published domains on the router
+func (r *Reconciler) setPublishedDomains(ctx context.Context, obj *crdsv1.Router, domains []string) error {
+	sort.Strings(domains)
+	v := strings.Join(domains, ",")
+	if obj.GetAnnotations()[constants.RouterDNSRecordsKey] == v {
+		return nil
+	}
+
+	ann := obj.GetAnnotations()
+	if ann == nil {
+		ann = make(map[string]string, 1)
+	}
+	if v == "" {
+		delete(ann, constants.RouterDNSRecordsKey)
+	} else {
+		ann[constants.RouterDNSRecordsKey] = v
+	}
+	obj.SetAnnotations(ann)
+
+	return rApi.UpdatePreservingStatus(ctx, r.Client, obj)
+}
+
+// reconDNSRecords points domains of the router, in the managed zone, to the load balancer of its ingress controller, and removes records
+// of domains, that the router no longer has
+func (r *Reconciler) reconDNSRecords(req *rApi.Request[*crdsv1.Router]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(PublishingDNSRecordsIfEnabled, req)
+
+	if r.dnsProvider == nil {
+		return check.Completed()
+	}
+
+	domains := r.dnsDomains(obj)
+	if len(obj.Spec.Routes) == 0 {
+		// without routes, there is no ingress, whose address domains could point to
+		domains = nil
+	}
+
+	keep := make(map[string]struct{}, len(domains))
+	for _, d := range domains {
+		keep[d] = struct{}{}
+	}
+
+	published, err := r.releaseDNSRecords(ctx, obj, keep)
+	if err != nil {
+		return check.StillRunning(err)
+	}
+	if err := r.setPublishedDomains(ctx, obj, published); err != nil {
+		return check.StillRunning(err)
+	}
+
+	if len(domains) == 0 {
+		return check.Completed()
+	}
+
+	targets, err := r.loadBalancerAddresses(ctx, obj)
+	if err != nil {
+		return check.StillRunning(err)
+	}
+	if len(targets) == 0 {
+		return check.StillRunning(fmt.Errorf("waiting for ingress controller to publish its load balancer address")).Err(nil).RequeueAfter(10 * time.Second)
+	}
+
+	isPublished := make(map[string]struct{}, len(published))
+	for _, d := range published {
+		isPublished[d] = struct{}{}
+	}
+
+	var conflicts []string
+	for _, domain := range domains {
+		if err := dns.Publish(ctx, r.dnsProvider, domain, targets, r.dnsOwner(obj), r.Env.DNSRecordTTL); err != nil {
+			var conflict *dns.ConflictError
+			if !errors.As(err, &conflict) {
+				return check.StillRunning(err)
+			}
+			conflicts = append(conflicts, conflict.Error())
+			continue
+		}
+		if _, ok := isPublished[domain]; !ok {
+			published = append(published, domain)
+		}
+	}
+
+	if err := r.setPublishedDomains(ctx, obj, published); err != nil {
+		return check.StillRunning(err)
+	}
+
+	if len(conflicts) > 0 {
+		// conflicting records might get released later on
+		return check.Failed(fmt.Errorf("dns records could not be published: %s", strings.Join(conflicts, "; "))).Err(nil).RequeueAfter(1 * time.Minute)
+	}
+
+	return check.Completed()
+}
+
+// finalizeDNSRecords releases all records, that the router has published
+func (r *Reconciler) finalizeDNSRecords(ctx context.Context, obj *crdsv1.Router) error {
+	if r.dnsProvider == nil {
+		return nil
+	}
+
+	if _, err := r.releaseDNSRecords(ctx, obj, nil); err != nil {
+		return err
+	}
+	return r.setPublishedDomains(ctx, obj, nil)
+}
@@ -0,0 +1,218 @@
+package router_controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/operators/routers/internal/templates"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	oauth2ProxyPort     = 4180
+	labelOauth2ProxyFor = "kloudlite.io/oauth2-proxy-for"
+)
+
+// keys, that oauth2-proxy reads its settings from, in the secret referenced by routers
+var oauth2ProxySecretKeys = []string{"issuer-url", "client-id", "client-secret", "cookie-secret"}
+
+func oauth2ProxyName(secretName string) string {
+	return fmt.Sprintf("%s-oauth2-proxy", secretName)
+}
+
+func oauth2IngressName(routerName string) string {
+	return fmt.Sprintf("%s-oauth2", routerName)
+}
+
+// oauth2ProxyAuthURL is where ingress-nginx verifies requests to an oidc protected router, along with groups and email domains, that router allows
+func oauth2ProxyAuthURL(obj *crdsv1.Router) string {
+	oidc := obj.Spec.Auth.OIDC
+
+	q := url.Values{}
+	if len(oidc.AllowedGroups) > 0 {
+		q.Set("allowed_groups", strings.Join(oidc.AllowedGroups, ","))
+	}
+	if len(oidc.AllowedEmailDomains) > 0 {
+		q.Set("allowed_email_domains", strings.Join(oidc.AllowedEmailDomains, ","))
+	}
+
+	u := url.URL{
+		Scheme:   "http",
+		Host:     fmt.Sprintf("%s.%s.svc.cluster.local:%d", oauth2ProxyName(oidc.SecretName), obj.Namespace, oauth2ProxyPort),
+		Path:     "/oauth2/auth",
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+// syncOauth2Proxies runs an oauth2-proxy for every secret, that oidc protected routers of the namespace refer to, and removes the ones,
+// no router refers to anymore. Routers sharing a proxy, all own it. It returns errors, per secret, that a proxy could not be run for
+func (r *Reconciler) syncOauth2Proxies(ctx context.Context, namespace string) (map[string]error, error) {
+	var routers crdsv1.RouterList
+	if err := r.List(ctx, &routers, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	owners := map[string][]metav1.OwnerReference{}
+	for i := range routers.Items {
+		router := &routers.Items[i]
+		if router.GetDeletionTimestamp() != nil || !router.IsOIDCEnabled() {
+			continue
+		}
+		secretName := router.Spec.Auth.OIDC.SecretName
+		owners[secretName] = append(owners[secretName], fn.AsOwner(router, false))
+	}
+
+	secretErrs := map[string]error{}
+	for secretName, ownerRefs := range owners {
+		sort.Slice(ownerRefs, func(i, j int) bool { return ownerRefs[i].Name < ownerRefs[j].Name })
+		if err := r.applyOauth2Proxy(ctx, namespace, secretName, ownerRefs); err != nil {
+			secretErrs[secretName] = err
+		}
+	}
+
+	var deployments appsv1.DeploymentList
+	if err := r.List(ctx, &deployments, client.InNamespace(namespace), client.HasLabels{labelOauth2ProxyFor}); err != nil {
+		return nil, err
+	}
+
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if _, ok := owners[d.GetLabels()[labelOauth2ProxyFor]]; ok {
+			continue
+		}
+		if err := r.Delete(ctx, d); err != nil && !apiErrors.IsNotFound(err) {
+			return nil, err
+		}
+		if err := r.Delete(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: d.Name, Namespace: d.Namespace}}); err != nil && !apiErrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return secretErrs, nil
+}
+
+func (r *Reconciler) applyOauth2Proxy(ctx context.Context, namespace string, secretName string, ownerRefs []metav1.OwnerReference) error {
+	secret, err := rApi.Get(ctx, r.Client, fn.NN(namespace, secretName), &corev1.Secret{})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range oauth2ProxySecretKeys {
+		if len(secret.Data[k]) == 0 {
+			return fmt.Errorf("secret %s has no %s, required by oauth2-proxy", secretName, k)
+		}
+	}
+
+	b, err := templates.ParseBytes(r.templateOauth2Proxy, map[string]any{
+		"name":       oauth2ProxyName(secretName),
+		"namespace":  namespace,
+		"owner-refs": ownerRefs,
+		"labels":     map[string]string{labelOauth2ProxyFor: secretName},
+
+		"image":       r.Env.Oauth2ProxyImage,
+		"secret-name": secretName,
+		// restarts oauth2-proxy, when its settings change
+		"secret-checksum": fn.Sha1Sum([]byte(fmt.Sprintf("%v", secret.Data))),
+		"port":            oauth2ProxyPort,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.yamlClient.ApplyYAML(ctx, b)
+	return err
+}
+
+// ensureOauth2Ingress serves oauth2-proxy's sign in, and callback endpoints on every domain of the router
+func (r *Reconciler) ensureOauth2Ingress(req *rApi.Request[*crdsv1.Router]) error {
+	ctx, obj := req.Context(), req.Object
+
+	pathType := networkingv1.PathTypePrefix
+	var rules []networkingv1.IngressRule
+	for _, domain := range obj.Spec.Domains {
+		rules = append(rules, networkingv1.IngressRule{
+			Host: domain,
+			IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{{
+					Path:     "/oauth2",
+					PathType: &pathType,
+					Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+						Name: oauth2ProxyName(obj.Spec.Auth.OIDC.SecretName),
+						Port: networkingv1.ServiceBackendPort{Number: oauth2ProxyPort},
+					}},
+				}},
+			}},
+		})
+	}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            oauth2IngressName(obj.Name),
+			Namespace:       obj.Namespace,
+			Labels:          obj.GetLabels(),
+			OwnerReferences: []metav1.OwnerReference{fn.AsOwner(obj, true)},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: fn.New(r.ingressClass(obj)),
+			Rules:            rules,
+		},
+	}
+
+	rr, err := r.yamlClient.Apply(ctx, ing)
+	if err != nil {
+		return err
+	}
+
+	req.AddToOwnedResources(rr...)
+	return nil
+}
+
+func (r *Reconciler) reconExternalAuth(req *rApi.Request[*crdsv1.Router]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(SettingUpExternalAuthIfEnabled, req)
+
+	secretErrs, err := r.syncOauth2Proxies(ctx, obj.Namespace)
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	if !obj.IsOIDCEnabled() {
+		if err := r.Delete(ctx, &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: oauth2IngressName(obj.Name), Namespace: obj.Namespace}}); err != nil && !apiErrors.IsNotFound(err) {
+			return check.StillRunning(err)
+		}
+		return check.Completed()
+	}
+
+	if err := secretErrs[obj.Spec.Auth.OIDC.SecretName]; err != nil {
+		return check.Failed(err)
+	}
+
+	if err := r.ensureOauth2Ingress(req); err != nil {
+		return check.StillRunning(err)
+	}
+
+	proxy, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, oauth2ProxyName(obj.Spec.Auth.OIDC.SecretName)), &appsv1.Deployment{})
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	if proxy.Status.ReadyReplicas == 0 {
+		return check.StillRunning(fmt.Errorf("waiting for oauth2-proxy to be ready"))
+	}
+
+	return check.Completed()
+}
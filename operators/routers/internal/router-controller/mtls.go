@@ -0,0 +1,83 @@
+package router_controller
+
+import (
+	"fmt"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// key, that ingress-nginx reads CA certificate from
+const caCertKey = "ca.crt"
+
+func isMTLSEnabled(obj *crdsv1.Router) bool {
+	return obj.Spec.MTLS != nil && obj.Spec.MTLS.Enabled
+}
+
+// mtlsCASecretName is the secret, that ingress verifies client certificates with.
+// CA certificate of an issuer is copied into a secret of its own, as issuer's secret holds it under tls.crt
+func mtlsCASecretName(obj *crdsv1.Router) string {
+	if obj.Spec.MTLS.CASecretName != "" {
+		return obj.Spec.MTLS.CASecretName
+	}
+	return fmt.Sprintf("%s-mtls-ca", obj.Name)
+}
+
+func (r *Reconciler) reconMTLS(req *rApi.Request[*crdsv1.Router]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(SettingUpMTLSIfEnabled, req)
+
+	if !isMTLSEnabled(obj) {
+		return check.Completed()
+	}
+
+	if obj.Spec.MTLS.CASecretName != "" {
+		caSecret, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Spec.MTLS.CASecretName), &corev1.Secret{})
+		if err != nil {
+			return check.StillRunning(err)
+		}
+		if len(caSecret.Data[caCertKey]) == 0 {
+			return check.Failed(fmt.Errorf("secret %s has no CA certificate, under key %s", caSecret.Name, caCertKey)).Err(nil)
+		}
+		return check.Completed()
+	}
+
+	issuer, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, obj.Spec.MTLS.Issuer), &certmanagerv1.Issuer{})
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	if issuer.Spec.CA == nil {
+		return check.Failed(fmt.Errorf("issuer %s is not a CA issuer", issuer.Name)).Err(nil)
+	}
+
+	issuerSecret, err := rApi.Get(ctx, r.Client, fn.NN(obj.Namespace, issuer.Spec.CA.SecretName), &corev1.Secret{})
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	caCert := issuerSecret.Data[corev1.TLSCertKey]
+	if len(caCert) == 0 {
+		return check.Failed(fmt.Errorf("CA secret %s, of issuer %s, has no certificate", issuerSecret.Name, issuer.Name)).Err(nil)
+	}
+
+	caSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: mtlsCASecretName(obj), Namespace: obj.Namespace}, Type: corev1.SecretTypeOpaque}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, caSecret, func() error {
+		caSecret.SetOwnerReferences([]metav1.OwnerReference{fn.AsOwner(obj, true)})
+		// only the certificate is copied, as ingress has no use of CA's private key
+		caSecret.Data = map[string][]byte{caCertKey: caCert}
+		return nil
+	}); err != nil {
+		return check.StillRunning(err)
+	}
+
+	req.AddToOwnedResources(rApi.ParseResourceRef(caSecret))
+
+	return check.Completed()
+}
@@ -46,7 +46,22 @@ func GenNginxIngressAnnotations(obj *crdsv1.Router) map[string]string {
 	}
 
 	if obj.Spec.BackendProtocol != nil {
-		annotations["nginx.ingress.kubernetes.io/backend-protocol"] = *obj.Spec.BackendProtocol
+		switch bp := strings.ToUpper(*obj.Spec.BackendProtocol); bp {
+		case crdsv1.BackendProtocolGRPCWeb:
+			// gRPC-web travels over plain HTTP, but browsers need its headers allowed, and exposed through CORS
+			annotations["nginx.ingress.kubernetes.io/backend-protocol"] = crdsv1.BackendProtocolHTTP
+			annotations["nginx.ingress.kubernetes.io/enable-cors"] = "true"
+			annotations["nginx.ingress.kubernetes.io/cors-allow-methods"] = "GET, PUT, POST, DELETE, PATCH, OPTIONS"
+			annotations["nginx.ingress.kubernetes.io/cors-allow-headers"] = "DNT,User-Agent,X-Requested-With,If-Modified-Since,Cache-Control,Content-Type,Range,Authorization,X-User-Agent,X-Grpc-Web,Grpc-Timeout"
+			annotations["nginx.ingress.kubernetes.io/cors-expose-headers"] = "Grpc-Status,Grpc-Message,Grpc-Status-Details-Bin"
+		case crdsv1.BackendProtocolGRPC, crdsv1.BackendProtocolGRPCS:
+			annotations["nginx.ingress.kubernetes.io/backend-protocol"] = bp
+			// streaming calls outlive nginx's default 60s read/send timeouts
+			annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] = "3600"
+			annotations["nginx.ingress.kubernetes.io/proxy-send-timeout"] = "3600"
+		default:
+			annotations["nginx.ingress.kubernetes.io/backend-protocol"] = bp
+		}
 	}
 
 	if obj.Spec.BasicAuth != nil && obj.Spec.BasicAuth.Enabled {
@@ -55,5 +70,115 @@ func GenNginxIngressAnnotations(obj *crdsv1.Router) map[string]string {
 		annotations["nginx.ingress.kubernetes.io/auth-realm"] = "route is protected by basic auth"
 	}
 
+	if obj.IsOIDCEnabled() {
+		annotations["nginx.ingress.kubernetes.io/auth-url"] = oauth2ProxyAuthURL(obj)
+		annotations["nginx.ingress.kubernetes.io/auth-signin"] = "https://$host/oauth2/start?rd=$escaped_request_uri"
+		annotations["nginx.ingress.kubernetes.io/auth-response-headers"] = "X-Auth-Request-User,X-Auth-Request-Email,X-Auth-Request-Groups"
+	}
+
+	if obj.IsForwardAuthEnabled() {
+		fa := obj.Spec.Auth.ForwardAuth
+		annotations["nginx.ingress.kubernetes.io/auth-url"] = fa.URL
+		if fa.SignInURL != "" {
+			annotations["nginx.ingress.kubernetes.io/auth-signin"] = fa.SignInURL
+		}
+		if len(fa.ResponseHeaders) > 0 {
+			annotations["nginx.ingress.kubernetes.io/auth-response-headers"] = strings.Join(fa.ResponseHeaders, ",")
+		}
+	}
+
+	if isMTLSEnabled(obj) {
+		annotations["nginx.ingress.kubernetes.io/auth-tls-secret"] = fmt.Sprintf("%s/%s", obj.Namespace, mtlsCASecretName(obj))
+		annotations["nginx.ingress.kubernetes.io/auth-tls-verify-client"] = "on"
+		if obj.Spec.MTLS.Optional {
+			annotations["nginx.ingress.kubernetes.io/auth-tls-verify-client"] = "optional"
+		}
+		annotations["nginx.ingress.kubernetes.io/auth-tls-verify-depth"] = fmt.Sprintf("%d", obj.Spec.MTLS.VerifyDepth)
+		annotations["nginx.ingress.kubernetes.io/auth-tls-pass-certificate-to-upstream"] = fmt.Sprintf("%v", obj.Spec.MTLS.ForwardClientCert)
+	}
+
+	return annotations
+}
+
+// GenRouteNginxIngressAnnotations are router's annotations, with settings of the route overriding them
+func GenRouteNginxIngressAnnotations(obj *crdsv1.Router, route crdsv1.Route) map[string]string {
+	annotations := GenNginxIngressAnnotations(obj)
+
+	if route.MaxBodySizeInMB != nil {
+		annotations["nginx.ingress.kubernetes.io/proxy-body-size"] = fmt.Sprintf("%vm", *route.MaxBodySizeInMB)
+	}
+
+	if rl := route.RateLimit; rl != nil {
+		delete(annotations, "nginx.ingress.kubernetes.io/limit-rps")
+		delete(annotations, "nginx.ingress.kubernetes.io/limit-rpm")
+		delete(annotations, "nginx.ingress.kubernetes.io/limit-connections")
+
+		if rl.Enabled {
+			if rl.Rps > 0 {
+				annotations["nginx.ingress.kubernetes.io/limit-rps"] = fmt.Sprintf("%v", rl.Rps)
+			}
+			if rl.Rpm > 0 {
+				annotations["nginx.ingress.kubernetes.io/limit-rpm"] = fmt.Sprintf("%v", rl.Rpm)
+			}
+			if rl.Connections > 0 {
+				annotations["nginx.ingress.kubernetes.io/limit-connections"] = fmt.Sprintf("%v", rl.Connections)
+			}
+		}
+	}
+
+	if t := route.Timeouts; t != nil {
+		if t.Connect > 0 {
+			annotations["nginx.ingress.kubernetes.io/proxy-connect-timeout"] = fmt.Sprintf("%d", t.Connect)
+		}
+		if t.Read > 0 {
+			annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] = fmt.Sprintf("%d", t.Read)
+		}
+		if t.Send > 0 {
+			annotations["nginx.ingress.kubernetes.io/proxy-send-timeout"] = fmt.Sprintf("%d", t.Send)
+		}
+	}
+
+	if rt := route.Retry; rt != nil {
+		if len(rt.On) > 0 {
+			annotations["nginx.ingress.kubernetes.io/proxy-next-upstream"] = strings.Join(rt.On, " ")
+		}
+		annotations["nginx.ingress.kubernetes.io/proxy-next-upstream-tries"] = fmt.Sprintf("%d", rt.Attempts)
+		annotations["nginx.ingress.kubernetes.io/proxy-next-upstream-timeout"] = fmt.Sprintf("%d", rt.TimeoutInSeconds)
+	}
+
+	if len(route.IPAllowList) > 0 {
+		annotations["nginx.ingress.kubernetes.io/whitelist-source-range"] = strings.Join(route.IPAllowList, ",")
+	}
+
+	if len(route.IPDenyList) > 0 {
+		annotations["nginx.ingress.kubernetes.io/denylist-source-range"] = strings.Join(route.IPDenyList, ",")
+	}
+
+	return annotations
+}
+
+// GenCanaryAnnotations configures an ingress-nginx canary ingress, to send route backend's share of traffic to it.
+// ingress-nginx evaluates header first, then cookie, and falls back to weight for requests matching neither
+func GenCanaryAnnotations(backend crdsv1.RouteBackend) map[string]string {
+	annotations := map[string]string{
+		"nginx.ingress.kubernetes.io/canary":        "true",
+		"nginx.ingress.kubernetes.io/canary-weight": fmt.Sprintf("%d", backend.Weight),
+	}
+
+	if m := backend.Match; m != nil {
+		if m.Header != "" {
+			annotations["nginx.ingress.kubernetes.io/canary-by-header"] = m.Header
+			if m.HeaderValue != "" {
+				annotations["nginx.ingress.kubernetes.io/canary-by-header-value"] = m.HeaderValue
+			}
+			if m.HeaderRegex != "" {
+				annotations["nginx.ingress.kubernetes.io/canary-by-header-pattern"] = m.HeaderRegex
+			}
+		}
+		if m.Cookie != "" {
+			annotations["nginx.ingress.kubernetes.io/canary-by-cookie"] = m.Cookie
+		}
+	}
+
 	return annotations
 }
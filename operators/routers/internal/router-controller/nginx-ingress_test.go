@@ -0,0 +1,211 @@
+package router_controller
+
+import (
+	"reflect"
+	"testing"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+)
+
+func TestGenCanaryAnnotations(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend crdsv1.RouteBackend
+		want    map[string]string
+	}{
+		{
+			name:    "1. weight only",
+			backend: crdsv1.RouteBackend{App: "web-v2", Port: 80, Weight: 20},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":        "true",
+				"nginx.ingress.kubernetes.io/canary-weight": "20",
+			},
+		},
+		{
+			name:    "2. header equals",
+			backend: crdsv1.RouteBackend{App: "web-v2", Port: 80, Match: &crdsv1.RouteMatch{Header: "x-version", HeaderValue: "v2"}},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":                 "true",
+				"nginx.ingress.kubernetes.io/canary-weight":          "0",
+				"nginx.ingress.kubernetes.io/canary-by-header":       "x-version",
+				"nginx.ingress.kubernetes.io/canary-by-header-value": "v2",
+			},
+		},
+		{
+			name:    "3. header regex, cookie and weight",
+			backend: crdsv1.RouteBackend{App: "web-v2", Port: 80, Weight: 5, Match: &crdsv1.RouteMatch{Header: "x-version", HeaderRegex: "^v2", Cookie: "kloudlite-workspace"}},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":                   "true",
+				"nginx.ingress.kubernetes.io/canary-weight":            "5",
+				"nginx.ingress.kubernetes.io/canary-by-header":         "x-version",
+				"nginx.ingress.kubernetes.io/canary-by-header-pattern": "^v2",
+				"nginx.ingress.kubernetes.io/canary-by-cookie":         "kloudlite-workspace",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenCanaryAnnotations(tt.backend); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenCanaryAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenNginxIngressAnnotationsForGRPC(t *testing.T) {
+	tests := []struct {
+		name            string
+		backendProtocol string
+		want            map[string]string
+	}{
+		{
+			name:            "1. grpc over h2c",
+			backendProtocol: "grpc",
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/backend-protocol":   "GRPC",
+				"nginx.ingress.kubernetes.io/proxy-read-timeout": "3600",
+				"nginx.ingress.kubernetes.io/proxy-send-timeout": "3600",
+			},
+		},
+		{
+			name:            "2. grpc-web",
+			backendProtocol: crdsv1.BackendProtocolGRPCWeb,
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/backend-protocol":    "HTTP",
+				"nginx.ingress.kubernetes.io/enable-cors":         "true",
+				"nginx.ingress.kubernetes.io/cors-expose-headers": "Grpc-Status,Grpc-Message,Grpc-Status-Details-Bin",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp := tt.backendProtocol
+			got := GenNginxIngressAnnotations(&crdsv1.Router{Spec: crdsv1.RouterSpec{BackendProtocol: &bp}})
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("GenNginxIngressAnnotations()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestGenNginxIngressAnnotationsForMTLS(t *testing.T) {
+	tests := []struct {
+		name string
+		mtls crdsv1.MTLS
+		want map[string]string
+	}{
+		{
+			name: "1. CA secret",
+			mtls: crdsv1.MTLS{Enabled: true, CASecretName: "clients-ca", VerifyDepth: 1},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/auth-tls-secret":                       "env/clients-ca",
+				"nginx.ingress.kubernetes.io/auth-tls-verify-client":                "on",
+				"nginx.ingress.kubernetes.io/auth-tls-verify-depth":                 "1",
+				"nginx.ingress.kubernetes.io/auth-tls-pass-certificate-to-upstream": "false",
+			},
+		},
+		{
+			name: "2. optional, with issuer, forwarding client cert",
+			mtls: crdsv1.MTLS{Enabled: true, Issuer: "clients", VerifyDepth: 2, Optional: true, ForwardClientCert: true},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/auth-tls-secret":                       "env/sample-mtls-ca",
+				"nginx.ingress.kubernetes.io/auth-tls-verify-client":                "optional",
+				"nginx.ingress.kubernetes.io/auth-tls-verify-depth":                 "2",
+				"nginx.ingress.kubernetes.io/auth-tls-pass-certificate-to-upstream": "true",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mtls := tt.mtls
+			router := &crdsv1.Router{Spec: crdsv1.RouterSpec{MTLS: &mtls}}
+			router.SetName("sample")
+			router.SetNamespace("env")
+
+			got := GenNginxIngressAnnotations(router)
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("GenNginxIngressAnnotations()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestGenNginxIngressAnnotationsForExternalAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		auth crdsv1.RouterAuth
+		want map[string]string
+	}{
+		{
+			name: "1. oidc, with allowed groups",
+			auth: crdsv1.RouterAuth{OIDC: &crdsv1.OIDCAuth{Enabled: true, SecretName: "sso", AllowedGroups: []string{"admins", "devs"}}},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/auth-url":    "http://sso-oauth2-proxy.env.svc.cluster.local:4180/oauth2/auth?allowed_groups=admins%2Cdevs",
+				"nginx.ingress.kubernetes.io/auth-signin": "https://$host/oauth2/start?rd=$escaped_request_uri",
+			},
+		},
+		{
+			name: "2. forward auth",
+			auth: crdsv1.RouterAuth{ForwardAuth: &crdsv1.ForwardAuth{Enabled: true, URL: "http://auth.env.svc/verify", ResponseHeaders: []string{"X-User", "X-Org"}}},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/auth-url":              "http://auth.env.svc/verify",
+				"nginx.ingress.kubernetes.io/auth-response-headers": "X-User,X-Org",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := tt.auth
+			router := &crdsv1.Router{Spec: crdsv1.RouterSpec{Auth: &auth}}
+			router.SetName("sample")
+			router.SetNamespace("env")
+
+			got := GenNginxIngressAnnotations(router)
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("GenNginxIngressAnnotations()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestGenRouteNginxIngressAnnotations(t *testing.T) {
+	router := &crdsv1.Router{Spec: crdsv1.RouterSpec{RateLimit: &crdsv1.RateLimit{Enabled: true, Rps: 10, Connections: 5}}}
+
+	route := crdsv1.Route{
+		App: "api", Path: "/webhooks", Port: 80,
+		RateLimit:   &crdsv1.RateLimit{Enabled: true, Rpm: 100},
+		Timeouts:    &crdsv1.RouteTimeouts{Read: 120},
+		Retry:       &crdsv1.RouteRetry{On: []string{"error", "timeout"}, Attempts: 3},
+		IPDenyList:  []string{"1.2.3.4"},
+		IPAllowList: []string{"10.0.0.0/8", "192.168.0.0/16"},
+	}
+
+	got := GenRouteNginxIngressAnnotations(router, route)
+	want := map[string]string{
+		"nginx.ingress.kubernetes.io/limit-rpm":                   "100",
+		"nginx.ingress.kubernetes.io/proxy-read-timeout":          "120",
+		"nginx.ingress.kubernetes.io/proxy-next-upstream":         "error timeout",
+		"nginx.ingress.kubernetes.io/proxy-next-upstream-tries":   "3",
+		"nginx.ingress.kubernetes.io/proxy-next-upstream-timeout": "0",
+		"nginx.ingress.kubernetes.io/whitelist-source-range":      "10.0.0.0/8,192.168.0.0/16",
+		"nginx.ingress.kubernetes.io/denylist-source-range":       "1.2.3.4",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("GenRouteNginxIngressAnnotations()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+
+	// route's rate limit replaces router's, instead of adding up to it
+	for _, k := range []string{"nginx.ingress.kubernetes.io/limit-rps", "nginx.ingress.kubernetes.io/limit-connections"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("GenRouteNginxIngressAnnotations() has %q, of router's rate limit", k)
+		}
+	}
+}
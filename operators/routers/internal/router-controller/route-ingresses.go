@@ -0,0 +1,81 @@
+package router_controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const labelRouteBackendOf = "kloudlite.io/route-backend-of"
+
+// routeGroup is a set of routes, that share the same settings, and hence, are served by the same ingress
+type routeGroup struct {
+	name        string
+	annotations map[string]string
+	routes      []crdsv1.Route
+}
+
+func annotationsChecksum(annotations map[string]string) string {
+	kv := make([]string, 0, len(annotations))
+	for k, v := range annotations {
+		kv = append(kv, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(kv)
+	return fn.Sha1Sum([]byte(strings.Join(kv, "\n")))
+}
+
+// groupRoutes splits routes by their ingress annotations, as nginx settings are per ingress. Routes without settings of their own
+// stay on the ingress named after the router, while the others go on ingresses named after a checksum of their settings
+func groupRoutes(obj *crdsv1.Router) []routeGroup {
+	routerChecksum := annotationsChecksum(GenNginxIngressAnnotations(obj))
+
+	var groups []routeGroup
+	idx := map[string]int{}
+	for _, route := range obj.Spec.Routes {
+		annotations := GenRouteNginxIngressAnnotations(obj, route)
+		checksum := annotationsChecksum(annotations)
+
+		i, ok := idx[checksum]
+		if !ok {
+			name := obj.Name
+			if checksum != routerChecksum {
+				name = fmt.Sprintf("%s-%s", obj.Name, checksum[:8])
+			}
+			groups = append(groups, routeGroup{name: name, annotations: annotations})
+			i = len(groups) - 1
+			idx[checksum] = i
+		}
+		groups[i].routes = append(groups[i].routes, route)
+	}
+
+	return groups
+}
+
+func routeBackendIngressName(routerName string, routeIdx int) string {
+	return fmt.Sprintf("%s-route-%d-backend", routerName, routeIdx)
+}
+
+// deleteStaleIngresses deletes ingresses, generated for the router and carrying label, except the ones in keep
+func (r *Reconciler) deleteStaleIngresses(ctx context.Context, obj *crdsv1.Router, label string, keep map[string]struct{}) error {
+	var ingList networkingv1.IngressList
+	if err := r.List(ctx, &ingList, client.InNamespace(obj.Namespace), client.MatchingLabels{label: obj.Name}); err != nil {
+		return err
+	}
+
+	for i := range ingList.Items {
+		if _, ok := keep[ingList.Items[i].Name]; ok {
+			continue
+		}
+		if err := r.Delete(ctx, &ingList.Items[i]); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package router_controller
+
+import (
+	"reflect"
+	"testing"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+)
+
+func TestGroupRoutes(t *testing.T) {
+	bodySize := 500
+
+	tests := []struct {
+		name   string
+		routes []crdsv1.Route
+		// want holds paths of routes, per ingress, with ingresses other than router's named by their position
+		want [][]string
+		// wantRouterIngress tells whether the first ingress is named after the router
+		wantRouterIngress bool
+	}{
+		{
+			name:              "1. routes without settings share router's ingress",
+			routes:            []crdsv1.Route{{App: "web", Path: "/", Port: 80}, {App: "api", Path: "/api", Port: 80}},
+			want:              [][]string{{"/", "/api"}},
+			wantRouterIngress: true,
+		},
+		{
+			name: "2. routes with settings of their own are split",
+			routes: []crdsv1.Route{
+				{App: "web", Path: "/", Port: 80},
+				{App: "api", Path: "/upload", Port: 80, MaxBodySizeInMB: &bodySize},
+				{App: "api", Path: "/webhooks", Port: 80, Timeouts: &crdsv1.RouteTimeouts{Read: 5}},
+				{App: "api", Path: "/files", Port: 80, MaxBodySizeInMB: &bodySize},
+			},
+			want:              [][]string{{"/"}, {"/upload", "/files"}, {"/webhooks"}},
+			wantRouterIngress: true,
+		},
+		{
+			name:   "3. every route has settings of its own",
+			routes: []crdsv1.Route{{App: "api", Path: "/", Port: 80, IPAllowList: []string{"10.0.0.0/8"}}},
+			want:   [][]string{{"/"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := &crdsv1.Router{Spec: crdsv1.RouterSpec{Domains: []string{"example.com"}, Routes: tt.routes}}
+			router.SetName("sample")
+
+			groups := groupRoutes(router)
+
+			var got [][]string
+			names := map[string]struct{}{}
+			for _, g := range groups {
+				var paths []string
+				for _, r := range g.routes {
+					paths = append(paths, r.Path)
+				}
+				got = append(got, paths)
+				names[g.name] = struct{}{}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupRoutes() paths = %v, want %v", got, tt.want)
+			}
+			if len(names) != len(groups) {
+				t.Errorf("groupRoutes() ingress names are not unique: %v", names)
+			}
+			if (groups[0].name == router.Name) != tt.wantRouterIngress {
+				t.Errorf("groupRoutes() first ingress = %s, want named after router: %v", groups[0].name, tt.wantRouterIngress)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package router_controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	labelIngressClass = "kloudlite.io/router.ingress-class"
+
+	// labels, ingress-nginx chart puts on its ingress class, and controller services
+	labelIngressNginxInstance  = "app.kubernetes.io/instance"
+	labelIngressNginxComponent = "app.kubernetes.io/component"
+
+	// prefixes names of ports, that routers add to ingress controller services
+	servicePortPrefix = "kl-"
+)
+
+// ports, that ingress controller already listens on, for http(s) traffic
+var reservedPorts = map[uint16]struct{}{80: {}, 443: {}}
+
+func (r *Reconciler) ingressClass(obj *crdsv1.Router) string {
+	if obj.Spec.IngressClass != "" {
+		return obj.Spec.IngressClass
+	}
+	return r.Env.DefaultIngressClass
+}
+
+// servicesScope identifies the configmaps, an ingress controller reads its tcp/udp services from
+type servicesScope struct {
+	namespace    string
+	ingressClass string
+}
+
+func (s servicesScope) configMapName(protocol corev1.Protocol) string {
+	return fmt.Sprintf("%s-%s-services", s.ingressClass, strings.ToLower(string(protocol)))
+}
+
+func (r *Reconciler) servicesScopeOf(obj *crdsv1.Router) (servicesScope, error) {
+	class := r.ingressClass(obj)
+	if class != r.Env.DefaultIngressClass {
+		return servicesScope{namespace: obj.Namespace, ingressClass: class}, nil
+	}
+	if r.Env.IngressControllerNamespace == "" {
+		return servicesScope{}, fmt.Errorf("ingress controller namespace is not configured, set env var INGRESS_CONTROLLER_NAMESPACE to expose ports on the default ingress class")
+	}
+	return servicesScope{namespace: r.Env.IngressControllerNamespace, ingressClass: class}, nil
+}
+
+// claimPorts allocates ports to routers, sharing an ingress controller. Older routers win conflicting ports, and ports, that
+// could not be allocated, are returned per router. It returns contents of tcp-services and udp-services configmaps
+func claimPorts(routers []crdsv1.Router) (map[corev1.Protocol]map[string]string, map[string][]string) {
+	sorted := make([]*crdsv1.Router, 0, len(routers))
+	for i := range routers {
+		sorted = append(sorted, &routers[i])
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+		}
+		return fn.NN(sorted[i].Namespace, sorted[i].Name).String() < fn.NN(sorted[j].Namespace, sorted[j].Name).String()
+	})
+
+	data := map[corev1.Protocol]map[string]string{
+		corev1.ProtocolTCP: {},
+		corev1.ProtocolUDP: {},
+	}
+	owners := map[string]string{}
+	conflicts := map[string][]string{}
+
+	for _, router := range sorted {
+		nn := fn.NN(router.Namespace, router.Name).String()
+		for _, p := range router.Spec.Ports {
+			protocol := p.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+
+			key := fmt.Sprintf("%d/%s", p.Port, protocol)
+			if _, ok := reservedPorts[p.Port]; ok && protocol == corev1.ProtocolTCP {
+				conflicts[nn] = append(conflicts[nn], fmt.Sprintf("%s (reserved for http traffic)", key))
+				continue
+			}
+			if owner, ok := owners[key]; ok {
+				conflicts[nn] = append(conflicts[nn], fmt.Sprintf("%s (already exposed by router %s)", key, owner))
+				continue
+			}
+
+			owners[key] = nn
+			data[protocol][fmt.Sprintf("%d", p.Port)] = fmt.Sprintf("%s/%s:%d", router.Namespace, p.Service, p.ServicePort)
+		}
+	}
+
+	return data, conflicts
+}
+
+// controllerServices are services of the ingress controller, serving scope's ingress class. Services of its admission webhook are left out,
+// as they have no http port
+func (r *Reconciler) controllerServices(ctx context.Context, scope servicesScope) ([]corev1.Service, error) {
+	ic, err := rApi.Get(ctx, r.Client, fn.NN("", scope.ingressClass), &networkingv1.IngressClass{})
+	if err != nil {
+		// ingress controller might not be installed yet
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	instance, ok := ic.GetLabels()[labelIngressNginxInstance]
+	if !ok {
+		return nil, nil
+	}
+
+	var svcList corev1.ServiceList
+	if err := r.List(ctx, &svcList, client.InNamespace(scope.namespace), client.MatchingLabels{
+		labelIngressNginxInstance:  instance,
+		labelIngressNginxComponent: "controller",
+	}); err != nil {
+		return nil, err
+	}
+
+	services := make([]corev1.Service, 0, len(svcList.Items))
+	for i := range svcList.Items {
+		for _, p := range svcList.Items[i].Spec.Ports {
+			if p.Name == "http" {
+				services = append(services, svcList.Items[i])
+				break
+			}
+		}
+	}
+	return services, nil
+}
+
+// servicePorts replaces ports, that routers added to an ingress controller service, with ones for data, i.e. contents of tcp/udp services
+// configmaps. Node ports, already allocated to them, are kept
+func servicePorts(current []corev1.ServicePort, data map[corev1.Protocol]map[string]string) []corev1.ServicePort {
+	nodePorts := map[string]int32{}
+	ports := make([]corev1.ServicePort, 0, len(current))
+	for _, p := range current {
+		if strings.HasPrefix(p.Name, servicePortPrefix) {
+			nodePorts[p.Name] = p.NodePort
+			continue
+		}
+		ports = append(ports, p)
+	}
+
+	for _, protocol := range []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP} {
+		keys := make([]string, 0, len(data[protocol]))
+		for k := range data[protocol] {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, _ := strconv.Atoi(keys[i])
+			b, _ := strconv.Atoi(keys[j])
+			return a < b
+		})
+
+		for _, k := range keys {
+			port, err := strconv.Atoi(k)
+			if err != nil {
+				continue
+			}
+			name := fmt.Sprintf("%s%s-%d", servicePortPrefix, strings.ToLower(string(protocol)), port)
+			ports = append(ports, corev1.ServicePort{
+				Name:       name,
+				Protocol:   protocol,
+				Port:       int32(port),
+				TargetPort: intstr.FromInt(port),
+				NodePort:   nodePorts[name],
+			})
+		}
+	}
+	return ports
+}
+
+// syncServicesConfigMaps rebuilds tcp/udp services configmaps of ingress controllers, that this router exposes its ports on, or used to,
+// and the ports of their controller services. It returns ports of this router, that could not be exposed
+func (r *Reconciler) syncServicesConfigMaps(ctx context.Context, obj *crdsv1.Router) ([]string, error) {
+	scopes := map[servicesScope]struct{}{}
+
+	if len(obj.Spec.Ports) > 0 && obj.GetDeletionTimestamp() == nil {
+		scope, err := r.servicesScopeOf(obj)
+		if err != nil {
+			return nil, err
+		}
+		scopes[scope] = struct{}{}
+	}
+
+	// configmaps, this router's ports might still be on, after its ingress class changed, or it stopped exposing them
+	for _, ns := range []string{obj.Namespace, r.Env.IngressControllerNamespace} {
+		if ns == "" {
+			continue
+		}
+		var cmList corev1.ConfigMapList
+		if err := r.List(ctx, &cmList, client.InNamespace(ns), client.MatchingLabels{constants.RouterTCPUDPServicesKey: "true"}); err != nil {
+			return nil, err
+		}
+		for i := range cmList.Items {
+			scopes[servicesScope{namespace: ns, ingressClass: cmList.Items[i].GetLabels()[labelIngressClass]}] = struct{}{}
+		}
+	}
+
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+
+	var routers crdsv1.RouterList
+	if err := r.List(ctx, &routers); err != nil {
+		return nil, err
+	}
+
+	var unexposed []string
+	for scope := range scopes {
+		var members []crdsv1.Router
+		for i := range routers.Items {
+			router := &routers.Items[i]
+			if router.GetDeletionTimestamp() != nil || len(router.Spec.Ports) == 0 {
+				continue
+			}
+			if s, err := r.servicesScopeOf(router); err != nil || s != scope {
+				continue
+			}
+			members = append(members, *router)
+		}
+
+		data, conflicts := claimPorts(members)
+		unexposed = append(unexposed, conflicts[fn.NN(obj.Namespace, obj.Name).String()]...)
+
+		for _, protocol := range []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP} {
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: scope.configMapName(protocol), Namespace: scope.namespace}}
+			if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+				cm.SetLabels(fn.MapMerge(cm.GetLabels(), map[string]string{
+					constants.RouterTCPUDPServicesKey: "true",
+					labelIngressClass:                 scope.ingressClass,
+				}))
+				cm.Data = data[protocol]
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		services, err := r.controllerServices(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		for i := range services {
+			svc := &services[i]
+			ports := servicePorts(svc.Spec.Ports, data)
+			if reflect.DeepEqual(ports, svc.Spec.Ports) {
+				continue
+			}
+			svc.Spec.Ports = ports
+			if err := r.Update(ctx, svc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return unexposed, nil
+}
+
+func (r *Reconciler) ensureExposedPorts(req *rApi.Request[*crdsv1.Router]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(ExposingPortsIfAny, req)
+
+	unexposed, err := r.syncServicesConfigMaps(ctx, obj)
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	if len(unexposed) > 0 {
+		// ports, held by other routers, might get released later on
+		return check.Failed(fmt.Errorf("ports could not be exposed: %s", strings.Join(unexposed, ", "))).Err(nil).RequeueAfter(30 * time.Second)
+	}
+
+	return check.Completed()
+}
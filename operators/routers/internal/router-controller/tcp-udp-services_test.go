@@ -0,0 +1,135 @@
+package router_controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestClaimPorts(t *testing.T) {
+	now := time.Now()
+	router := func(name string, createdAt time.Time, ports ...crdsv1.RouterPort) crdsv1.Router {
+		return crdsv1.Router{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "env", CreationTimestamp: metav1.Time{Time: createdAt}},
+			Spec:       crdsv1.RouterSpec{Ports: ports},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		routers       []crdsv1.Router
+		wantData      map[corev1.Protocol]map[string]string
+		wantConflicts map[string][]string
+	}{
+		{
+			name: "1. tcp and udp on the same port",
+			routers: []crdsv1.Router{
+				router("pg", now, crdsv1.RouterPort{Port: 5432, Service: "pg", ServicePort: 5432}),
+				router("dns", now, crdsv1.RouterPort{Port: 5432, Protocol: corev1.ProtocolUDP, Service: "dns", ServicePort: 53}),
+			},
+			wantData: map[corev1.Protocol]map[string]string{
+				corev1.ProtocolTCP: {"5432": "env/pg:5432"},
+				corev1.ProtocolUDP: {"5432": "env/dns:53"},
+			},
+			wantConflicts: map[string][]string{},
+		},
+		{
+			name: "2. older router wins a conflicting port",
+			routers: []crdsv1.Router{
+				router("redis-new", now, crdsv1.RouterPort{Port: 6379, Service: "redis-new", ServicePort: 6379}),
+				router("redis-old", now.Add(-time.Hour), crdsv1.RouterPort{Port: 6379, Service: "redis-old", ServicePort: 6379}),
+			},
+			wantData: map[corev1.Protocol]map[string]string{
+				corev1.ProtocolTCP: {"6379": "env/redis-old:6379"},
+				corev1.ProtocolUDP: {},
+			},
+			wantConflicts: map[string][]string{"env/redis-new": {"6379/TCP (already exposed by router env/redis-old)"}},
+		},
+		{
+			name: "3. http ports are reserved",
+			routers: []crdsv1.Router{
+				router("web", now, crdsv1.RouterPort{Port: 443, Service: "web", ServicePort: 8443}),
+			},
+			wantData: map[corev1.Protocol]map[string]string{
+				corev1.ProtocolTCP: {},
+				corev1.ProtocolUDP: {},
+			},
+			wantConflicts: map[string][]string{"env/web": {"443/TCP (reserved for http traffic)"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotData, gotConflicts := claimPorts(tt.routers)
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("claimPorts() data = %v, want %v", gotData, tt.wantData)
+			}
+			if !reflect.DeepEqual(gotConflicts, tt.wantConflicts) {
+				t.Errorf("claimPorts() conflicts = %v, want %v", gotConflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestServicePorts(t *testing.T) {
+	http := corev1.ServicePort{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromString("http")}
+	https := corev1.ServicePort{Name: "https", Protocol: corev1.ProtocolTCP, Port: 443, TargetPort: intstr.FromString("https")}
+	port := func(protocol corev1.Protocol, name string, p int, nodePort int32) corev1.ServicePort {
+		return corev1.ServicePort{Name: name, Protocol: protocol, Port: int32(p), TargetPort: intstr.FromInt(p), NodePort: nodePort}
+	}
+
+	tests := []struct {
+		name    string
+		current []corev1.ServicePort
+		data    map[corev1.Protocol]map[string]string
+		want    []corev1.ServicePort
+	}{
+		{
+			name:    "1. adds ports, sorted by protocol and port",
+			current: []corev1.ServicePort{http, https},
+			data: map[corev1.Protocol]map[string]string{
+				corev1.ProtocolTCP: {"6379": "env/redis:6379", "5432": "env/pg:5432"},
+				corev1.ProtocolUDP: {"53": "env/dns:53"},
+			},
+			want: []corev1.ServicePort{
+				http, https,
+				port(corev1.ProtocolTCP, "kl-tcp-5432", 5432, 0),
+				port(corev1.ProtocolTCP, "kl-tcp-6379", 6379, 0),
+				port(corev1.ProtocolUDP, "kl-udp-53", 53, 0),
+			},
+		},
+		{
+			name: "2. removes ports, no longer exposed, and keeps allocated node ports",
+			current: []corev1.ServicePort{
+				http,
+				port(corev1.ProtocolTCP, "kl-tcp-5432", 5432, 31432),
+				port(corev1.ProtocolTCP, "kl-tcp-6379", 6379, 31379),
+				https,
+			},
+			data: map[corev1.Protocol]map[string]string{
+				corev1.ProtocolTCP: {"5432": "env/pg:5432"},
+			},
+			want: []corev1.ServicePort{http, https, port(corev1.ProtocolTCP, "kl-tcp-5432", 5432, 31432)},
+		},
+		{
+			name:    "3. leaves service untouched, without any ports to expose",
+			current: []corev1.ServicePort{http, https},
+			data: map[corev1.Protocol]map[string]string{
+				corev1.ProtocolTCP: {},
+				corev1.ProtocolUDP: {},
+			},
+			want: []corev1.ServicePort{http, https},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := servicePorts(tt.current, tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("servicePorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
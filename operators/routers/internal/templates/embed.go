@@ -14,3 +14,7 @@ func ReadIngressTemplate() ([]byte, error) {
 }
 
 var ParseBytes = templates.ParseBytes
+
+func ReadOauth2ProxyTemplate() ([]byte, error) {
+	return templatesDir.ReadFile("oauth2-proxy.yml.tpl")
+}
@@ -0,0 +1,89 @@
+{{- $name := get . "name" }}
+{{- $namespace := get . "namespace" }}
+
+{{- $ownerRefs := get . "owner-refs" | default list }}
+{{- $labels := get . "labels" | default dict }}
+
+{{- $image := get . "image" }}
+{{- $secretName := get . "secret-name" }}
+{{- $secretChecksum := get . "secret-checksum" }}
+{{- $port := get . "port" }}
+
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{$name}}
+  namespace: {{$namespace}}
+  labels: {{ $labels | toYAML | nindent 4 }}
+  ownerReferences: {{ $ownerRefs | toYAML | nindent 4 }}
+spec:
+  replicas: 1
+  selector:
+    matchLabels: {{ $labels | toYAML | nindent 6 }}
+  template:
+    metadata:
+      labels: {{ $labels | toYAML | nindent 8 }}
+      annotations:
+        kloudlite.io/oauth2-proxy.secret-checksum: {{$secretChecksum}}
+    spec:
+      containers:
+        - name: oauth2-proxy
+          image: {{$image}}
+          args:
+            - --http-address=0.0.0.0:{{$port}}
+            - --provider=oidc
+            - --reverse-proxy=true
+            - --set-xauthrequest=true
+            - --skip-provider-button=true
+            - --upstream=static://202
+            - --email-domain=*
+            - --cookie-secure=true
+          env:
+            - name: OAUTH2_PROXY_OIDC_ISSUER_URL
+              valueFrom:
+                secretKeyRef:
+                  name: {{$secretName}}
+                  key: issuer-url
+            - name: OAUTH2_PROXY_CLIENT_ID
+              valueFrom:
+                secretKeyRef:
+                  name: {{$secretName}}
+                  key: client-id
+            - name: OAUTH2_PROXY_CLIENT_SECRET
+              valueFrom:
+                secretKeyRef:
+                  name: {{$secretName}}
+                  key: client-secret
+            - name: OAUTH2_PROXY_COOKIE_SECRET
+              valueFrom:
+                secretKeyRef:
+                  name: {{$secretName}}
+                  key: cookie-secret
+          ports:
+            - name: http
+              containerPort: {{$port}}
+          readinessProbe:
+            httpGet:
+              path: /ready
+              port: {{$port}}
+          resources:
+            requests:
+              cpu: 10m
+              memory: 32Mi
+            limits:
+              cpu: 100m
+              memory: 64Mi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{$name}}
+  namespace: {{$namespace}}
+  labels: {{ $labels | toYAML | nindent 4 }}
+  ownerReferences: {{ $ownerRefs | toYAML | nindent 4 }}
+spec:
+  selector: {{ $labels | toYAML | nindent 4 }}
+  ports:
+    - name: http
+      port: {{$port}}
+      targetPort: {{$port}}
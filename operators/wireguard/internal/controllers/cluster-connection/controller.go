@@ -89,7 +89,7 @@ var (
 // +kubebuilder:rbac:groups=wireguard.kloudlite.io,resources=connections/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &wgv1.ClusterConnection{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &wgv1.ClusterConnection{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
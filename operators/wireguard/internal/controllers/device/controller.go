@@ -84,7 +84,7 @@ var (
 // +kubebuilder:rbac:groups=wireguard.kloudlite.io,resources=devices/finalizers,verbs=update
 
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger), r.Client, request.NamespacedName, &wgv1.Device{})
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, &wgv1.Device{})
 	if err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
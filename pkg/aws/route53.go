@@ -5,6 +5,7 @@ import (
 	"net"
 	"reflect"
 	"sort"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -16,6 +17,11 @@ type Route53 interface {
 	UpdateRecord(site string, aRecords []string, hostedZone string) error
 	getRecord(site string, hostedZone *string, zoneId *string) (*route53.ResourceRecordSet, error)
 	DeleteRecord(site string, hostedZone *string, zoneId *string) error
+
+	// GetRecordSet returns record set of the given type, or nil, when site has none
+	GetRecordSet(site string, recordType string, hostedZone string) (*route53.ResourceRecordSet, error)
+	UpsertRecordSet(site string, recordType string, values []string, ttl int64, hostedZone string) error
+	DeleteRecordSet(site string, recordType string, hostedZone string) error
 }
 
 type aws_route53 struct {
@@ -193,6 +199,95 @@ func (a aws_route53) getRecord(site string, hostedZone *string, zoneId *string)
 	return nil, fmt.Errorf("domain %s not found", site)
 }
 
+// route53 escapes '*' of wildcard record names, as octal
+func unescapeRecordName(name string) string {
+	return strings.ReplaceAll(name, "\\052", "*")
+}
+
+func (a aws_route53) GetRecordSet(site string, recordType string, hostedZone string) (*route53.ResourceRecordSet, error) {
+	zoneId, err := a.getHid(aws.String(hostedZone))
+	if err != nil {
+		return nil, err
+	}
+
+	recordName := fmt.Sprintf("%s.", site)
+	result, err := a.svc.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    zoneId,
+		MaxItems:        aws.String("1"),
+		StartRecordName: aws.String(recordName),
+		StartRecordType: aws.String(recordType),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, recordSet := range result.ResourceRecordSets {
+		if unescapeRecordName(*recordSet.Name) == recordName && *recordSet.Type == recordType {
+			return recordSet, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (a aws_route53) UpsertRecordSet(site string, recordType string, values []string, ttl int64, hostedZone string) error {
+	zoneId, err := a.getHid(aws.String(hostedZone))
+	if err != nil {
+		return err
+	}
+
+	rRecords := make([]*route53.ResourceRecord, 0, len(values))
+	for _, v := range values {
+		rRecords = append(rRecords, &route53.ResourceRecord{Value: aws.String(v)})
+	}
+
+	_, err = a.svc.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: zoneId,
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(route53.ChangeActionUpsert),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name:            aws.String(fmt.Sprintf("%s.", site)),
+						Type:            aws.String(recordType),
+						TTL:             aws.Int64(ttl),
+						ResourceRecords: rRecords,
+					},
+				},
+			},
+		},
+	})
+	return err
+}
+
+func (a aws_route53) DeleteRecordSet(site string, recordType string, hostedZone string) error {
+	rSet, err := a.GetRecordSet(site, recordType, hostedZone)
+	if err != nil {
+		return err
+	}
+	if rSet == nil {
+		return nil
+	}
+
+	zoneId, err := a.getHid(aws.String(hostedZone))
+	if err != nil {
+		return err
+	}
+
+	_, err = a.svc.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: zoneId,
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action:            aws.String(route53.ChangeActionDelete),
+					ResourceRecordSet: rSet,
+				},
+			},
+		},
+	})
+	return err
+}
+
 func GetARecordFromLive(host string) []string {
 	addresses, err := net.LookupHost(host)
 	if err != nil {
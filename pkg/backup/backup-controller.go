@@ -0,0 +1,221 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	job_manager "github.com/kloudlite/operator/pkg/job-helper"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+const (
+	runBackup       string = "run-backup"
+	removeArtifacts string = "remove-artifacts"
+)
+
+var (
+	backupChecklist        = []rApi.CheckMeta{{Name: runBackup, Title: "Running Backup"}}
+	backupDestroyChecklist = []rApi.CheckMeta{{Name: removeArtifacts, Title: "Removing Backup Artifacts"}}
+)
+
+type backupReconciler[S ScheduleObject, B BackupObject, R RestoreObject, D DatabaseObject] struct {
+	client.Client
+	logger logging.Logger
+	name   string
+	engine *Engine[S, B, R, D]
+}
+
+func (r *backupReconciler[S, B, R, D]) GetName() string {
+	return r.name
+}
+
+func (r *backupReconciler[S, B, R, D]) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, r.engine.NewBackup())
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	req.PreReconcile()
+	defer req.PostReconcile()
+
+	if req.Object.GetDeletionTimestamp() != nil {
+		if x := r.finalize(req); !x.ShouldProceed() {
+			return x.ReconcilerResponse()
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if step := req.ClearStatusIfAnnotated(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureCheckList(backupChecklist); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureLabelsAndAnnotations(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	// no foreground deletion, as the cleanup job, and s3 credentials, it is run with, are owned by the backup
+	if step := req.EnsureFinalizers(constants.CommonFinalizer); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.runBackup(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	req.Object.GetStatus().IsReady = true
+	return ctrl.Result{}, nil
+}
+
+func (r *backupReconciler[S, B, R, D]) finalize(req *rApi.Request[B]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(removeArtifacts, req)
+
+	if step := req.EnsureCheckList(backupDestroyChecklist); !step.ShouldProceed() {
+		return step
+	}
+
+	out := obj.GetBackupOutput()
+	if out.ArtifactURL == "" {
+		return req.Finalize()
+	}
+
+	if r.engine.JobImage == "" {
+		return check.Failed(fmt.Errorf("env var BACKUP_JOB_IMAGE is not set, artifact %s can not be removed", out.ArtifactURL)).Err(nil)
+	}
+
+	target := obj.GetBackupSpec().Target
+	s3Secret, err := EnsureCredentials(ctx, r.Client, obj, target, r.engine.CredentialsNamespaces)
+	if err != nil {
+		if errors.Is(err, ErrCredentialsNotAllowed) {
+			return check.Failed(err).Err(nil)
+		}
+		return check.StillRunning(err)
+	}
+
+	job := NewJob(JobArgs{
+		Name:        obj.GetName() + "-cleanup",
+		Namespace:   obj.GetNamespace(),
+		Image:       r.engine.JobImage,
+		Script:      RemoveArtifactScript,
+		S3Secret:    s3Secret,
+		Target:      target,
+		ArtifactURL: out.ArtifactURL,
+		Owner:       obj,
+	})
+
+	done, err := RunJob(ctx, r.Client, job)
+	if err != nil {
+		// a new job gets to retry
+		if err := job_manager.DeleteJob(ctx, r.Client, job.Namespace, job.Name); err != nil {
+			return check.StillRunning(err)
+		}
+		return check.Failed(err)
+	}
+	if !done {
+		return check.StillRunning(fmt.Errorf("waiting for artifact %s to be removed", out.ArtifactURL)).Err(nil).RequeueAfter(5 * time.Second)
+	}
+
+	return req.Finalize()
+}
+
+func (r *backupReconciler[S, B, R, D]) runBackup(req *rApi.Request[B]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(runBackup, req)
+
+	if obj.GetBackupOutput().CompletedAt != nil {
+		return check.Completed()
+	}
+
+	if r.engine.JobImage == "" {
+		return check.Failed(fmt.Errorf("env var BACKUP_JOB_IMAGE is not set, backups can not be run")).Err(nil)
+	}
+
+	spec := obj.GetBackupSpec()
+	db, err := rApi.Get(ctx, r.Client, fn.NN(obj.GetNamespace(), spec.DatabaseName), r.engine.NewDatabase())
+	if err != nil {
+		return check.Failed(err).Err(nil).RequeueAfter(30 * time.Second)
+	}
+	if !db.GetStatus().IsReady {
+		return check.StillRunning(fmt.Errorf("waiting for database %s to be ready", db.GetName())).Err(nil).RequeueAfter(10 * time.Second)
+	}
+
+	dbSecret, err := rApi.Get(ctx, r.Client, fn.NN(db.GetNamespace(), r.engine.DatabaseSecret(db)), &corev1.Secret{})
+	if err != nil {
+		return check.StillRunning(err)
+	}
+	dbName := string(dbSecret.Data["DB_NAME"])
+	if dbName == "" {
+		return check.Failed(fmt.Errorf("secret %s has no DB_NAME", dbSecret.Name)).Err(nil)
+	}
+
+	s3Secret, err := EnsureCredentials(ctx, r.Client, obj, spec.Target, r.engine.CredentialsNamespaces)
+	if err != nil {
+		// credentials of a namespace, that is not allowed, stay so until spec changes
+		if errors.Is(err, ErrCredentialsNotAllowed) {
+			return check.Failed(err).Err(nil)
+		}
+		return check.StillRunning(err)
+	}
+
+	artifactURL := ArtifactURL(spec.Target, obj.GetNamespace(), db.GetName(), obj.GetName(), r.engine.ArtifactExt)
+
+	done, err := RunJob(ctx, r.Client, NewJob(JobArgs{
+		Name:           obj.GetName() + "-backup",
+		Namespace:      obj.GetNamespace(),
+		Image:          r.engine.JobImage,
+		Script:         r.engine.script(UploadScript(r.engine.DumpScript)),
+		DatabaseSecret: dbSecret.Name,
+		S3Secret:       s3Secret,
+		Target:         spec.Target,
+		ArtifactURL:    artifactURL,
+		Owner:          obj,
+	}))
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+	if !done {
+		return check.StillRunning(fmt.Errorf("waiting for backup job to complete")).Err(nil).RequeueAfter(10 * time.Second)
+	}
+
+	msvcRef := db.GetMsvcRef()
+	*obj.GetBackupOutput() = ct.BackupOutput{
+		ArtifactURL: artifactURL,
+		DbName:      dbName,
+		MsvcRef:     &msvcRef,
+		CompletedAt: &metav1.Time{Time: time.Now()},
+	}
+
+	if err := rApi.UpdatePreservingStatus(ctx, r.Client, obj); err != nil {
+		return check.StillRunning(err)
+	}
+
+	return check.Completed()
+}
+
+func (r *backupReconciler[S, B, R, D]) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) error {
+	r.Client = mgr.GetClient()
+	r.logger = logger.WithName(r.name)
+
+	builder := ctrl.NewControllerManagedBy(mgr).For(r.engine.NewBackup())
+	builder.Owns(&batchv1.Job{})
+	builder.WithEventFilter(rApi.ReconcileFilter())
+	builder.WithOptions(controller.Options{MaxConcurrentReconciles: r.engine.MaxConcurrentReconciles})
+	return builder.Complete(r)
+}
@@ -0,0 +1,231 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	job_manager "github.com/kloudlite/operator/pkg/job-helper"
+	"github.com/robfig/cron/v3"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// LabelBackupSchedule marks backups, that a BackupSchedule has created
+	LabelBackupSchedule = "kloudlite.io/backup-schedule"
+
+	DefaultKeepLast = 7
+)
+
+// awsS3 is the aws cli, pointed to the endpoint of the target, when it is not aws s3
+const awsS3 = `aws ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"} s3`
+
+// RemoveArtifactScript deletes the artifact of a backup, from its target
+const RemoveArtifactScript = awsS3 + ` rm "$ARTIFACT_URL"`
+
+// UploadScript streams stdout of dump to ARTIFACT_URL
+func UploadScript(dump string) string {
+	return fmt.Sprintf("%s | %s cp - \"$ARTIFACT_URL\"", dump, awsS3)
+}
+
+// DownloadScript streams ARTIFACT_URL to stdin of restore
+func DownloadScript(restore string) string {
+	return fmt.Sprintf("%s cp \"$ARTIFACT_URL\" - | %s", awsS3, restore)
+}
+
+// ArtifactURL is where a backup gets streamed to, in the target bucket
+func ArtifactURL(target ct.S3Target, namespace string, database string, backupName string, ext string) string {
+	key := path.Join(strings.Trim(target.Prefix, "/"), namespace, database, fmt.Sprintf("%s.%s", backupName, ext))
+	return fmt.Sprintf("s3://%s/%s", target.Bucket, key)
+}
+
+// NextRun is the first time, after after, that a cron schedule fires
+func NextRun(schedule string, after time.Time) (time.Time, error) {
+	s, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	return s.Next(after), nil
+}
+
+// Snapshot is a completed backup, that could be restored
+type Snapshot struct {
+	Name        string
+	CompletedAt time.Time
+}
+
+func newestFirst(snapshots []Snapshot) []Snapshot {
+	sorted := make([]Snapshot, len(snapshots))
+	copy(sorted, snapshots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CompletedAt.After(sorted[j].CompletedAt)
+	})
+	return sorted
+}
+
+// Expired are names of backups, beyond the keepLast most recent ones
+func Expired(snapshots []Snapshot, keepLast int) []string {
+	if keepLast <= 0 {
+		keepLast = DefaultKeepLast
+	}
+
+	sorted := newestFirst(snapshots)
+	if len(sorted) <= keepLast {
+		return nil
+	}
+
+	names := make([]string, 0, len(sorted)-keepLast)
+	for _, p := range sorted[keepLast:] {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// LatestBefore picks the latest backup, that completed at, or before at. It selects a snapshot to restore, changes made after it are lost
+func LatestBefore(snapshots []Snapshot, at time.Time) (string, error) {
+	for _, p := range newestFirst(snapshots) {
+		if !p.CompletedAt.After(at) {
+			return p.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no backup completed at, or before %s", at.Format(time.RFC3339))
+}
+
+// ErrCredentialsNotAllowed is returned, when a target refers to s3 credentials in a namespace, that its object may not read from
+var ErrCredentialsNotAllowed = errors.New("s3 credentials may not be read from namespace")
+
+// EnsureCredentials makes s3 credentials of target available in the namespace of obj, as jobs can only read secrets of their own namespace.
+// Credentials are read from namespace of obj, or from one of allowedNamespaces, so that objects can not copy secrets of namespaces, they
+// have no access to. It returns name of the secret, that jobs should read credentials from
+func EnsureCredentials(ctx context.Context, cli client.Client, obj client.Object, target ct.S3Target, allowedNamespaces []string) (string, error) {
+	ref := target.CredentialsRef
+	if ref.Namespace == "" || ref.Namespace == obj.GetNamespace() {
+		return ref.Name, nil
+	}
+	if !slices.Contains(allowedNamespaces, ref.Namespace) {
+		return "", fmt.Errorf("%w %s, only namespace %s, or the ones allowed by the operator, are", ErrCredentialsNotAllowed, ref.Namespace, obj.GetNamespace())
+	}
+
+	var src corev1.Secret
+	if err := cli.Get(ctx, fn.NN(ref.Namespace, ref.Name), &src); err != nil {
+		return "", err
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: obj.GetName() + "-s3-credentials", Namespace: obj.GetNamespace()}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, cli, secret, func() error {
+		secret.SetOwnerReferences([]metav1.OwnerReference{fn.AsOwner(obj, true)})
+		secret.Data = src.Data
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return secret.Name, nil
+}
+
+type JobArgs struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	Image     string
+	// Script runs with bash, and streams artifacts with the aws cli, to, or from ARTIFACT_URL
+	Script string
+	Env    []corev1.EnvVar
+
+	// DatabaseSecret holds credentials of the database, as exposed by its managed service
+	DatabaseSecret string
+	S3Secret       string
+	Target         ct.S3Target
+
+	ArtifactURL string
+	Owner       client.Object
+}
+
+// NewJob runs a backup, restore, or cleanup script, with database and s3 credentials, as env vars
+func NewJob(args JobArgs) *batchv1.Job {
+	env := []corev1.EnvVar{
+		{Name: "ARTIFACT_URL", Value: args.ArtifactURL},
+		{Name: "S3_ENDPOINT", Value: args.Target.Endpoint},
+	}
+	if args.Target.Region != "" {
+		env = append(env, corev1.EnvVar{Name: "AWS_REGION", Value: args.Target.Region})
+	}
+	env = append(env, args.Env...)
+
+	envFrom := []corev1.EnvFromSource{
+		{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: args.S3Secret}}},
+	}
+	if args.DatabaseSecret != "" {
+		envFrom = append(envFrom, corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: args.DatabaseSecret}}})
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            args.Name,
+			Namespace:       args.Namespace,
+			Labels:          args.Labels,
+			OwnerReferences: []metav1.OwnerReference{fn.AsOwner(args.Owner, true)},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: fn.New(int32(2)),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:                     "main",
+							Image:                    args.Image,
+							Command:                  []string{"bash", "-c", "set -euo pipefail\n" + args.Script},
+							Env:                      env,
+							EnvFrom:                  envFrom,
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// RunJob creates job, if it does not exist yet, and tells whether it has succeeded. It returns an error, with the job's termination log,
+// once it has failed
+func RunJob(ctx context.Context, cli client.Client, job *batchv1.Job) (bool, error) {
+	var current batchv1.Job
+	if err := cli.Get(ctx, client.ObjectKeyFromObject(job), &current); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return false, err
+		}
+		if err := cli.Create(ctx, job); err != nil && !apiErrors.IsAlreadyExists(err) {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if !job_manager.HasJobFinished(ctx, cli, &current) {
+		return false, nil
+	}
+
+	if current.Status.Succeeded > 0 {
+		return true, nil
+	}
+
+	for _, c := range current.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return false, fmt.Errorf("job %s failed: %s", job.Name, job_manager.GetTerminationLog(ctx, cli, job.Namespace, job.Name))
+		}
+	}
+
+	// the pod has finished, though the job controller has yet to record it
+	return false, nil
+}
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestArtifactURL(t *testing.T) {
+	got := ArtifactURL(ct.S3Target{Bucket: "backups", Prefix: "/kloudlite/"}, "sample", "db", "nightly", "sql.gz")
+	if want := "s3://backups/kloudlite/sample/db/nightly.sql.gz"; got != want {
+		t.Fatalf("ArtifactURL() = %q, want %q", got, want)
+	}
+
+	got = ArtifactURL(ct.S3Target{Bucket: "backups"}, "sample", "db", "nightly", "archive.gz")
+	if want := "s3://backups/sample/db/nightly.archive.gz"; got != want {
+		t.Fatalf("ArtifactURL() = %q, want %q", got, want)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	after := time.Date(2023, 7, 1, 2, 30, 0, 0, time.UTC)
+	got, err := NextRun("0 2 * * *", after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2023, 7, 2, 2, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("NextRun() = %s, want %s", got, want)
+	}
+
+	if _, err := NextRun("every night", after); err == nil {
+		t.Fatalf("NextRun() accepted an invalid schedule")
+	}
+}
+
+func testSnapshots() []Snapshot {
+	base := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	return []Snapshot{
+		{Name: "b2", CompletedAt: base.Add(2 * time.Hour)},
+		{Name: "b0", CompletedAt: base},
+		{Name: "b3", CompletedAt: base.Add(3 * time.Hour)},
+		{Name: "b1", CompletedAt: base.Add(1 * time.Hour)},
+	}
+}
+
+func TestExpired(t *testing.T) {
+	if got, want := Expired(testSnapshots(), 2), []string{"b1", "b0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expired() = %v, want %v", got, want)
+	}
+	if got := Expired(testSnapshots(), 0); got != nil {
+		t.Fatalf("Expired() = %v, with default retention, want none", got)
+	}
+}
+
+func TestLatestBefore(t *testing.T) {
+	base := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at      time.Time
+		want    string
+		wantErr bool
+	}{
+		{at: base.Add(90 * time.Minute), want: "b1"},
+		{at: base.Add(2 * time.Hour), want: "b2"},
+		{at: base.Add(24 * time.Hour), want: "b3"},
+		{at: base.Add(-time.Minute), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := LatestBefore(testSnapshots(), tt.at)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("LatestBefore(%s) = (%q, %v), want %q", tt.at, got, err, tt.want)
+		}
+	}
+}
+
+func TestEnsureCredentials(t *testing.T) {
+	backup := &mongodbMsvcv1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "db"}}
+	secrets := []*corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "db"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "backups"}, Data: map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("key")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "other-team"}},
+	}
+
+	tests := []struct {
+		name    string
+		ref     ct.SecretRef
+		want    string
+		wantErr error
+	}{
+		{name: "own namespace", ref: ct.SecretRef{Name: "s3", Namespace: "db"}, want: "s3"},
+		{name: "namespace not set", ref: ct.SecretRef{Name: "s3"}, want: "s3"},
+		{name: "allowed namespace", ref: ct.SecretRef{Name: "s3", Namespace: "backups"}, want: "nightly-s3-credentials"},
+		{name: "namespace not allowed", ref: ct.SecretRef{Name: "s3", Namespace: "other-team"}, wantErr: ErrCredentialsNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			for _, s := range secrets {
+				builder.WithObjects(s.DeepCopy())
+			}
+			cli := builder.Build()
+
+			got, err := EnsureCredentials(context.TODO(), cli, backup, ct.S3Target{Bucket: "backups", CredentialsRef: tt.ref}, []string{"backups"})
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("EnsureCredentials() = (%q, %v), want (%q, %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScheduleObject is the BackupSchedule kind of a managed service
+type ScheduleObject interface {
+	rApi.Resource
+	GetScheduleSpec() *ct.BackupScheduleSpec
+}
+
+// BackupObject is the Backup kind of a managed service
+type BackupObject interface {
+	rApi.Resource
+	GetBackupSpec() *ct.BackupSpec
+	GetBackupOutput() *ct.BackupOutput
+}
+
+// RestoreObject is the Restore kind of a managed service
+type RestoreObject interface {
+	rApi.Resource
+	GetRestoreSpec() *ct.RestoreSpec
+}
+
+// DatabaseObject is the Database kind of a managed service, that gets backed up, and restored into
+type DatabaseObject interface {
+	rApi.Resource
+	GetMsvcRef() ct.MsvcRef
+}
+
+// Engine is what differs between managed services, when backing up, and restoring their databases. Scheduling, retention, and jobs are
+// common to all of them
+type Engine[S ScheduleObject, B BackupObject, R RestoreObject, D DatabaseObject] struct {
+	NewSchedule   func() S
+	NewBackup     func() B
+	NewBackupList func() client.ObjectList
+	NewRestore    func() R
+	NewDatabase   func() D
+
+	// NewRestoreTarget is the database, that a restore creates on msvc, when the one it restores into does not exist
+	NewRestoreTarget func(name string, namespace string, msvc ct.MsvcRef) D
+	// DatabaseSecret names the secret of db, with its DB_NAME, HOSTS, USERNAME and PASSWORD, that jobs read
+	DatabaseSecret func(db D) string
+
+	// ArtifactExt is the extension of dumps, e.g. archive.gz
+	ArtifactExt string
+	// SetupScript runs ahead of dump, and restore scripts, e.g. to derive connection settings from HOSTS
+	SetupScript string
+	// DumpScript writes a dump of DB_NAME to stdout
+	DumpScript string
+	// RestoreScript reads a dump from stdin, into DB_NAME. SOURCE_DB_NAME is the database, that was backed up
+	RestoreScript string
+
+	// JobImage runs backup, restore, and cleanup jobs. It needs bash, the aws cli, and tools, that the scripts use
+	JobImage string
+	// CredentialsNamespaces are namespaces, besides their own, that backups, and restores may read s3 credentials from
+	CredentialsNamespaces   []string
+	MaxConcurrentReconciles int
+}
+
+// Reconcilers are controllers of backups, backup schedules, and restores, with names prefixed by prefix
+func (e *Engine[S, B, R, D]) Reconcilers(prefix string) []rApi.Reconciler {
+	return []rApi.Reconciler{
+		&backupReconciler[S, B, R, D]{name: prefix + "backup", engine: e},
+		&scheduleReconciler[S, B, R, D]{name: prefix + "backup-schedule", engine: e},
+		&restoreReconciler[S, B, R, D]{name: prefix + "restore", engine: e},
+	}
+}
+
+func (e *Engine[S, B, R, D]) script(s string) string {
+	if e.SetupScript == "" {
+		return s
+	}
+	return e.SetupScript + "\n" + s
+}
+
+// listBackups lists backups, that schedule has created in namespace
+func (e *Engine[S, B, R, D]) listBackups(ctx context.Context, cli client.Client, namespace string, schedule string) ([]B, error) {
+	list := e.NewBackupList()
+	if err := cli.List(ctx, list, client.InNamespace(namespace), client.MatchingLabels{LabelBackupSchedule: schedule}); err != nil {
+		return nil, err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]B, 0, len(items))
+	for i := range items {
+		b, ok := items[i].(B)
+		if !ok {
+			return nil, fmt.Errorf("unexpected item %T, in %T", items[i], list)
+		}
+		backups = append(backups, b)
+	}
+	return backups, nil
+}
+
+// completed are snapshots of backups, that have completed
+func completed[B BackupObject](backups []B) []Snapshot {
+	snapshots := make([]Snapshot, 0, len(backups))
+	for _, b := range backups {
+		if out := b.GetBackupOutput(); out.CompletedAt != nil {
+			snapshots = append(snapshots, Snapshot{Name: b.GetName(), CompletedAt: out.CompletedAt.Time})
+		}
+	}
+	return snapshots
+}
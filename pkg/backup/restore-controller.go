@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+const runRestore string = "run-restore"
+
+var restoreChecklist = []rApi.CheckMeta{{Name: runRestore, Title: "Restoring Backup"}}
+
+type restoreReconciler[S ScheduleObject, B BackupObject, R RestoreObject, D DatabaseObject] struct {
+	client.Client
+	logger logging.Logger
+	name   string
+	engine *Engine[S, B, R, D]
+}
+
+func (r *restoreReconciler[S, B, R, D]) GetName() string {
+	return r.name
+}
+
+func (r *restoreReconciler[S, B, R, D]) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, r.engine.NewRestore())
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	req.PreReconcile()
+	defer req.PostReconcile()
+
+	if req.Object.GetDeletionTimestamp() != nil {
+		// restored database is left as is
+		return req.Finalize().ReconcilerResponse()
+	}
+
+	if step := req.ClearStatusIfAnnotated(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureCheckList(restoreChecklist); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureLabelsAndAnnotations(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureFinalizers(constants.CommonFinalizer); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.runRestore(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	req.Object.GetStatus().IsReady = true
+	return ctrl.Result{}, nil
+}
+
+// resolveBackup finds the backup to restore, either by its name, or as the latest one of a schedule, completed before spec.restoreBefore
+func (r *restoreReconciler[S, B, R, D]) resolveBackup(ctx context.Context, obj R) (B, error) {
+	var zero B
+	spec := obj.GetRestoreSpec()
+
+	name := spec.BackupName
+	if name == "" {
+		if spec.BackupScheduleName == "" || spec.RestoreBefore == nil {
+			return zero, fmt.Errorf("either .spec.backupName, or .spec.backupScheduleName along with .spec.restoreBefore, must be set")
+		}
+
+		backups, err := r.engine.listBackups(ctx, r.Client, obj.GetNamespace(), spec.BackupScheduleName)
+		if err != nil {
+			return zero, err
+		}
+
+		if name, err = LatestBefore(completed(backups), spec.RestoreBefore.Time); err != nil {
+			return zero, err
+		}
+	}
+
+	b, err := rApi.Get(ctx, r.Client, fn.NN(obj.GetNamespace(), name), r.engine.NewBackup())
+	if err != nil {
+		return zero, err
+	}
+	if out := b.GetBackupOutput(); out.CompletedAt == nil || out.MsvcRef == nil {
+		return zero, fmt.Errorf("backup %s has not completed yet", b.GetName())
+	}
+	return b, nil
+}
+
+// ensureDatabase creates the database to restore into, on the managed service of the backup, when it does not exist
+func (r *restoreReconciler[S, B, R, D]) ensureDatabase(ctx context.Context, obj R, b B) (D, error) {
+	name := obj.GetRestoreSpec().DatabaseName
+
+	db, err := rApi.Get(ctx, r.Client, fn.NN(obj.GetNamespace(), name), r.engine.NewDatabase())
+	if err == nil {
+		return db, nil
+	}
+	if !apiErrors.IsNotFound(err) {
+		return db, err
+	}
+
+	db = r.engine.NewRestoreTarget(name, obj.GetNamespace(), *b.GetBackupOutput().MsvcRef)
+	if err := r.Create(ctx, db); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return db, err
+	}
+	return db, nil
+}
+
+func (r *restoreReconciler[S, B, R, D]) runRestore(req *rApi.Request[R]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(runRestore, req)
+
+	jobName := obj.GetName() + "-restore"
+
+	// once the job is created, it carries all it needs, and the backup might as well get pruned
+	job, err := rApi.Get(ctx, r.Client, fn.NN(obj.GetNamespace(), jobName), &batchv1.Job{})
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return check.StillRunning(err)
+		}
+
+		if r.engine.JobImage == "" {
+			return check.Failed(fmt.Errorf("env var BACKUP_JOB_IMAGE is not set, restores can not be run")).Err(nil)
+		}
+
+		b, err := r.resolveBackup(ctx, obj)
+		if err != nil {
+			return check.Failed(err).Err(nil).RequeueAfter(30 * time.Second)
+		}
+
+		db, err := r.ensureDatabase(ctx, obj, b)
+		if err != nil {
+			return check.StillRunning(err)
+		}
+		if !db.GetStatus().IsReady {
+			return check.StillRunning(fmt.Errorf("waiting for database %s to be ready", db.GetName())).Err(nil).RequeueAfter(10 * time.Second)
+		}
+
+		target := b.GetBackupSpec().Target
+		s3Secret, err := EnsureCredentials(ctx, r.Client, obj, target, r.engine.CredentialsNamespaces)
+		if err != nil {
+			if errors.Is(err, ErrCredentialsNotAllowed) {
+				return check.Failed(err).Err(nil)
+			}
+			return check.StillRunning(err)
+		}
+
+		job = NewJob(JobArgs{
+			Name:           jobName,
+			Namespace:      obj.GetNamespace(),
+			Image:          r.engine.JobImage,
+			Script:         r.engine.script(DownloadScript(r.engine.RestoreScript)),
+			Env:            []corev1.EnvVar{{Name: "SOURCE_DB_NAME", Value: b.GetBackupOutput().DbName}},
+			DatabaseSecret: r.engine.DatabaseSecret(db),
+			S3Secret:       s3Secret,
+			Target:         target,
+			ArtifactURL:    b.GetBackupOutput().ArtifactURL,
+			Owner:          obj,
+		})
+	}
+
+	done, err := RunJob(ctx, r.Client, job)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+	if !done {
+		return check.StillRunning(fmt.Errorf("waiting for restore job to complete")).Err(nil).RequeueAfter(10 * time.Second)
+	}
+
+	return check.Completed()
+}
+
+func (r *restoreReconciler[S, B, R, D]) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) error {
+	r.Client = mgr.GetClient()
+	r.logger = logger.WithName(r.name)
+
+	builder := ctrl.NewControllerManagedBy(mgr).For(r.engine.NewRestore())
+	builder.Owns(&batchv1.Job{})
+	builder.WithEventFilter(rApi.ReconcileFilter())
+	builder.WithOptions(controller.Options{MaxConcurrentReconciles: r.engine.MaxConcurrentReconciles})
+	return builder.Complete(r)
+}
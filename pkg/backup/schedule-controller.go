@@ -0,0 +1,176 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	scheduleBackups string = "schedule-backups"
+	pruneBackups    string = "prune-backups"
+)
+
+var scheduleChecklist = []rApi.CheckMeta{
+	{Name: scheduleBackups, Title: "Scheduling Backups"},
+	{Name: pruneBackups, Title: "Pruning Backups"},
+}
+
+type scheduleReconciler[S ScheduleObject, B BackupObject, R RestoreObject, D DatabaseObject] struct {
+	client.Client
+	logger logging.Logger
+	name   string
+	engine *Engine[S, B, R, D]
+}
+
+func (r *scheduleReconciler[S, B, R, D]) GetName() string {
+	return r.name
+}
+
+func (r *scheduleReconciler[S, B, R, D]) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	req, err := rApi.NewRequest(rApi.NewReconcilerCtx(ctx, r.logger, r.GetName()), r.Client, request.NamespacedName, r.engine.NewSchedule())
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	req.PreReconcile()
+	defer req.PostReconcile()
+
+	if req.Object.GetDeletionTimestamp() != nil {
+		// backups outlive their schedule, so that they can still be restored
+		return req.Finalize().ReconcilerResponse()
+	}
+
+	if step := req.ClearStatusIfAnnotated(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureCheckList(scheduleChecklist); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureLabelsAndAnnotations(); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := req.EnsureFinalizers(constants.CommonFinalizer); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.scheduleBackups(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	if step := r.pruneBackups(req); !step.ShouldProceed() {
+		return step.ReconcilerResponse()
+	}
+
+	req.Object.GetStatus().IsReady = true
+
+	spec := req.Object.GetScheduleSpec()
+	if spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+	next, err := NextRun(spec.Schedule, time.Now())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: time.Until(next)}, nil
+}
+
+// scheduleBackups creates a backup, once the schedule has fired since the last one. Runs missed, while the operator was down, are not
+// made up for
+func (r *scheduleReconciler[S, B, R, D]) scheduleBackups(req *rApi.Request[S]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(scheduleBackups, req)
+
+	spec := obj.GetScheduleSpec()
+	if spec.Suspend {
+		return check.Completed()
+	}
+
+	backups, err := r.engine.listBackups(ctx, r.Client, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	last := obj.GetCreationTimestamp().Time
+	for _, b := range backups {
+		if created := b.GetCreationTimestamp(); created.After(last) {
+			last = created.Time
+		}
+	}
+
+	next, err := NextRun(spec.Schedule, last)
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+	if next.After(time.Now()) {
+		return check.Completed()
+	}
+
+	b := r.engine.NewBackup()
+	b.SetName(fmt.Sprintf("%s-%d", obj.GetName(), next.Unix()))
+	b.SetNamespace(obj.GetNamespace())
+	b.SetLabels(map[string]string{LabelBackupSchedule: obj.GetName()})
+	*b.GetBackupSpec() = spec.BackupSpec()
+	if err := r.Create(ctx, b); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return check.StillRunning(err)
+	}
+
+	return check.Completed()
+}
+
+// pruneBackups deletes completed backups, beyond retention of the schedule, and with them, their artifacts
+func (r *scheduleReconciler[S, B, R, D]) pruneBackups(req *rApi.Request[S]) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(pruneBackups, req)
+
+	backups, err := r.engine.listBackups(ctx, r.Client, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		return check.StillRunning(err)
+	}
+
+	for _, name := range Expired(completed(backups), obj.GetScheduleSpec().Retention.KeepLast) {
+		b := r.engine.NewBackup()
+		b.SetName(name)
+		b.SetNamespace(obj.GetNamespace())
+		if err := r.Delete(ctx, b); client.IgnoreNotFound(err) != nil {
+			return check.StillRunning(err)
+		}
+	}
+
+	return check.Completed()
+}
+
+func (r *scheduleReconciler[S, B, R, D]) SetupWithManager(mgr ctrl.Manager, logger logging.Logger) error {
+	r.Client = mgr.GetClient()
+	r.logger = logger.WithName(r.name)
+
+	builder := ctrl.NewControllerManagedBy(mgr).For(r.engine.NewSchedule())
+	builder.Watches(
+		r.engine.NewBackup(),
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			name, ok := obj.GetLabels()[LabelBackupSchedule]
+			if !ok {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: fn.NN(obj.GetNamespace(), name)}}
+		}),
+	)
+	builder.WithEventFilter(rApi.ReconcileFilter())
+	builder.WithOptions(controller.Options{MaxConcurrentReconciles: r.engine.MaxConcurrentReconciles})
+	return builder.Complete(r)
+}
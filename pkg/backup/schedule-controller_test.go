@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	mongodbMsvcv1 "github.com/kloudlite/operator/apis/mongodb.msvc/v1"
+	"github.com/kloudlite/operator/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScheduleReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, mongodbMsvcv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	schedule := &mongodbMsvcv1.BackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "db", CreationTimestamp: metav1.Time{Time: now.Add(-48 * time.Hour)}},
+		Spec: ct.BackupScheduleSpec{
+			DatabaseName: "app",
+			Schedule:     "0 2 * * *",
+			Retention:    ct.BackupRetention{KeepLast: 1},
+		},
+	}
+
+	completedBackup := func(name string, at time.Time) *mongodbMsvcv1.Backup {
+		return &mongodbMsvcv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db", Labels: map[string]string{LabelBackupSchedule: "nightly"}},
+			Output:     ct.BackupOutput{CompletedAt: &metav1.Time{Time: at}},
+		}
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(schedule, completedBackup("older", now.Add(-72*time.Hour)), completedBackup("newer", now.Add(-24*time.Hour))).
+		WithStatusSubresource(&mongodbMsvcv1.BackupSchedule{}, &mongodbMsvcv1.Backup{}).
+		Build()
+
+	engine := &Engine[*mongodbMsvcv1.BackupSchedule, *mongodbMsvcv1.Backup, *mongodbMsvcv1.Restore, *mongodbMsvcv1.Database]{
+		NewSchedule:   func() *mongodbMsvcv1.BackupSchedule { return &mongodbMsvcv1.BackupSchedule{} },
+		NewBackup:     func() *mongodbMsvcv1.Backup { return &mongodbMsvcv1.Backup{} },
+		NewBackupList: func() client.ObjectList { return &mongodbMsvcv1.BackupList{} },
+	}
+	logger, err := logging.New(&logging.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &scheduleReconciler[*mongodbMsvcv1.BackupSchedule, *mongodbMsvcv1.Backup, *mongodbMsvcv1.Restore, *mongodbMsvcv1.Database]{
+		Client: cli,
+		logger: logger,
+		name:   "backup-schedule",
+		engine: engine,
+	}
+
+	ctx := context.TODO()
+	request := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(schedule)}
+
+	// first passes only set up checklist, labels, and finalizers
+	var result ctrl.Result
+	for i := 0; i < 5; i++ {
+		if result, err = r.Reconcile(ctx, request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > 24*time.Hour {
+		t.Fatalf("Reconcile() requeues after %s, want the next run, within a day", result.RequeueAfter)
+	}
+
+	var backups mongodbMsvcv1.BackupList
+	if err := cli.List(ctx, &backups, client.InNamespace("db")); err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	for _, b := range backups.Items {
+		names[b.Name] = true
+		if b.Name != "older" && b.Name != "newer" {
+			if b.Labels[LabelBackupSchedule] != "nightly" || b.Spec.DatabaseName != "app" {
+				t.Fatalf("scheduled backup %s has labels %v, and database %q", b.Name, b.Labels, b.Spec.DatabaseName)
+			}
+		}
+	}
+
+	if len(backups.Items) != 2 || !names["newer"] || names["older"] {
+		t.Fatalf("backups = %v, want newer, and a scheduled one, with older pruned", names)
+	}
+}
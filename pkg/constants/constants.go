@@ -99,6 +99,15 @@ const (
 	CsiDriverNameKey             string = "kloudlite.io/csi-driver.name"
 	ClusterManagedServiceNameKey string = "kloudlite.io/cluster-msvc.name"
 
+	// lists domains of a router, that it has published dns records for, so that they are released, once removed from the router
+	RouterDNSRecordsKey string = "kloudlite.io/router.dns-records"
+	// marks ingresses, that a router splits its routes across
+	RouteIngressOfKey string = "kloudlite.io/route-ingress-of"
+	// marks configmaps, that routers generate for tcp, and udp services of ingress-nginx
+	RouterTCPUDPServicesKey string = "kloudlite.io/router.tcp-udp-services"
+	// marks secrets, that a controller copies into a namespace, e.g. tls secrets of routers
+	SecretClonedByKey string = "kloudlite.io/secret.cloned-by"
+
 	ProjectManagedServiceNameKey string = "kloudlite.io/project-msvc.name"
 	ProjectManagedServiceRefKey  string = "kloudlite.io/project-msvc-ref"
 
@@ -110,6 +119,15 @@ const (
 	RestartKey     string = "kloudlite.io/do-restart"
 	DoHelmUpgrade  string = "kloudlite.io/do-helm-upgrade"
 
+	RotateCredentialsKey string = "kloudlite.io/rotate-credentials"
+
+	AppRolloutPromoteKey  string = "kloudlite.io/rollout.promote"
+	AppRolloutAbortKey    string = "kloudlite.io/rollout.abort"
+	AppRolloutRevisionKey string = "kloudlite.io/rollout.revision"
+
+	// RFC3339 time, at which app activator last proxied a request to the app
+	AppLastRequestAtKey string = "kloudlite.io/app.last-request-at"
+
 	IsBluePrintKey    string = "kloudlite.io/is-blueprint"
 	MarkedAsBlueprint string = "kloudlite.io/marked-as-blueprint"
 
@@ -142,6 +160,7 @@ const (
 	CacheNameKey                      string = "kloudlite.io/cache-key"
 	BuildNameKey                      string = "kloudlite.io/build.name"
 	AnnotationResourceReady           string = "kloudlite.io/resource.ready"
+	AnnotationResourceChecks          string = "kloudlite.io/checks"
 	AnnotationReconcileScheduledAfter string = "kloudlite.io/reconcile.scheduled-after"
 )
 
@@ -0,0 +1,160 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ConflictError is returned, when a domain already has records, that were not published by the same owner
+type ConflictError struct {
+	Domain string
+	Owner  string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Owner == "" {
+		return fmt.Sprintf("domain %s already has records, that are not managed by kloudlite", e.Domain)
+	}
+	return fmt.Sprintf("domain %s is already owned by %s", e.Domain, e.Owner)
+}
+
+// ownerRecordName is where ownership of domain is recorded. Records of the domain itself can't carry it, as CNAMEs allow no other
+// records alongside them
+func ownerRecordName(domain string) string {
+	if strings.HasPrefix(domain, "*.") {
+		return "_kloudlite-owner-wildcard." + strings.TrimPrefix(domain, "*.")
+	}
+	return "_kloudlite-owner." + domain
+}
+
+func ownerValue(owner string) string {
+	return fmt.Sprintf("heritage=kloudlite,owner=%s", owner)
+}
+
+// addressRecords are record types, that a published domain points to its targets with
+var addressRecords = []RecordType{RecordTypeA, RecordTypeAAAA, RecordTypeCNAME}
+
+// targetRecords splits targets into A and AAAA records, for addresses, or a CNAME record, for a hostname. CNAME allows a single
+// hostname, and no other records, so the first hostname is used, only when there are no addresses
+func targetRecords(domain string, targets []string, ttl int64) []Record {
+	var ipv4, ipv6, hosts []string
+	for _, t := range targets {
+		ip := net.ParseIP(t)
+		switch {
+		case ip == nil:
+			hosts = append(hosts, strings.TrimSuffix(t, "."))
+		case ip.To4() != nil:
+			ipv4 = append(ipv4, t)
+		default:
+			ipv6 = append(ipv6, t)
+		}
+	}
+
+	var records []Record
+	if len(ipv4) > 0 {
+		records = append(records, Record{Name: domain, Type: RecordTypeA, Values: ipv4, TTL: ttl})
+	}
+	if len(ipv6) > 0 {
+		records = append(records, Record{Name: domain, Type: RecordTypeAAAA, Values: ipv6, TTL: ttl})
+	}
+	if len(records) == 0 && len(hosts) > 0 {
+		records = append(records, Record{Name: domain, Type: RecordTypeCNAME, Values: hosts[:1], TTL: ttl})
+	}
+	return records
+}
+
+// checkOwnership tells whether owner has claimed domain. It returns a ConflictError, if someone else has, or domain has records, nobody claimed
+func checkOwnership(ctx context.Context, p Provider, domain string, owner string) (bool, error) {
+	txt, err := p.GetRecord(ctx, ownerRecordName(domain), RecordTypeTXT)
+	if err != nil {
+		return false, err
+	}
+
+	if txt != nil {
+		for _, v := range txt.Values {
+			if v == ownerValue(owner) {
+				return true, nil
+			}
+		}
+		return false, &ConflictError{Domain: domain, Owner: strings.TrimPrefix(strings.Join(txt.Values, ","), "heritage=kloudlite,owner=")}
+	}
+
+	for _, t := range addressRecords {
+		record, err := p.GetRecord(ctx, domain, t)
+		if err != nil {
+			return false, err
+		}
+		if record != nil {
+			return false, &ConflictError{Domain: domain}
+		}
+	}
+	return false, nil
+}
+
+// Publish points domain to targets, and claims it for owner, with a TXT record. Domains, claimed by others, or having records of their own,
+// are left alone, with a ConflictError
+func Publish(ctx context.Context, p Provider, domain string, targets []string, owner string, ttl int64) error {
+	records := targetRecords(domain, targets, ttl)
+	if len(records) == 0 {
+		return fmt.Errorf("no targets to point domain %s to", domain)
+	}
+
+	owned, err := checkOwnership(ctx, p, domain, owner)
+	if err != nil {
+		return err
+	}
+
+	// ownership is claimed first, so that an interrupted publish is still recognized as ours
+	if !owned {
+		if err := p.UpsertRecord(ctx, Record{Name: ownerRecordName(domain), Type: RecordTypeTXT, Values: []string{ownerValue(owner)}, TTL: ttl}); err != nil {
+			return err
+		}
+	}
+
+	desired := make(map[RecordType]struct{}, len(records))
+	for _, record := range records {
+		desired[record.Type] = struct{}{}
+	}
+
+	// targets might have switched between addresses and a hostname
+	for _, t := range addressRecords {
+		if _, ok := desired[t]; ok {
+			continue
+		}
+		if err := p.DeleteRecord(ctx, domain, t); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		if err := p.UpsertRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release removes records of domain, along with its ownership, if owner had claimed it
+func Release(ctx context.Context, p Provider, domain string, owner string) error {
+	owned, err := checkOwnership(ctx, p, domain, owner)
+	if err != nil {
+		var conflict *ConflictError
+		if errors.As(err, &conflict) {
+			return nil
+		}
+		return err
+	}
+	if !owned {
+		return nil
+	}
+
+	for _, t := range addressRecords {
+		if err := p.DeleteRecord(ctx, domain, t); err != nil {
+			return err
+		}
+	}
+	return p.DeleteRecord(ctx, ownerRecordName(domain), RecordTypeTXT)
+}
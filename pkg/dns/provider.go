@@ -0,0 +1,42 @@
+package dns
+
+import (
+	"context"
+	"strings"
+)
+
+type RecordType string
+
+const (
+	RecordTypeA     RecordType = "A"
+	RecordTypeAAAA  RecordType = "AAAA"
+	RecordTypeCNAME RecordType = "CNAME"
+	RecordTypeTXT   RecordType = "TXT"
+)
+
+// Record is a record set, i.e. all values of one type, on one name. TXT values are unquoted
+type Record struct {
+	Name   string
+	Type   RecordType
+	Values []string
+	TTL    int64
+}
+
+// Provider manages records of a single DNS zone
+type Provider interface {
+	// Zone is the domain, that provider manages records under
+	Zone() string
+	// GetRecord returns nil, when name has no records of that type
+	GetRecord(ctx context.Context, name string, recordType RecordType) (*Record, error)
+	// UpsertRecord replaces all values of the record's name and type
+	UpsertRecord(ctx context.Context, record Record) error
+	// DeleteRecord removes all values of the name and type, if any
+	DeleteRecord(ctx context.Context, name string, recordType RecordType) error
+}
+
+// InZone tells whether domain lies under zone
+func InZone(zone string, domain string) bool {
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	return domain == zone || strings.HasSuffix(domain, "."+zone)
+}
@@ -0,0 +1,147 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	miekgdns "github.com/miekg/dns"
+)
+
+type RFC2136Config struct {
+	// Server is host:port of the primary name server of the zone
+	Server string
+	Zone   string
+
+	// TSIG key, that dynamic updates are signed with. Updates go unsigned, when TSIGKeyName is empty
+	TSIGKeyName   string
+	TSIGSecret    string
+	TSIGAlgorithm string
+}
+
+type rfc2136Provider struct {
+	cfg    RFC2136Config
+	client *miekgdns.Client
+}
+
+// NewRFC2136Provider manages records of a zone through dynamic updates (RFC 2136), as supported by bind, knot, powerdns and the like
+func NewRFC2136Provider(cfg RFC2136Config) (Provider, error) {
+	if cfg.Server == "" || cfg.Zone == "" {
+		return nil, fmt.Errorf("rfc2136 provider requires both, server and zone")
+	}
+	if cfg.TSIGKeyName != "" && cfg.TSIGSecret == "" {
+		return nil, fmt.Errorf("rfc2136 provider requires tsig secret, along with tsig key name")
+	}
+
+	cfg.Zone = strings.TrimSuffix(cfg.Zone, ".")
+	cfg.TSIGKeyName = miekgdns.Fqdn(cfg.TSIGKeyName)
+	if cfg.TSIGAlgorithm == "" {
+		cfg.TSIGAlgorithm = miekgdns.HmacSHA256
+	}
+	cfg.TSIGAlgorithm = miekgdns.Fqdn(cfg.TSIGAlgorithm)
+
+	client := &miekgdns.Client{Net: "tcp", Timeout: 10 * time.Second}
+	if cfg.TSIGSecret != "" {
+		client.TsigSecret = map[string]string{cfg.TSIGKeyName: cfg.TSIGSecret}
+	}
+
+	return &rfc2136Provider{cfg: cfg, client: client}, nil
+}
+
+func (p *rfc2136Provider) Zone() string {
+	return p.cfg.Zone
+}
+
+func (p *rfc2136Provider) exchange(ctx context.Context, m *miekgdns.Msg) (*miekgdns.Msg, error) {
+	if p.cfg.TSIGSecret != "" {
+		m.SetTsig(p.cfg.TSIGKeyName, p.cfg.TSIGAlgorithm, 300, time.Now().Unix())
+	}
+
+	resp, _, err := p.client.ExchangeContext(ctx, m, p.cfg.Server)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode != miekgdns.RcodeSuccess && resp.Rcode != miekgdns.RcodeNameError {
+		return nil, fmt.Errorf("name server %s responded with %s", p.cfg.Server, miekgdns.RcodeToString[resp.Rcode])
+	}
+	return resp, nil
+}
+
+func (p *rfc2136Provider) GetRecord(ctx context.Context, name string, recordType RecordType) (*Record, error) {
+	rrType, ok := miekgdns.StringToType[string(recordType)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %s", recordType)
+	}
+
+	m := new(miekgdns.Msg)
+	m.SetQuestion(miekgdns.Fqdn(name), rrType)
+	m.RecursionDesired = false
+
+	resp, err := p.exchange(ctx, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s record of %s: %w", recordType, name, err)
+	}
+
+	var record *Record
+	for _, rr := range resp.Answer {
+		hdr := rr.Header()
+		if hdr.Rrtype != rrType || !strings.EqualFold(hdr.Name, miekgdns.Fqdn(name)) {
+			continue
+		}
+		if record == nil {
+			record = &Record{Name: name, Type: recordType, TTL: int64(hdr.Ttl)}
+		}
+
+		switch v := rr.(type) {
+		case *miekgdns.A:
+			record.Values = append(record.Values, v.A.String())
+		case *miekgdns.AAAA:
+			record.Values = append(record.Values, v.AAAA.String())
+		case *miekgdns.CNAME:
+			record.Values = append(record.Values, strings.TrimSuffix(v.Target, "."))
+		case *miekgdns.TXT:
+			record.Values = append(record.Values, strings.Join(v.Txt, ""))
+		}
+	}
+	return record, nil
+}
+
+func (p *rfc2136Provider) UpsertRecord(ctx context.Context, record Record) error {
+	rrs := make([]miekgdns.RR, 0, len(record.Values))
+	for _, v := range record.Values {
+		switch record.Type {
+		case RecordTypeTXT:
+			v = strconv.Quote(v)
+		case RecordTypeCNAME:
+			v = miekgdns.Fqdn(v)
+		}
+		rr, err := miekgdns.NewRR(fmt.Sprintf("%s %d IN %s %s", miekgdns.Fqdn(record.Name), record.TTL, record.Type, v))
+		if err != nil {
+			return err
+		}
+		rrs = append(rrs, rr)
+	}
+
+	m := new(miekgdns.Msg)
+	m.SetUpdate(miekgdns.Fqdn(p.cfg.Zone))
+	m.RemoveRRset([]miekgdns.RR{&miekgdns.ANY{Hdr: miekgdns.RR_Header{Name: miekgdns.Fqdn(record.Name), Rrtype: miekgdns.StringToType[string(record.Type)]}}})
+	m.Insert(rrs)
+
+	if _, err := p.exchange(ctx, m); err != nil {
+		return fmt.Errorf("failed to upsert %s record of %s: %w", record.Type, record.Name, err)
+	}
+	return nil
+}
+
+func (p *rfc2136Provider) DeleteRecord(ctx context.Context, name string, recordType RecordType) error {
+	m := new(miekgdns.Msg)
+	m.SetUpdate(miekgdns.Fqdn(p.cfg.Zone))
+	m.RemoveRRset([]miekgdns.RR{&miekgdns.ANY{Hdr: miekgdns.RR_Header{Name: miekgdns.Fqdn(name), Rrtype: miekgdns.StringToType[string(recordType)]}}})
+
+	if _, err := p.exchange(ctx, m); err != nil {
+		return fmt.Errorf("failed to delete %s record of %s: %w", recordType, name, err)
+	}
+	return nil
+}
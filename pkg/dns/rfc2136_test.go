@@ -0,0 +1,229 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+
+	miekgdns "github.com/miekg/dns"
+)
+
+const (
+	testZone       = "example.test"
+	testTSIGKey    = "kloudlite."
+	testTSIGSecret = "c2VjcmV0LXVzZWQtaW4tdGVzdHMtb25seQ=="
+)
+
+// zoneServer is an authoritative name server, for a single zone, that accepts tsig signed dynamic updates
+type zoneServer struct {
+	mu      sync.Mutex
+	records map[string][]miekgdns.RR
+}
+
+func rrsetKey(name string, rrType uint16) string {
+	return miekgdns.CanonicalName(name) + "/" + miekgdns.TypeToString[rrType]
+}
+
+func (s *zoneServer) ServeDNS(w miekgdns.ResponseWriter, r *miekgdns.Msg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := new(miekgdns.Msg)
+	m.SetReply(r)
+
+	switch r.Opcode {
+	case miekgdns.OpcodeUpdate:
+		if r.IsTsig() == nil || w.TsigStatus() != nil {
+			m.SetRcode(r, miekgdns.RcodeRefused)
+			break
+		}
+		for _, rr := range r.Ns {
+			hdr := rr.Header()
+			key := rrsetKey(hdr.Name, hdr.Rrtype)
+			switch hdr.Class {
+			case miekgdns.ClassANY:
+				delete(s.records, key)
+			case miekgdns.ClassINET:
+				s.records[key] = append(s.records[key], rr)
+			}
+		}
+	case miekgdns.OpcodeQuery:
+		m.Authoritative = true
+		q := r.Question[0]
+		m.Answer = append(m.Answer, s.records[rrsetKey(q.Name, q.Qtype)]...)
+		if len(m.Answer) == 0 {
+			m.SetRcode(r, miekgdns.RcodeNameError)
+		}
+	}
+
+	if r.IsTsig() != nil && w.TsigStatus() == nil {
+		m.SetTsig(testTSIGKey, miekgdns.HmacSHA256, 300, int64(r.IsTsig().TimeSigned))
+	}
+	_ = w.WriteMsg(m)
+}
+
+func startZoneServer(t *testing.T) (*zoneServer, string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zs := &zoneServer{records: map[string][]miekgdns.RR{}}
+	started := make(chan struct{})
+	srv := &miekgdns.Server{
+		Listener:          l,
+		Net:               "tcp",
+		Handler:           zs,
+		TsigSecret:        map[string]string{testTSIGKey: testTSIGSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// default accept func rejects dynamic updates
+		MsgAcceptFunc: func(miekgdns.Header) miekgdns.MsgAcceptAction { return miekgdns.MsgAccept },
+	}
+	go func() {
+		_ = srv.ActivateAndServe()
+	}()
+	<-started
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	return zs, l.Addr().String()
+}
+
+func newTestProvider(t *testing.T, addr string, tsigKeyName string, tsigSecret string) Provider {
+	t.Helper()
+	p, err := NewRFC2136Provider(RFC2136Config{Server: addr, Zone: testZone, TSIGKeyName: tsigKeyName, TSIGSecret: tsigSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRFC2136Provider(t *testing.T) {
+	_, addr := startZoneServer(t)
+	p := newTestProvider(t, addr, testTSIGKey, testTSIGSecret)
+	ctx := context.TODO()
+
+	if err := p.UpsertRecord(ctx, Record{Name: "app.example.test", Type: RecordTypeA, Values: []string{"10.0.0.1", "10.0.0.2"}, TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpsertRecord(ctx, Record{Name: "app.example.test", Type: RecordTypeA, Values: []string{"10.0.0.3"}, TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.GetRecord(ctx, "app.example.test", RecordTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Record{Name: "app.example.test", Type: RecordTypeA, Values: []string{"10.0.0.3"}, TTL: 60}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetRecord() = %+v, want %+v", got, want)
+	}
+
+	if err := p.UpsertRecord(ctx, Record{Name: "app.example.test", Type: RecordTypeTXT, Values: []string{"a=b,c=d"}, TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	txt, err := p.GetRecord(ctx, "app.example.test", RecordTypeTXT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txt == nil || !reflect.DeepEqual(txt.Values, []string{"a=b,c=d"}) {
+		t.Fatalf("GetRecord(TXT) = %+v, want value a=b,c=d", txt)
+	}
+
+	if err := p.DeleteRecord(ctx, "app.example.test", RecordTypeA); err != nil {
+		t.Fatal(err)
+	}
+	got, err = p.GetRecord(ctx, "app.example.test", RecordTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("GetRecord() = %+v, after deleting it", got)
+	}
+
+	unsigned := newTestProvider(t, addr, "", "")
+	if err := unsigned.UpsertRecord(ctx, Record{Name: "app.example.test", Type: RecordTypeA, Values: []string{"10.0.0.1"}, TTL: 60}); err == nil {
+		t.Fatalf("UpsertRecord() succeeded, without tsig")
+	}
+}
+
+func TestPublishAndRelease(t *testing.T) {
+	zs, addr := startZoneServer(t)
+	p := newTestProvider(t, addr, testTSIGKey, testTSIGSecret)
+	ctx := context.TODO()
+
+	if err := Publish(ctx, p, "app.example.test", []string{"10.0.0.1"}, "cluster-a/ns/router", 60); err != nil {
+		t.Fatal(err)
+	}
+
+	// republishing, with a hostname, switches the A record for a CNAME
+	if err := Publish(ctx, p, "app.example.test", []string{"lb.example.net"}, "cluster-a/ns/router", 60); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := p.GetRecord(ctx, "app.example.test", RecordTypeA); a != nil {
+		t.Fatalf("A record %+v lingers, after switching to a hostname", a)
+	}
+	cname, err := p.GetRecord(ctx, "app.example.test", RecordTypeCNAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cname == nil || !reflect.DeepEqual(cname.Values, []string{"lb.example.net"}) {
+		t.Fatalf("GetRecord(CNAME) = %+v, want lb.example.net", cname)
+	}
+
+	var conflict *ConflictError
+	err = Publish(ctx, p, "app.example.test", []string{"10.0.0.2"}, "cluster-b/ns/router", 60)
+	if !errors.As(err, &conflict) || conflict.Owner != "cluster-a/ns/router" {
+		t.Fatalf("Publish() by another owner = %v, want a conflict with cluster-a/ns/router", err)
+	}
+
+	// releasing someone else's domain is a no-op
+	if err := Release(ctx, p, "app.example.test", "cluster-b/ns/router"); err != nil {
+		t.Fatal(err)
+	}
+	if cname, _ := p.GetRecord(ctx, "app.example.test", RecordTypeCNAME); cname == nil {
+		t.Fatalf("CNAME record got released, by a foreign owner")
+	}
+
+	if err := Release(ctx, p, "app.example.test", "cluster-a/ns/router"); err != nil {
+		t.Fatal(err)
+	}
+	zs.mu.Lock()
+	if len(zs.records) != 0 {
+		t.Fatalf("records %v remain, after release", zs.records)
+	}
+	zs.mu.Unlock()
+
+	// records, not published by kloudlite, are never taken over
+	if err := p.UpsertRecord(ctx, Record{Name: "*.example.test", Type: RecordTypeA, Values: []string{"10.0.0.9"}, TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	err = Publish(ctx, p, "*.example.test", []string{"10.0.0.1"}, "cluster-a/ns/router", 60)
+	if !errors.As(err, &conflict) || conflict.Owner != "" {
+		t.Fatalf("Publish() over unmanaged records = %v, want a conflict", err)
+	}
+}
+
+func TestInZone(t *testing.T) {
+	tests := []struct {
+		zone   string
+		domain string
+		want   bool
+	}{
+		{zone: "example.test", domain: "example.test", want: true},
+		{zone: "example.test.", domain: "App.Example.Test", want: true},
+		{zone: "example.test", domain: "*.dev.example.test", want: true},
+		{zone: "example.test", domain: "badexample.test", want: false},
+		{zone: "example.test", domain: "example.org", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := InZone(tt.zone, tt.domain); got != tt.want {
+			t.Errorf("InZone(%q, %q) = %v, want %v", tt.zone, tt.domain, got, tt.want)
+		}
+	}
+}
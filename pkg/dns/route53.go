@@ -0,0 +1,79 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kloudlite/operator/pkg/aws"
+)
+
+type route53Provider struct {
+	client     aws.Route53
+	hostedZone string
+}
+
+// NewRoute53Provider manages records of an aws route53 hosted zone, named after the domain it serves
+func NewRoute53Provider(accessKey string, secretKey string, hostedZone string) (Provider, error) {
+	client, err := aws.NewAwsRoute53Client(accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &route53Provider{client: client, hostedZone: strings.TrimSuffix(hostedZone, ".")}, nil
+}
+
+func (p *route53Provider) Zone() string {
+	return p.hostedZone
+}
+
+func (p *route53Provider) GetRecord(_ context.Context, name string, recordType RecordType) (*Record, error) {
+	rSet, err := p.client.GetRecordSet(name, string(recordType), p.hostedZone)
+	if err != nil {
+		return nil, err
+	}
+	if rSet == nil {
+		return nil, nil
+	}
+
+	record := &Record{Name: name, Type: recordType}
+	if rSet.TTL != nil {
+		record.TTL = *rSet.TTL
+	}
+	for _, rr := range rSet.ResourceRecords {
+		if rr.Value == nil {
+			continue
+		}
+		v := *rr.Value
+		if recordType == RecordTypeTXT {
+			if uq, err := strconv.Unquote(v); err == nil {
+				v = uq
+			}
+		}
+		record.Values = append(record.Values, strings.TrimSuffix(v, "."))
+	}
+	return record, nil
+}
+
+func (p *route53Provider) UpsertRecord(_ context.Context, record Record) error {
+	values := make([]string, 0, len(record.Values))
+	for _, v := range record.Values {
+		if record.Type == RecordTypeTXT {
+			// route53 requires TXT values to be quoted
+			v = strconv.Quote(v)
+		}
+		values = append(values, v)
+	}
+
+	if err := p.client.UpsertRecordSet(record.Name, string(record.Type), values, record.TTL, p.hostedZone); err != nil {
+		return fmt.Errorf("failed to upsert %s record of %s: %w", record.Type, record.Name, err)
+	}
+	return nil
+}
+
+func (p *route53Provider) DeleteRecord(_ context.Context, name string, recordType RecordType) error {
+	if err := p.client.DeleteRecordSet(name, string(recordType), p.hostedZone); err != nil {
+		return fmt.Errorf("failed to delete %s record of %s: %w", recordType, name, err)
+	}
+	return nil
+}
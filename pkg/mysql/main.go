@@ -88,6 +88,30 @@ func (c *Client) DropUser(username string) error {
 	return nil
 }
 
+// RotateUserPassword sets a new password for username, while the current one keeps working, until DiscardOldPassword
+func (c *Client) RotateUserPassword(username, password string) error {
+	if err := c.Connect(context.Background()); err != nil {
+		return err
+	}
+	_, err := c.conn.ExecContext(c.ctx, fmt.Sprintf("ALTER USER '%s'@'%%' IDENTIFIED BY '%s' RETAIN CURRENT PASSWORD", username, password))
+	if err != nil {
+		return errors.NewEf(err, "rotating user password")
+	}
+	return nil
+}
+
+// DiscardOldPassword revokes the password, that username had, prior to RotateUserPassword
+func (c *Client) DiscardOldPassword(username string) error {
+	if err := c.Connect(context.Background()); err != nil {
+		return err
+	}
+	_, err := c.conn.ExecContext(c.ctx, fmt.Sprintf("ALTER USER '%s'@'%%' DISCARD OLD PASSWORD", username))
+	if err != nil {
+		return errors.NewEf(err, "discarding old user password")
+	}
+	return nil
+}
+
 func (c *Client) DropDatabase(dbName string) error {
 	_, err := c.conn.ExecContext(c.ctx, fmt.Sprintf("DROP DATABASE %s", c.sanitizeDbName(dbName)))
 	if err != nil {
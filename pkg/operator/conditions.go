@@ -0,0 +1,140 @@
+package operator
+
+import (
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fn "github.com/kloudlite/operator/pkg/functions"
+)
+
+// ConditionReady mirrors Status.IsReady, so that tools like `kubectl wait --for=condition=Ready` work with every kloudlite resource
+const ConditionReady = "Ready"
+
+// metav1.Condition.Message is limited to 32768 characters, by its validation schema
+const conditionMessageMaxLen = 32768
+
+var conditionReasons = map[State]string{
+	WaitingState:   "Waiting",
+	RunningState:   "Running",
+	ErroredState:   "Errored",
+	CompletedState: "Completed",
+}
+
+// ConditionTypeForCheck converts a check name like `deployment-ready` into condition type `DeploymentReady`
+func ConditionTypeForCheck(checkName string) string {
+	parts := strings.FieldsFunc(checkName, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+
+	sb := strings.Builder{}
+	for _, p := range parts {
+		sb.WriteString(strings.ToUpper(p[:1]))
+		sb.WriteString(p[1:])
+	}
+	return sb.String()
+}
+
+func conditionFromCheck(checkName string, check Check, generation int64) metav1.Condition {
+	status := metav1.ConditionUnknown
+	switch {
+	case check.Status:
+		status = metav1.ConditionTrue
+	case check.State == ErroredState:
+		status = metav1.ConditionFalse
+	}
+
+	reason, ok := conditionReasons[check.State]
+	if !ok {
+		reason = conditionReasons[WaitingState]
+	}
+	if check.Status {
+		reason = conditionReasons[CompletedState]
+	}
+
+	observedGeneration := check.Generation
+	if observedGeneration == 0 {
+		observedGeneration = generation
+	}
+
+	return metav1.Condition{
+		Type:               ConditionTypeForCheck(checkName),
+		Status:             status,
+		ObservedGeneration: observedGeneration,
+		Reason:             reason,
+		Message:            truncateConditionMessage(check.Message),
+	}
+}
+
+func truncateConditionMessage(msg string) string {
+	if len(msg) > conditionMessageMaxLen {
+		return msg[:conditionMessageMaxLen]
+	}
+	return msg
+}
+
+// orderedCheckNames returns checks in the order of CheckList, followed by the ones not present in CheckList, sorted by name
+func orderedCheckNames(status *Status) []string {
+	names := make([]string, 0, len(status.Checks))
+	seen := make(map[string]struct{}, len(status.CheckList))
+	for _, cm := range status.CheckList {
+		if _, ok := status.Checks[cm.Name]; ok {
+			names = append(names, cm.Name)
+			seen[cm.Name] = struct{}{}
+		}
+	}
+
+	rest := fn.MapKeys(status.Checks)
+	slices.Sort(rest)
+	for _, name := range rest {
+		if _, ok := seen[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SyncConditions derives status.Conditions from status.Checks and status.IsReady.
+// Transition times only change, when a condition's status changes.
+func SyncConditions(status *Status, generation int64) {
+	names := orderedCheckNames(status)
+
+	wanted := make(map[string]struct{}, len(names)+1)
+	wanted[ConditionReady] = struct{}{}
+
+	ready := metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             conditionReasons[CompletedState],
+	}
+
+	if !status.IsReady {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = conditionReasons[WaitingState]
+	}
+
+	failingCheckFound := false
+	for _, name := range names {
+		check := status.Checks[name]
+		cond := conditionFromCheck(name, check, generation)
+		wanted[cond.Type] = struct{}{}
+		meta.SetStatusCondition(&status.Conditions, cond)
+
+		if !status.IsReady && !check.Status && !failingCheckFound {
+			failingCheckFound = true
+			ready.Reason = cond.Reason
+			ready.Message = truncateConditionMessage(check.Message)
+		}
+	}
+
+	meta.SetStatusCondition(&status.Conditions, ready)
+
+	for _, c := range slices.Clone(status.Conditions) {
+		if _, ok := wanted[c.Type]; !ok {
+			meta.RemoveStatusCondition(&status.Conditions, c.Type)
+		}
+	}
+}
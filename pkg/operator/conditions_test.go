@@ -0,0 +1,95 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConditionTypeForCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "deployment-ready", want: "DeploymentReady"},
+		{name: "patch_defaults", want: "PatchDefaults"},
+		{name: "cleanupLogic", want: "CleanupLogic"},
+		{name: "ready", want: "Ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConditionTypeForCheck(tt.name); got != tt.want {
+				t.Errorf("ConditionTypeForCheck() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncConditions(t *testing.T) {
+	status := &Status{
+		CheckList: []CheckMeta{{Name: "created"}, {Name: "deployment-ready"}},
+		Checks: map[string]Check{
+			"created":          {Status: true, State: CompletedState, Generation: 2},
+			"deployment-ready": {Status: false, State: ErroredState, Message: "pods crashlooping"},
+			"stale-check":      {State: WaitingState},
+		},
+		Conditions: []metav1.Condition{{Type: "Removed", Status: metav1.ConditionTrue, Reason: "Completed"}},
+	}
+
+	SyncConditions(status, 3)
+
+	wantTypes := []string{"Created", "DeploymentReady", "StaleCheck", ConditionReady}
+	if len(status.Conditions) != len(wantTypes) {
+		t.Fatalf("got %d conditions, want %d", len(status.Conditions), len(wantTypes))
+	}
+	for i := range wantTypes {
+		if status.Conditions[i].Type != wantTypes[i] {
+			t.Errorf("conditions[%d].Type = %s, want %s", i, status.Conditions[i].Type, wantTypes[i])
+		}
+	}
+
+	created := meta.FindStatusCondition(status.Conditions, "Created")
+	if created.Status != metav1.ConditionTrue || created.Reason != "Completed" || created.ObservedGeneration != 2 {
+		t.Errorf("unexpected Created condition: %+v", created)
+	}
+
+	dr := meta.FindStatusCondition(status.Conditions, "DeploymentReady")
+	if dr.Status != metav1.ConditionFalse || dr.Reason != "Errored" || dr.ObservedGeneration != 3 {
+		t.Errorf("unexpected DeploymentReady condition: %+v", dr)
+	}
+
+	if c := meta.FindStatusCondition(status.Conditions, "StaleCheck"); c.Status != metav1.ConditionUnknown || c.Reason != "Waiting" {
+		t.Errorf("unexpected StaleCheck condition: %+v", c)
+	}
+
+	ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+	if ready.Status != metav1.ConditionFalse || ready.Message != "pods crashlooping" || ready.Reason != "Errored" {
+		t.Errorf("unexpected Ready condition: %+v", ready)
+	}
+
+	// transition time must only change, when condition status changes
+	transitionedAt := metav1.NewTime(time.Now().Add(-1 * time.Hour))
+	for i := range status.Conditions {
+		status.Conditions[i].LastTransitionTime = transitionedAt
+	}
+
+	status.IsReady = true
+	status.Checks["deployment-ready"] = Check{Status: true, State: CompletedState, Generation: 3}
+	delete(status.Checks, "stale-check")
+	SyncConditions(status, 3)
+
+	if c := meta.FindStatusCondition(status.Conditions, "Created"); !c.LastTransitionTime.Equal(&transitionedAt) {
+		t.Errorf("Created condition transition time should not have changed")
+	}
+
+	if c := meta.FindStatusCondition(status.Conditions, ConditionReady); c.Status != metav1.ConditionTrue || c.LastTransitionTime.Equal(&transitionedAt) {
+		t.Errorf("unexpected Ready condition: %+v", c)
+	}
+
+	if meta.FindStatusCondition(status.Conditions, "StaleCheck") != nil {
+		t.Errorf("condition for removed check should have been removed")
+	}
+}
@@ -17,8 +17,19 @@ func GetMessagesFromPods(pods ...corev1.Pod) []ContainerMessage {
 	cMsgs := make([]ContainerMessage, 0, len(pods))
 
 	for i := range pods {
-		for j := range pods[i].Status.ContainerStatuses {
-			st := pods[i].Status.ContainerStatuses[j]
+		// init containers, and sidecars among them, block pods from getting ready, just like regular containers
+		statuses := make([]corev1.ContainerStatus, 0, len(pods[i].Status.InitContainerStatuses)+len(pods[i].Status.ContainerStatuses))
+		for _, st := range pods[i].Status.InitContainerStatuses {
+			// init containers, that ran to completion, are not worth reporting
+			if st.State.Terminated != nil && st.State.Terminated.ExitCode == 0 {
+				continue
+			}
+			statuses = append(statuses, st)
+		}
+		statuses = append(statuses, pods[i].Status.ContainerStatuses...)
+
+		for j := range statuses {
+			st := statuses[j]
 			if st.State.Terminated != nil {
 				cMsgs = append(
 					cMsgs, ContainerMessage{
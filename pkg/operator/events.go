@@ -0,0 +1,95 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+type eventRecorderKey struct{}
+
+// WithEventRecorder hands recorder to every Request, created from ctx, or from contexts derived from it, to emit kubernetes events on
+// check state transitions. operator.Start passes the manager's recorder this way, into contexts, that controllers reconcile with
+func WithEventRecorder(ctx context.Context, recorder record.EventRecorder) context.Context {
+	return context.WithValue(ctx, eventRecorderKey{}, recorder)
+}
+
+func eventRecorderFrom(ctx context.Context) record.EventRecorder {
+	recorder, _ := ctx.Value(eventRecorderKey{}).(record.EventRecorder)
+	return recorder
+}
+
+var checkEventReasons = map[State]string{
+	WaitingState:   "CheckWaiting",
+	RunningState:   "CheckRunning",
+	ErroredState:   "CheckErrored",
+	CompletedState: "CheckCompleted",
+}
+
+var checkEventMessages = map[State]string{
+	WaitingState:   "is waiting to be reconciled",
+	RunningState:   "is running",
+	ErroredState:   "failed",
+	CompletedState: "completed",
+}
+
+type checkEvent struct {
+	EventType string
+	Reason    string
+	Message   string
+}
+
+// checkTransitionEvent returns an event only when a check moves into a new state, or fails with a different error,
+// so that repeated reconcilations of a stuck check do not flood the event stream
+func checkTransitionEvent(title string, prev Check, curr Check) (*checkEvent, bool) {
+	if curr.State == "" {
+		return nil, false
+	}
+
+	if prev.State == curr.State && (curr.State != ErroredState || prev.Message == curr.Message) {
+		return nil, false
+	}
+
+	ev := checkEvent{
+		EventType: corev1.EventTypeNormal,
+		Reason:    checkEventReasons[curr.State],
+		Message:   fmt.Sprintf("check %q %s", title, checkEventMessages[curr.State]),
+	}
+
+	if curr.State == ErroredState {
+		ev.EventType = corev1.EventTypeWarning
+	}
+
+	if curr.Message != "" && curr.State != CompletedState {
+		ev.Message = fmt.Sprintf("%s: %s", ev.Message, curr.Message)
+	}
+
+	return &ev, true
+}
+
+func (r *Request[T]) checkTitle(name string) string {
+	for _, cm := range r.Object.GetStatus().CheckList {
+		if cm.Name == name && cm.Title != "" {
+			return cm.Title
+		}
+	}
+	return name
+}
+
+// recordCheckTransition must be called before the new check is written into status.Checks
+func (r *Request[T]) recordCheckTransition(name string, curr Check) {
+	prev := r.Object.GetStatus().Checks[name]
+	r.observeCheckTransition(name, prev, curr)
+
+	if r.recorder == nil {
+		return
+	}
+
+	if ev, ok := checkTransitionEvent(r.checkTitle(name), prev, curr); ok {
+		r.recorder.Event(r.Object, ev.EventType, ev.Reason, ev.Message)
+	}
+}
@@ -0,0 +1,103 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCheckTransitionEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev      Check
+		curr      Check
+		wantEvent bool
+		wantType  string
+		wantMsg   string
+	}{
+		{
+			name:      "waiting to running",
+			prev:      Check{State: WaitingState},
+			curr:      Check{State: RunningState, Message: "waiting for pods"},
+			wantEvent: true,
+			wantType:  corev1.EventTypeNormal,
+			wantMsg:   `check "Deployment Ready" is running: waiting for pods`,
+		},
+		{
+			name: "still running",
+			prev: Check{State: RunningState, Message: "waiting for pods"},
+			curr: Check{State: RunningState, Message: "waiting for 1 more pod"},
+		},
+		{
+			name:      "running to errored",
+			prev:      Check{State: RunningState},
+			curr:      Check{State: ErroredState, Message: "image pull backoff"},
+			wantEvent: true,
+			wantType:  corev1.EventTypeWarning,
+			wantMsg:   `check "Deployment Ready" failed: image pull backoff`,
+		},
+		{
+			name: "errored again, with same error",
+			prev: Check{State: ErroredState, Message: "image pull backoff"},
+			curr: Check{State: ErroredState, Message: "image pull backoff"},
+		},
+		{
+			name:      "errored again, with different error",
+			prev:      Check{State: ErroredState, Message: "image pull backoff"},
+			curr:      Check{State: ErroredState, Message: "crashloop backoff"},
+			wantEvent: true,
+			wantType:  corev1.EventTypeWarning,
+			wantMsg:   `check "Deployment Ready" failed: crashloop backoff`,
+		},
+		{
+			name:      "errored to completed",
+			prev:      Check{State: ErroredState, Message: "image pull backoff"},
+			curr:      Check{State: CompletedState, Status: true, Message: "image pull backoff"},
+			wantEvent: true,
+			wantType:  corev1.EventTypeNormal,
+			wantMsg:   `check "Deployment Ready" completed`,
+		},
+		{
+			name: "already completed",
+			prev: Check{State: CompletedState, Status: true},
+			curr: Check{State: CompletedState, Status: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, ok := checkTransitionEvent("Deployment Ready", tt.prev, tt.curr)
+			if ok != tt.wantEvent {
+				t.Fatalf("checkTransitionEvent() emitted = %v, want %v", ok, tt.wantEvent)
+			}
+			if !ok {
+				return
+			}
+			if ev.EventType != tt.wantType {
+				t.Errorf("event type = %v, want %v", ev.EventType, tt.wantType)
+			}
+			if ev.Message != tt.wantMsg {
+				t.Errorf("event message = %v, want %v", ev.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestEventRecorderFrom(t *testing.T) {
+	if eventRecorderFrom(context.TODO()) != nil {
+		t.Fatalf("eventRecorderFrom() found a recorder, where none was passed")
+	}
+
+	recorder := record.NewFakeRecorder(1)
+	ctx := WithEventRecorder(context.TODO(), recorder)
+
+	// controllers reconcile with contexts derived from the one, the manager is started with
+	derived, cancel := context.WithCancel(NewReconcilerCtx(ctx, nil, "sample"))
+	defer cancel()
+
+	if got := eventRecorderFrom(derived); got != recorder {
+		t.Fatalf("eventRecorderFrom() = %v, want the recorder passed with WithEventRecorder", got)
+	}
+}
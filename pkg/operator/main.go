@@ -3,6 +3,7 @@ package operator
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
@@ -60,3 +61,24 @@ func GetRaw[T any](ctx context.Context, cli client.Client, nn types.NamespacedNa
 	}
 	return &result, nil
 }
+
+// UpdatePreservingStatus updates obj, keeping its in-memory status. A plain Update decodes the server response into obj, and discards status
+// changes made during the ongoing reconcile, that are yet to be written by PostReconcile
+func UpdatePreservingStatus(ctx context.Context, cli client.Client, obj client.Object) error {
+	updated, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("failed to copy %T", obj)
+	}
+
+	if err := cli.Update(ctx, updated); err != nil {
+		return err
+	}
+
+	obj.SetResourceVersion(updated.GetResourceVersion())
+	obj.SetGeneration(updated.GetGeneration())
+	obj.SetLabels(updated.GetLabels())
+	obj.SetAnnotations(updated.GetAnnotations())
+	obj.SetFinalizers(updated.GetFinalizers())
+	obj.SetManagedFields(updated.GetManagedFields())
+	return nil
+}
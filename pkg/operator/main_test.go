@@ -0,0 +1,44 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdatePreservingStatus(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}}
+	cli := fake.NewClientBuilder().WithObjects(pod).WithStatusSubresource(pod).Build()
+
+	obj := &corev1.Pod{}
+	if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(pod), obj); err != nil {
+		t.Fatal(err)
+	}
+
+	obj.Status.Message = "set during reconcile"
+	obj.SetAnnotations(map[string]string{"kloudlite.io/sample": "true"})
+	oldVersion := obj.GetResourceVersion()
+
+	if err := UpdatePreservingStatus(context.TODO(), cli, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if obj.Status.Message != "set during reconcile" {
+		t.Fatalf("UpdatePreservingStatus() dropped in-memory status, got %q", obj.Status.Message)
+	}
+	if obj.GetResourceVersion() == oldVersion {
+		t.Fatalf("UpdatePreservingStatus() did not carry over the new resource version")
+	}
+
+	var got corev1.Pod
+	if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(pod), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.GetAnnotations()["kloudlite.io/sample"] != "true" {
+		t.Fatalf("UpdatePreservingStatus() did not update annotations")
+	}
+}
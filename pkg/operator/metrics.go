@@ -0,0 +1,89 @@
+package operator
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// metrics are served over controller-runtime's metrics endpoint, configured in operator.New
+var (
+	checkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kloudlite",
+		Subsystem: "operator",
+		Name:      "check_duration_seconds",
+		Help:      "time taken by a single run of a check, partitioned by the state it ended up in",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"controller", "gvk", "check", "state"})
+
+	checkStateTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kloudlite",
+		Subsystem: "operator",
+		Name:      "check_state_transitions_total",
+		Help:      "number of times a check moved into a state",
+	}, []string{"controller", "gvk", "check", "state"})
+
+	resourcesNotReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "kloudlite",
+		Subsystem: "operator",
+		Name:      "resources_not_ready",
+		Help:      "number of resources, that are not ready",
+	}, []string{"controller", "gvk"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(checkDuration, checkStateTransitions, resourcesNotReady)
+}
+
+type notReadyKey struct {
+	controller string
+	gvk        string
+}
+
+// notReadyTracker keeps track of not ready resources, so that resourcesNotReady gauge can be computed
+// without listing resources
+type notReadyTracker struct {
+	sync.Mutex
+	items map[notReadyKey]map[types.NamespacedName]struct{}
+}
+
+var notReadyResources = &notReadyTracker{items: map[notReadyKey]map[types.NamespacedName]struct{}{}}
+
+func (t *notReadyTracker) set(key notReadyKey, nn types.NamespacedName, notReady bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.items[key]; !ok {
+		t.items[key] = map[types.NamespacedName]struct{}{}
+	}
+
+	if notReady {
+		t.items[key][nn] = struct{}{}
+	} else {
+		delete(t.items[key], nn)
+	}
+
+	resourcesNotReady.WithLabelValues(key.controller, key.gvk).Set(float64(len(t.items[key])))
+}
+
+func (r *Request[T]) gvkLabel() string {
+	return r.Object.GetObjectKind().GroupVersionKind().String()
+}
+
+func (r *Request[T]) observeCheckDuration(checkName string, state State, seconds float64) {
+	checkDuration.WithLabelValues(r.controllerName, r.gvkLabel(), checkName, string(state)).Observe(seconds)
+}
+
+func (r *Request[T]) observeCheckTransition(checkName string, prev Check, curr Check) {
+	if curr.State == "" || prev.State == curr.State {
+		return
+	}
+	checkStateTransitions.WithLabelValues(r.controllerName, r.gvkLabel(), checkName, string(curr.State)).Inc()
+}
+
+func (r *Request[T]) observeReadiness(notReady bool) {
+	nn := types.NamespacedName{Namespace: r.Object.GetNamespace(), Name: r.Object.GetName()}
+	notReadyResources.set(notReadyKey{controller: r.controllerName, gvk: r.gvkLabel()}, nn, notReady)
+}
@@ -0,0 +1,26 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNotReadyTracker(t *testing.T) {
+	tracker := &notReadyTracker{items: map[notReadyKey]map[types.NamespacedName]struct{}{}}
+	key := notReadyKey{controller: "app", gvk: "crds.kloudlite.io/v1, Kind=App"}
+
+	tracker.set(key, types.NamespacedName{Namespace: "sample", Name: "app-1"}, true)
+	tracker.set(key, types.NamespacedName{Namespace: "sample", Name: "app-2"}, true)
+	tracker.set(key, types.NamespacedName{Namespace: "sample", Name: "app-1"}, true)
+
+	if got := testutil.ToFloat64(resourcesNotReady.WithLabelValues(key.controller, key.gvk)); got != 2 {
+		t.Errorf("resources_not_ready = %v, want 2", got)
+	}
+
+	tracker.set(key, types.NamespacedName{Namespace: "sample", Name: "app-1"}, false)
+	if got := testutil.ToFloat64(resourcesNotReady.WithLabelValues(key.controller, key.gvk)); got != 1 {
+		t.Errorf("resources_not_ready = %v, want 1", got)
+	}
+}
@@ -40,6 +40,7 @@ func (cw *checkWrapper[T]) Failed(err error) step_result.Result {
 	cw.Check.Status = false
 	cw.Check.Message = err.Error()
 
+	cw.request.recordCheckTransition(cw.checkName, cw.Check)
 	cw.request.Object.GetStatus().Checks[cw.checkName] = cw.Check
 
 	// FIXME: change `Err(err)` to `Err(nil)`, once failed calls are checked on each of the controllers
@@ -55,6 +56,7 @@ func (cw *checkWrapper[T]) StillRunning(err error) step_result.Result {
 		cw.Check.Message = err.Error()
 	}
 
+	cw.request.recordCheckTransition(cw.checkName, cw.Check)
 	cw.request.Object.GetStatus().Checks[cw.checkName] = cw.Check
 
 	return cw.request.updateStatus().Continue(false).Err(err)
@@ -66,6 +68,7 @@ func (cw *checkWrapper[T]) Completed() step_result.Result {
 	cw.Check.State = CompletedState
 	cw.Check.Status = true
 
+	cw.request.recordCheckTransition(cw.checkName, cw.Check)
 	cw.request.Object.GetStatus().Checks[cw.checkName] = cw.Check
 
 	return cw.request.updateStatus().Continue(true)
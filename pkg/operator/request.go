@@ -15,6 +15,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -40,12 +41,15 @@ type Request[T Resource] struct {
 	timerMap       map[string]time.Time
 
 	resourceRefs []ResourceRef
+
+	recorder       record.EventRecorder
+	controllerName string
 }
 
 type ReconcilerCtx context.Context
 
-func NewReconcilerCtx(parent context.Context, logger logging.Logger) ReconcilerCtx {
-	return context.WithValue(parent, "logger", logger)
+func NewReconcilerCtx(parent context.Context, logger logging.Logger, controllerName string) ReconcilerCtx {
+	return context.WithValue(context.WithValue(parent, "logger", logger), "controller-name", controllerName)
 }
 
 func NewRequest[T Resource](ctx ReconcilerCtx, c client.Client, nn types.NamespacedName, resource T) (*Request[T], error) {
@@ -69,6 +73,8 @@ func NewRequest[T Resource](ctx ReconcilerCtx, c client.Client, nn types.Namespa
 		panic("no logger passed into NewRequest")
 	}
 
+	controllerName, _ := ctx.Value("controller-name").(string)
+
 	anchorName := func() string {
 		x := strings.ToLower(fmt.Sprintf("%s-%s", resource.GetObjectKind().GroupVersionKind().Kind, resource.GetName()))
 		if len(x) >= 63 {
@@ -90,6 +96,8 @@ func NewRequest[T Resource](ctx ReconcilerCtx, c client.Client, nn types.Namespa
 		anchorName:     anchorName,
 		locals:         map[string]any{},
 		timerMap:       map[string]time.Time{},
+		recorder:       eventRecorderFrom(ctx),
+		controllerName: controllerName,
 	}, nil
 }
 
@@ -240,8 +248,8 @@ func (r *Request[T]) ClearStatusIfAnnotated() stepResult.Result {
 		obj.GetStatus().LastReconcileTime = &metav1.Time{Time: time.Now()}
 		obj.GetStatus().Checks = nil
 		obj.GetStatus().Message = nil
+		obj.GetStatus().Conditions = nil
 		// obj.GetStatus().Messages = nil
-		// obj.GetStatus().Conditions = nil
 		// obj.GetStatus().OpsConditions = nil
 		// obj.GetStatus().ChildConditions = nil
 		// obj.GetStatus().DisplayVars = nil
@@ -303,11 +311,13 @@ func (r *Request[T]) CheckFailed(name string, check Check, msg string) stepResul
 
 	status := r.Object.GetStatus()
 
+	r.recordCheckTransition(name, check)
 	status.Checks[name] = check
 	status.Message = &raw_json.RawJson{}
 	status.Message.Set(name, msg)
 	status.IsReady = false
 	status.LastReconcileTime = &metav1.Time{Time: time.Now()}
+	r.syncConditions()
 
 	if err := r.client.Status().Update(r.ctx, r.Object); err != nil {
 		return stepResult.New().Err(err)
@@ -326,11 +336,13 @@ func (r *Request[T]) CheckStillRunning(name string, check Check, msg string) ste
 
 	status := r.Object.GetStatus()
 
+	r.recordCheckTransition(name, check)
 	status.Checks[name] = check
 	status.Message = &raw_json.RawJson{}
 	status.Message.Set(name, msg)
 	status.IsReady = false
 	status.LastReconcileTime = &metav1.Time{Time: time.Now()}
+	r.syncConditions()
 
 	if err := r.client.Status().Update(r.ctx, r.Object); err != nil {
 		return stepResult.New().Err(err)
@@ -378,12 +390,18 @@ func (r *Request[T]) updateStatus() stepResult.Result {
 		status.Message = nil
 	}
 
+	r.syncConditions()
+
 	if err := r.client.Status().Update(r.Context(), r.Object); err != nil {
 		return stepResult.New().Err(err)
 	}
 	return stepResult.New().Continue(true)
 }
 
+func (r *Request[T]) syncConditions() {
+	SyncConditions(r.Object.GetStatus(), r.Object.GetGeneration())
+}
+
 func (r *Request[T]) UpdateStatus() stepResult.Result {
 	r.Object.GetStatus().LastReconcileTime = &metav1.Time{Time: time.Now()}
 
@@ -404,10 +422,13 @@ func (r *Request[T]) UpdateStatus() stepResult.Result {
 			}
 
 			check.State = CompletedState
+			r.recordCheckTransition(name, check)
 			checks[name] = check
 		}
 	}
 
+	r.syncConditions()
+
 	if err := r.client.Status().Update(r.Context(), r.Object); err != nil {
 		return stepResult.New().Err(err)
 	}
@@ -415,6 +436,7 @@ func (r *Request[T]) UpdateStatus() stepResult.Result {
 }
 
 func (r *Request[T]) Finalize() stepResult.Result {
+	r.observeReadiness(false)
 	controllerutil.RemoveFinalizer(r.Object, constants.CommonFinalizer)
 	controllerutil.RemoveFinalizer(r.Object, constants.ForegroundFinalizer)
 	controllerutil.RemoveFinalizer(r.Object, "finalizers.kloudlite.io")
@@ -452,6 +474,10 @@ func (r *Request[T]) PostReconcile() {
 		r.Object.GetStatus().LastReadyGeneration = r.Object.GetGeneration()
 	}
 
+	r.observeReadiness(!isReady)
+
+	r.syncConditions()
+
 	if err := r.client.Status().Update(r.Context(), r.Object); err != nil {
 		if !apiErrors.IsNotFound(err) && !apiErrors.IsConflict(err) {
 			red := color.New(color.FgHiRed, color.Bold).SprintFunc()
@@ -478,7 +504,7 @@ func (r *Request[T]) PostReconcile() {
 		return fmt.Sprintf("%s (%s%s)", readyMsg, generationMsg, deletionMsg)
 	}()
 
-	m["kloudlite.io/checks"] = func() string {
+	m[constants.AnnotationResourceChecks] = func() string {
 		checks := make([]string, 0, len(r.Object.GetStatus().Checks))
 		currentCheck := ""
 		keys := fn.MapKeys(r.Object.GetStatus().Checks)
@@ -526,6 +552,7 @@ func (r *Request[T]) LogPostCheck(checkName string) {
 	tDiff := time.Since(r.timerMap[checkName]).Seconds()
 	check, ok := r.Object.GetStatus().Checks[checkName]
 	if ok {
+		r.observeCheckDuration(checkName, check.State, tDiff)
 		if !check.Status {
 			red := color.New(color.FgRed).SprintFunc()
 			r.internalLogger.Infof(red("[check:end] (took: %.2fs) %-20s [status] %v [message] %v"), tDiff, checkName, check.Status, check.Message)
@@ -81,6 +81,9 @@ type Status struct {
 	Checks              map[string]Check `json:"checks,omitempty"`
 	LastReadyGeneration int64            `json:"lastReadyGeneration,omitempty"`
 	LastReconcileTime   *metav1.Time     `json:"lastReconcileTime,omitempty"`
+
+	// Conditions are derived from Checks, and IsReady, on every status update
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type Reconciler interface {
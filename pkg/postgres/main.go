@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kloudlite/operator/pkg/errors"
+	"github.com/lib/pq"
+)
+
+type Client struct {
+	db *sql.DB
+}
+
+// ConnectionURI is the postgres:// uri for username, to connect to dbName on host (host:port)
+func ConnectionURI(host, dbName, username, password string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     host,
+		Path:     "/" + dbName,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+// DSN is the libpq keyword/value connection string for username, to connect to dbName on host (host:port)
+func DSN(host, dbName, username, password string) string {
+	port := "5432"
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+
+	quote := func(v string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+	}
+
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", quote(host), port, quote(username), quote(password), quote(dbName))
+}
+
+func NewClient(host, dbName, username, password string) (*Client, error) {
+	connector, err := pq.NewConnector(ConnectionURI(host, dbName, username, password))
+	if err != nil {
+		return nil, errors.NewEf(err, "could not create postgres connector")
+	}
+	db := sql.OpenDB(connector)
+	db.SetConnMaxLifetime(20 * time.Minute)
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	return &Client{db: db}, nil
+}
+
+func (c *Client) Connect(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return errors.NewEf(err, "could not ping with postgres connection")
+	}
+	return nil
+}
+
+func (c *Client) Close() error {
+	return c.db.Close()
+}
+
+func (c *Client) exists(ctx context.Context, query string, args ...any) (bool, error) {
+	var exists bool
+	if err := c.db.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (c *Client) UserExists(ctx context.Context, username string) (bool, error) {
+	exists, err := c.exists(ctx, "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)", username)
+	if err != nil {
+		return false, errors.NewEf(err, "checking if user exists")
+	}
+	return exists, nil
+}
+
+// UpsertUser creates a login role username, or resets its password, when it already exists
+func (c *Client) UpsertUser(ctx context.Context, username, password string) error {
+	exists, err := c.UserExists(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	stmt := "CREATE ROLE %s WITH LOGIN PASSWORD %s"
+	if exists {
+		stmt = "ALTER ROLE %s WITH LOGIN PASSWORD %s"
+	}
+
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf(stmt, pq.QuoteIdentifier(username), pq.QuoteLiteral(password))); err != nil {
+		return errors.NewEf(err, "upserting user")
+	}
+	return nil
+}
+
+func (c *Client) DropUser(ctx context.Context, username string) error {
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf("DROP ROLE IF EXISTS %s", pq.QuoteIdentifier(username))); err != nil {
+		return errors.NewEf(err, "dropping user")
+	}
+	return nil
+}
+
+// UpsertDatabase creates database dbName, owned by owner, when it does not exist yet
+func (c *Client) UpsertDatabase(ctx context.Context, dbName, owner string) error {
+	exists, err := c.exists(ctx, "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)", dbName)
+	if err != nil {
+		return errors.NewEf(err, "checking if database exists")
+	}
+	if exists {
+		return nil
+	}
+
+	// CREATE DATABASE has no IF NOT EXISTS, and can not run in a transaction
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s OWNER %s", pq.QuoteIdentifier(dbName), pq.QuoteIdentifier(owner))); err != nil {
+		return errors.NewEf(err, "creating database")
+	}
+	return nil
+}
+
+// GrantPrivileges grants username all privileges on database dbName
+func (c *Client) GrantPrivileges(ctx context.Context, dbName, username string) error {
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf("GRANT ALL PRIVILEGES ON DATABASE %s TO %s", pq.QuoteIdentifier(dbName), pq.QuoteIdentifier(username))); err != nil {
+		return errors.NewEf(err, "granting privileges")
+	}
+	return nil
+}
+
+// DropDatabase drops database dbName, terminating connections still open to it
+func (c *Client) DropDatabase(ctx context.Context, dbName string) error {
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", pq.QuoteIdentifier(dbName))); err != nil {
+		return errors.NewEf(err, "dropping database")
+	}
+	return nil
+}
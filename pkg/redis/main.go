@@ -48,6 +48,11 @@ func (c *Client) UpsertUser(ctx context.Context, prefix, username, password stri
 	return nil
 }
 
+// AddUserPassword lets username authenticate with password, alongside its existing passwords. UpsertUser resets them, to the one given
+func (c *Client) AddUserPassword(ctx context.Context, username, password string) error {
+	return c.cli.Do(ctx, "ACL", "SETUSER", username, fmt.Sprintf(">%s", password)).Err()
+}
+
 func (c *Client) UserExists(ctx context.Context, username string) (bool, error) {
 	exists, err := c.userExists(ctx, username)
 	if err != nil {
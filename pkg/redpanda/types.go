@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/kloudlite/operator/pkg/errors"
 	fn "github.com/kloudlite/operator/pkg/functions"
 	"github.com/kloudlite/operator/pkg/logging"
 	"github.com/twmb/franz-go/pkg/kgo"
@@ -27,37 +28,19 @@ func parseSASLAuth(sasl *KafkaSASLAuth) (kgo.Opt, error) {
 		return nil, nil
 	}
 
-	return kgo.SASL((scram.Auth{
+	auth := scram.Auth{
 		User: sasl.User,
 		Pass: sasl.Password,
-	}).AsSha256Mechanism()), nil
-
-	// if sasl.SASLMechanism == ScramSHA256 {
-	// 	return kgo.SASL(
-	// 		scram.Sha256(
-	// 			func(context.Context) (scram.Auth, error) {
-	// 				return scram.Auth{
-	// 					User: sasl.User,
-	// 					Pass: sasl.Password,
-	// 				}, nil
-	// 			},
-	// 		),
-	// 	), nil
-	// }
-	//
-	// if sasl.SASLMechanism == ScramSHA512 {
-	// 	return kgo.SASL(
-	// 		scram.Sha512(
-	// 			func(ctx context.Context) (scram.Auth, error) {
-	// 				return scram.Auth{
-	// 					User: sasl.User,
-	// 					Pass: sasl.Password,
-	// 				}, nil
-	// 			},
-	// 		),
-	// 	), nil
-	// }
-	// return nil, errors.Newf("unknown SASL mechanism")
+	}
+
+	switch sasl.SASLMechanism {
+	case "", ScramSHA256:
+		return kgo.SASL(auth.AsSha256Mechanism()), nil
+	case ScramSHA512:
+		return kgo.SASL(auth.AsSha512Mechanism()), nil
+	default:
+		return nil, errors.Newf("unknown SASL mechanism (%s)", sasl.SASLMechanism)
+	}
 }
 
 type ConsumerOpts struct {
@@ -0,0 +1,306 @@
+package resize
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiLabels "k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationStartedAt records, when an ongoing resize started. Pods created before it are restarted, once the resize is through, so that
+	// they pick up expanded volumes
+	AnnotationStartedAt = "kloudlite.io/msvc.resize-started-at"
+
+	annotationDefaultStorageClass = "storageclass.kubernetes.io/is-default-class"
+)
+
+// StartedAt tells when the ongoing resize of obj started, if there is one
+func StartedAt(obj client.Object) (time.Time, bool) {
+	v, ok := obj.GetAnnotations()[AnnotationStartedAt]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// MarkStarted records the start of a resize on obj, unless one is already ongoing. It tells whether obj changed
+func MarkStarted(obj client.Object, now time.Time) bool {
+	if _, ok := StartedAt(obj); ok {
+		return false
+	}
+	ann := obj.GetAnnotations()
+	fn.MapSet(&ann, AnnotationStartedAt, now.UTC().Format(time.RFC3339))
+	obj.SetAnnotations(ann)
+	return true
+}
+
+// MarkDone clears the ongoing resize of obj
+func MarkDone(obj client.Object) {
+	ann := obj.GetAnnotations()
+	delete(ann, AnnotationStartedAt)
+	obj.SetAnnotations(ann)
+}
+
+// listStatefulSets lists statefulsets of a managed service, in namespace, that carry labels
+func listStatefulSets(ctx context.Context, cli client.Client, namespace string, labels map[string]string) ([]appsv1.StatefulSet, error) {
+	var stsList appsv1.StatefulSetList
+	if err := cli.List(ctx, &stsList, &client.ListOptions{
+		LabelSelector: apiLabels.SelectorFromValidatedSet(labels),
+		Namespace:     namespace,
+	}); err != nil {
+		return nil, err
+	}
+	return stsList.Items, nil
+}
+
+func replicas(sts *appsv1.StatefulSet) int {
+	if sts.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*sts.Spec.Replicas)
+}
+
+// claimNames are names of the persistent volume claims, that statefulset controller creates for sts
+func claimNames(sts *appsv1.StatefulSet) []string {
+	names := make([]string, 0, len(sts.Spec.VolumeClaimTemplates)*replicas(sts))
+	for _, vct := range sts.Spec.VolumeClaimTemplates {
+		for i := 0; i < replicas(sts); i++ {
+			names = append(names, fmt.Sprintf("%s-%s-%d", vct.Name, sts.Name, i))
+		}
+	}
+	return names
+}
+
+func requestedStorage(resources corev1.ResourceRequirements) resource.Quantity {
+	return resources.Requests[corev1.ResourceStorage]
+}
+
+// claimExpanded tells whether pvc got to size. Claims, waiting for their filesystem to be resized, count as expanded, as that happens once
+// their pods restart
+func claimExpanded(pvc *corev1.PersistentVolumeClaim, size resource.Quantity) bool {
+	for _, c := range pvc.Status.Conditions {
+		if c.Type == corev1.PersistentVolumeClaimFileSystemResizePending && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	return capacity.Cmp(size) >= 0
+}
+
+// needsRecreate tells whether volume claim templates of sts request less than size. They are immutable, so sts has to be recreated
+func needsRecreate(sts *appsv1.StatefulSet, size resource.Quantity) bool {
+	for _, vct := range sts.Spec.VolumeClaimTemplates {
+		if requested := requestedStorage(vct.Spec.Resources); requested.Cmp(size) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsExpansion tells whether storage class name (the default one, when nil) allows volume expansion
+func allowsExpansion(ctx context.Context, cli client.Client, name *string) (bool, error) {
+	if name != nil && *name != "" {
+		var storageClass storagev1.StorageClass
+		if err := cli.Get(ctx, fn.NN("", *name), &storageClass); err != nil {
+			return false, err
+		}
+		return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+	}
+
+	var scList storagev1.StorageClassList
+	if err := cli.List(ctx, &scList); err != nil {
+		return false, err
+	}
+	for i := range scList.Items {
+		if scList.Items[i].GetAnnotations()[annotationDefaultStorageClass] == "true" {
+			return scList.Items[i].AllowVolumeExpansion != nil && *scList.Items[i].AllowVolumeExpansion, nil
+		}
+	}
+	return false, fmt.Errorf("no default storage class found")
+}
+
+// expandClaims grows persistent volume claims of statefulsets to storage size, in place. It fails, when a claim would need to shrink, or its
+// storage class does not allow expansion. beforeResize runs ahead of the first claim being resized, for the resize to be recorded, and it
+// tells which claims are yet to expand
+func expandClaims(ctx context.Context, cli client.Client, statefulSets []appsv1.StatefulSet, size ct.StorageSize, beforeResize func() error) ([]string, error) {
+	desired, err := resource.ParseQuantity(string(size))
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+
+	for i := range statefulSets {
+		for _, name := range claimNames(&statefulSets[i]) {
+			var pvc corev1.PersistentVolumeClaim
+			if err := cli.Get(ctx, fn.NN(statefulSets[i].Namespace, name), &pvc); err != nil {
+				if apiErrors.IsNotFound(err) {
+					// claims of replicas, that have not started yet, get created with the new size
+					continue
+				}
+				return nil, err
+			}
+
+			requested := requestedStorage(pvc.Spec.Resources)
+			switch requested.Cmp(desired) {
+			case 1:
+				return nil, fmt.Errorf("persistent volume claim %s has %s, and can not shrink to %s", name, requested.String(), size)
+			case -1:
+				ok, err := allowsExpansion(ctx, cli, pvc.Spec.StorageClassName)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return nil, fmt.Errorf("storage class of persistent volume claim %s does not allow volume expansion", name)
+				}
+
+				if beforeResize != nil {
+					if err := beforeResize(); err != nil {
+						return nil, err
+					}
+					beforeResize = nil
+				}
+
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+				if err := cli.Update(ctx, &pvc); err != nil {
+					return nil, err
+				}
+			}
+
+			if !claimExpanded(&pvc, desired) {
+				pending = append(pending, name)
+			}
+		}
+	}
+
+	return pending, nil
+}
+
+// recreateStatefulSets deletes statefulsets, whose volume claim templates request less than storage size, leaving their pods, and claims
+// running. Applying the helm chart again recreates them, with the new size, and they adopt the pods. It tells, whether any was deleted
+func recreateStatefulSets(ctx context.Context, cli client.Client, statefulSets []appsv1.StatefulSet, size ct.StorageSize) (bool, error) {
+	desired, err := resource.ParseQuantity(string(size))
+	if err != nil {
+		return false, err
+	}
+
+	deleted := false
+	for i := range statefulSets {
+		if !needsRecreate(&statefulSets[i], desired) {
+			continue
+		}
+		if err := cli.Delete(ctx, &statefulSets[i], client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+			if apiErrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		deleted = true
+	}
+	return deleted, nil
+}
+
+// rolledOut tells whether all replicas of sts run its latest revision, and are ready
+func rolledOut(sts *appsv1.StatefulSet) bool {
+	if sts.Status.ObservedGeneration < sts.Generation {
+		return false
+	}
+	if sts.Status.UpdateRevision != "" && sts.Status.CurrentRevision != sts.Status.UpdateRevision {
+		return false
+	}
+	n := int32(replicas(sts))
+	return sts.Status.UpdatedReplicas == n && sts.Status.ReadyReplicas == n
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func ordinal(pod *corev1.Pod) int {
+	i := strings.LastIndex(pod.Name, "-")
+	n, err := strconv.Atoi(pod.Name[i+1:])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// nextPodToRoll picks a pod, created before since, to restart, going from the highest ordinal down, as statefulset rollouts do. Nothing is
+// picked, while any pod is not ready, so that pods restart one at a time
+func nextPodToRoll(pods []corev1.Pod, since time.Time) (next *corev1.Pod, notReady *corev1.Pod) {
+	sort.Slice(pods, func(i, j int) bool { return ordinal(&pods[i]) > ordinal(&pods[j]) })
+
+	for i := range pods {
+		if pods[i].DeletionTimestamp != nil || !isPodReady(&pods[i]) {
+			return nil, &pods[i]
+		}
+	}
+
+	for i := range pods {
+		if pods[i].CreationTimestamp.Time.Before(since) {
+			return &pods[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// rollPods waits for statefulsets to roll out, and then restarts their pods, created before since, one at a time. It tells what is still
+// being waited for, and is empty, once all pods are rolled
+func rollPods(ctx context.Context, cli client.Client, statefulSets []appsv1.StatefulSet, since time.Time) (string, error) {
+	for i := range statefulSets {
+		if !rolledOut(&statefulSets[i]) {
+			return fmt.Sprintf("waiting for statefulset %s to roll out", statefulSets[i].Name), nil
+		}
+	}
+
+	for i := range statefulSets {
+		sts := &statefulSets[i]
+		if sts.Spec.Selector == nil {
+			continue
+		}
+
+		var podList corev1.PodList
+		if err := cli.List(ctx, &podList, &client.ListOptions{
+			LabelSelector: apiLabels.SelectorFromSet(sts.Spec.Selector.MatchLabels),
+			Namespace:     sts.Namespace,
+		}); err != nil {
+			return "", err
+		}
+
+		next, notReady := nextPodToRoll(podList.Items, since)
+		if notReady != nil {
+			return fmt.Sprintf("waiting for pod %s to be ready", notReady.Name), nil
+		}
+		if next != nil {
+			if err := cli.Delete(ctx, next); err != nil && !apiErrors.IsNotFound(err) {
+				return "", err
+			}
+			return fmt.Sprintf("restarting pod %s", next.Name), nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,162 @@
+package resize
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newStatefulSet(name string, n int32, storage string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "env"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &n,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "data"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(storage)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestClaimNames(t *testing.T) {
+	got := claimNames(newStatefulSet("pg-read", 2, "1Gi"))
+	if len(got) != 2 || got[0] != "data-pg-read-0" || got[1] != "data-pg-read-1" {
+		t.Fatalf("claimNames() = %v", got)
+	}
+}
+
+func TestNeedsRecreate(t *testing.T) {
+	sts := newStatefulSet("pg", 1, "1Gi")
+	if !needsRecreate(sts, resource.MustParse("2Gi")) {
+		t.Fatalf("needsRecreate(), growing 1Gi to 2Gi, should be true")
+	}
+	if needsRecreate(sts, resource.MustParse("1024Mi")) {
+		t.Fatalf("needsRecreate(), at the same size, should be false")
+	}
+}
+
+func TestClaimExpanded(t *testing.T) {
+	size := resource.MustParse("2Gi")
+
+	pvc := &corev1.PersistentVolumeClaim{
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+		},
+	}
+	if claimExpanded(pvc, size) {
+		t.Fatalf("claimExpanded(), with capacity still at 1Gi, should be false")
+	}
+
+	pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+		{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+	}
+	if !claimExpanded(pvc, size) {
+		t.Fatalf("claimExpanded(), waiting for a filesystem resize, should be true")
+	}
+}
+
+func TestRolledOut(t *testing.T) {
+	sts := newStatefulSet("pg", 2, "1Gi")
+	sts.Status = appsv1.StatefulSetStatus{CurrentRevision: "pg-1", UpdateRevision: "pg-2", UpdatedReplicas: 1, ReadyReplicas: 2}
+	if rolledOut(sts) {
+		t.Fatalf("rolledOut(), midway through a rollout, should be false")
+	}
+
+	sts.Status = appsv1.StatefulSetStatus{CurrentRevision: "pg-2", UpdateRevision: "pg-2", UpdatedReplicas: 2, ReadyReplicas: 2}
+	if !rolledOut(sts) {
+		t.Fatalf("rolledOut(), with all replicas updated, and ready, should be true")
+	}
+}
+
+func newPod(name string, createdAt time.Time, ready bool) corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(createdAt)},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func TestNextPodToRoll(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before, after := since.Add(-time.Hour), since.Add(time.Minute)
+
+	tests := []struct {
+		name         string
+		pods         []corev1.Pod
+		wantNext     string
+		wantNotReady string
+	}{
+		{
+			name:     "highest ordinal goes first",
+			pods:     []corev1.Pod{newPod("pg-0", before, true), newPod("pg-1", before, true), newPod("pg-2", before, true)},
+			wantNext: "pg-2",
+		},
+		{
+			name:         "waits for a restarted pod to be ready",
+			pods:         []corev1.Pod{newPod("pg-0", before, true), newPod("pg-1", after, false)},
+			wantNotReady: "pg-1",
+		},
+		{
+			name:     "skips pods, created after the resize started",
+			pods:     []corev1.Pod{newPod("pg-0", before, true), newPod("pg-1", after, true)},
+			wantNext: "pg-0",
+		},
+		{
+			name: "nothing left to roll",
+			pods: []corev1.Pod{newPod("pg-0", after, true), newPod("pg-1", after, true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, notReady := nextPodToRoll(tt.pods, since)
+			if name := podName(next); name != tt.wantNext {
+				t.Fatalf("nextPodToRoll(), next = %q, want %q", name, tt.wantNext)
+			}
+			if name := podName(notReady); name != tt.wantNotReady {
+				t.Fatalf("nextPodToRoll(), not ready = %q, want %q", name, tt.wantNotReady)
+			}
+		})
+	}
+}
+
+func podName(pod *corev1.Pod) string {
+	if pod == nil {
+		return ""
+	}
+	return pod.Name
+}
+
+func TestMarkStarted(t *testing.T) {
+	obj := &corev1.Secret{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if !MarkStarted(obj, now) {
+		t.Fatalf("MarkStarted(), without an ongoing resize, should change obj")
+	}
+	if MarkStarted(obj, now.Add(time.Hour)) {
+		t.Fatalf("MarkStarted(), with an ongoing resize, should not change obj")
+	}
+	if at, ok := StartedAt(obj); !ok || !at.Equal(now) {
+		t.Fatalf("StartedAt() = %v, %v", at, ok)
+	}
+
+	MarkDone(obj)
+	if _, ok := StartedAt(obj); ok {
+		t.Fatalf("StartedAt(), after MarkDone(), should not be set")
+	}
+}
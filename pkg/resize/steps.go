@@ -0,0 +1,118 @@
+package resize
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	"github.com/kloudlite/operator/pkg/constants"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	stepResult "github.com/kloudlite/operator/pkg/operator/step-result"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Steps below resize managed services, whose statefulsets carry constants.MsvcNameKey label. They are run by postgres, mongo, mysql and
+// redis operators. Influx, elasticsearch, neo4j, zookeeper and redpanda services are not resized in place, their CRDs reject storage
+// changes instead
+const (
+	StorageExpanded       string = "storage-expanded"
+	StatefulSetsRecreated string = "statefulsets-recreated"
+	PodsRolled            string = "pods-rolled"
+)
+
+// CheckList holds checks, that resize steps record
+var CheckList = []rApi.CheckMeta{
+	{Name: StorageExpanded, Title: "Storage Expanded"},
+	{Name: StatefulSetsRecreated, Title: "StatefulSets Recreated"},
+	{Name: PodsRolled, Title: "Pods Rolled"},
+}
+
+func msvcStatefulSets[T rApi.Resource](req *rApi.Request[T], cli client.Client) ([]appsv1.StatefulSet, error) {
+	obj := req.Object
+	return listStatefulSets(req.Context(), cli, obj.GetNamespace(), map[string]string{constants.MsvcNameKey: obj.GetName()})
+}
+
+// ExpandStorage grows persistent volume claims in place, when storage asks for more than they have
+func ExpandStorage[T rApi.Resource](req *rApi.Request[T], cli client.Client, storage *ct.Storage) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(StorageExpanded, req)
+
+	if storage == nil {
+		return check.Completed()
+	}
+
+	statefulSets, err := msvcStatefulSets(req, cli)
+	if err != nil {
+		return check.Failed(err)
+	}
+
+	pending, err := expandClaims(ctx, cli, statefulSets, storage.Size, func() error {
+		if !MarkStarted(obj, time.Now()) {
+			return nil
+		}
+		return rApi.UpdatePreservingStatus(ctx, cli, obj)
+	})
+	if err != nil {
+		return check.Failed(err).Err(nil)
+	}
+
+	if len(pending) > 0 {
+		return check.StillRunning(fmt.Errorf("waiting for persistent volume claims %s to expand", strings.Join(pending, ", "))).Err(nil).RequeueAfter(5 * time.Second)
+	}
+
+	return check.Completed()
+}
+
+// RecreateStatefulSets orphan deletes statefulsets with smaller volume claim templates than storage, as they are immutable, for helm to
+// recreate them
+func RecreateStatefulSets[T rApi.Resource](req *rApi.Request[T], cli client.Client, storage *ct.Storage) stepResult.Result {
+	check := rApi.NewRunningCheck(StatefulSetsRecreated, req)
+
+	if storage == nil {
+		return check.Completed()
+	}
+
+	statefulSets, err := msvcStatefulSets(req, cli)
+	if err != nil {
+		return check.Failed(err)
+	}
+
+	if _, err := recreateStatefulSets(req.Context(), cli, statefulSets, storage.Size); err != nil {
+		return check.Failed(err)
+	}
+
+	return check.Completed()
+}
+
+// RollPods restarts pods, one at a time, once a resize is through, for them to pick up expanded volumes
+func RollPods[T rApi.Resource](req *rApi.Request[T], cli client.Client) stepResult.Result {
+	ctx, obj := req.Context(), req.Object
+	check := rApi.NewRunningCheck(PodsRolled, req)
+
+	since, ok := StartedAt(obj)
+	if !ok {
+		return check.Completed()
+	}
+
+	statefulSets, err := msvcStatefulSets(req, cli)
+	if err != nil {
+		return check.Failed(err)
+	}
+
+	msg, err := rollPods(ctx, cli, statefulSets, since)
+	if err != nil {
+		return check.Failed(err)
+	}
+	if msg != "" {
+		return check.StillRunning(fmt.Errorf("%s", msg)).Err(nil).RequeueAfter(5 * time.Second)
+	}
+
+	MarkDone(obj)
+	if err := rApi.UpdatePreservingStatus(ctx, cli, obj); err != nil {
+		return check.Failed(err)
+	}
+
+	return check.Completed()
+}
@@ -0,0 +1,105 @@
+package resize
+
+import (
+	"context"
+	"testing"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	postgresMsvcv1 "github.com/kloudlite/operator/apis/postgres.msvc/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	"github.com/kloudlite/operator/pkg/logging"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExpandStorage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, postgresMsvcv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msvc := &postgresMsvcv1.StandaloneService{ObjectMeta: metav1.ObjectMeta{Name: "pg", Namespace: "db"}}
+	msvc.Spec.Resources.Storage = &ct.Storage{Size: "2Gi"}
+
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "pg", Namespace: "db", Labels: map[string]string{constants.MsvcNameKey: "pg"}},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: fn.New(int32(1)),
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "data"},
+				Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				}},
+			}},
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-pg-0", Namespace: "db"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: fn.New("standard"),
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+	}
+
+	sc := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}, AllowVolumeExpansion: fn.New(true)}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(msvc, sts, pvc, sc).WithStatusSubresource(msvc, pvc).Build()
+
+	logger, err := logging.New(&logging.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func() *rApi.Request[*postgresMsvcv1.StandaloneService] {
+		req, err := rApi.NewRequest(rApi.NewReconcilerCtx(context.TODO(), logger, "pg"), cli, client.ObjectKeyFromObject(msvc), &postgresMsvcv1.StandaloneService{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	req := newRequest()
+	if step := ExpandStorage(req, cli, req.Object.Spec.Resources.Storage); step.ShouldProceed() {
+		t.Fatal("ExpandStorage() should wait for claims to expand")
+	}
+
+	var got corev1.PersistentVolumeClaim
+	if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(pvc), &got); err != nil {
+		t.Fatal(err)
+	}
+	if requested := got.Spec.Resources.Requests[corev1.ResourceStorage]; requested.Cmp(resource.MustParse("2Gi")) != 0 {
+		t.Fatalf("claim requests %s, want 2Gi", requested.String())
+	}
+
+	if _, ok := StartedAt(newRequest().Object); !ok {
+		t.Fatal("expected resize to be recorded on the managed service")
+	}
+
+	got.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}
+	if err := cli.Status().Update(context.TODO(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	req = newRequest()
+	if step := ExpandStorage(req, cli, req.Object.Spec.Resources.Storage); !step.ShouldProceed() {
+		t.Fatal("ExpandStorage() should proceed, once claims are expanded")
+	}
+	if !req.Object.Status.Checks[StorageExpanded].Status {
+		t.Errorf("expected check %q to be completed", StorageExpanded)
+	}
+}
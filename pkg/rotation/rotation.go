@@ -0,0 +1,202 @@
+package rotation
+
+import (
+	"context"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	fn "github.com/kloudlite/operator/pkg/functions"
+	rApi "github.com/kloudlite/operator/pkg/operator"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationRotatedAt records, when credentials were last rotated
+	AnnotationRotatedAt = "kloudlite.io/credentials.rotated-at"
+	// AnnotationRevokeAt records, when the previous credential gets revoked, for as long as it still works
+	AnnotationRevokeAt = "kloudlite.io/credentials.revoke-at"
+	// AnnotationConsumersRestartPending records, that apps reading the rotated credentials are yet to be restarted
+	AnnotationConsumersRestartPending = "kloudlite.io/credentials.consumers-restart-pending"
+
+	DefaultInterval    = 90 * 24 * time.Hour
+	DefaultGracePeriod = 1 * time.Hour
+)
+
+func interval(policy *ct.CredentialRotation) time.Duration {
+	if policy == nil || policy.Interval.Duration <= 0 {
+		return DefaultInterval
+	}
+	return policy.Interval.Duration
+}
+
+func gracePeriod(policy *ct.CredentialRotation) time.Duration {
+	if policy == nil || policy.GracePeriod.Duration <= 0 {
+		return DefaultGracePeriod
+	}
+	return policy.GracePeriod.Duration
+}
+
+func annotationTime(obj client.Object, key string) (time.Time, bool) {
+	v, ok := obj.GetAnnotations()[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// RevokeAt tells when the previous credential is due for revocation, if there is one
+func RevokeAt(obj client.Object) (time.Time, bool) {
+	return annotationTime(obj, AnnotationRevokeAt)
+}
+
+// NextRotation is when credentials are due for rotation, as per policy. It is zero, without a policy
+func NextRotation(obj client.Object, policy *ct.CredentialRotation) time.Time {
+	if policy == nil {
+		return time.Time{}
+	}
+	last, ok := annotationTime(obj, AnnotationRotatedAt)
+	if !ok {
+		last = obj.GetCreationTimestamp().Time
+	}
+	return last.Add(interval(policy))
+}
+
+// IsDue tells whether credentials should be rotated now, either on demand, or as per policy. Rotations wait for the previous one's grace period
+// to end, as there are only ever two working credentials
+func IsDue(obj client.Object, policy *ct.CredentialRotation, now time.Time) bool {
+	if _, ok := RevokeAt(obj); ok {
+		return false
+	}
+	if obj.GetAnnotations()[constants.RotateCredentialsKey] == "true" {
+		return true
+	}
+	next := NextRotation(obj, policy)
+	return !next.IsZero() && !now.Before(next)
+}
+
+// MarkRotated records a rotation on obj, and schedules revocation of the previous credential, after the grace period. Consumers are marked
+// for restart, and revocation waits for them
+func MarkRotated(obj client.Object, policy *ct.CredentialRotation, now time.Time) {
+	ann := obj.GetAnnotations()
+	if ann == nil {
+		ann = make(map[string]string, 3)
+	}
+	delete(ann, constants.RotateCredentialsKey)
+	ann[AnnotationRotatedAt] = now.UTC().Format(time.RFC3339)
+	ann[AnnotationRevokeAt] = now.Add(gracePeriod(policy)).UTC().Format(time.RFC3339)
+	ann[AnnotationConsumersRestartPending] = "true"
+	obj.SetAnnotations(ann)
+}
+
+// MarkRevoked records, that the previous credential has been revoked
+func MarkRevoked(obj client.Object) {
+	ann := obj.GetAnnotations()
+	delete(ann, AnnotationRevokeAt)
+	obj.SetAnnotations(ann)
+}
+
+// ConsumersRestartPending tells whether apps reading rotated credentials of obj are yet to be restarted
+func ConsumersRestartPending(obj client.Object) bool {
+	return obj.GetAnnotations()[AnnotationConsumersRestartPending] == "true"
+}
+
+// MarkConsumersRestarted records, that apps reading rotated credentials of obj have been restarted
+func MarkConsumersRestarted(obj client.Object) {
+	ann := obj.GetAnnotations()
+	delete(ann, AnnotationConsumersRestartPending)
+	obj.SetAnnotations(ann)
+}
+
+// CanRevoke tells whether the previous credential of obj is due for revocation now. It stays, past the grace period, for as long as
+// consumers are yet to be restarted, as they might still be using it
+func CanRevoke(obj client.Object, now time.Time) bool {
+	at, ok := RevokeAt(obj)
+	return ok && !now.Before(at) && !ConsumersRestartPending(obj)
+}
+
+// RequeueAfter is how long until obj needs reconciling again, for a pending revocation, or the next rotation. It is zero, when neither
+// is pending
+func RequeueAfter(obj client.Object, policy *ct.CredentialRotation, now time.Time) time.Duration {
+	at, ok := RevokeAt(obj)
+	if !ok {
+		at = NextRotation(obj, policy)
+	}
+	if at.IsZero() {
+		return 0
+	}
+	if d := at.Sub(now); d > time.Second {
+		return d
+	}
+	return time.Second
+}
+
+// AlternateUsername switches between two users, for services, that allow a single password per user. The previous user keeps working,
+// through the grace period
+func AlternateUsername(base string, current string) string {
+	if current == base {
+		return base + "-rotated"
+	}
+	return base
+}
+
+func consumesSecret(app *crdsv1.App, secretName string) bool {
+	containers := append(append([]crdsv1.AppContainer{}, app.Spec.Containers...), app.Spec.InitContainers...)
+	for _, c := range containers {
+		for _, e := range c.Env {
+			if e.Type == crdsv1.SecretType && e.RefName == secretName {
+				return true
+			}
+		}
+		for _, e := range c.EnvFrom {
+			if e.Type == crdsv1.SecretType && e.RefName == secretName {
+				return true
+			}
+		}
+		for _, v := range c.Volumes {
+			if v.Type == crdsv1.SecretType && v.RefName == secretName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EnsureConsumersRestarted restarts apps, reading secretName, while obj has them pending a restart, and then records it on obj. Failed restarts
+// are retried on the next call, as the record stays
+func EnsureConsumersRestarted(ctx context.Context, cli client.Client, obj client.Object, secretName string) error {
+	if !ConsumersRestartPending(obj) {
+		return nil
+	}
+	if err := RestartConsumers(ctx, cli, obj.GetNamespace(), secretName); err != nil {
+		return err
+	}
+	MarkConsumersRestarted(obj)
+	return rApi.UpdatePreservingStatus(ctx, cli, obj)
+}
+
+// RestartConsumers restarts apps, in namespace, that read secretName, so that they pick up rotated credentials. Apps run as a
+// deployment, or as a statefulset, with per replica volumes
+func RestartConsumers(ctx context.Context, cli client.Client, namespace string, secretName string) error {
+	var apps crdsv1.AppList
+	if err := cli.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	for i := range apps.Items {
+		if !consumesSecret(&apps.Items[i], secretName) {
+			continue
+		}
+		for _, kind := range []fn.Restartable{fn.Deployment, fn.StatefulSet} {
+			if err := fn.RolloutRestart(cli, kind, namespace, map[string]string{constants.AppNameKey: apps.Items[i].Name}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
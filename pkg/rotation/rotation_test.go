@@ -0,0 +1,164 @@
+package rotation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ct "github.com/kloudlite/operator/apis/common-types"
+	crdsv1 "github.com/kloudlite/operator/apis/crds/v1"
+	"github.com/kloudlite/operator/pkg/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRotationLifecycle(t *testing.T) {
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", CreationTimestamp: metav1.Time{Time: created}}}
+	policy := &ct.CredentialRotation{GracePeriod: metav1.Duration{Duration: 30 * time.Minute}}
+
+	if IsDue(obj, nil, created.Add(365*24*time.Hour)) {
+		t.Fatalf("IsDue() without a policy, or a request")
+	}
+	if IsDue(obj, policy, created.Add(89*24*time.Hour)) {
+		t.Fatalf("IsDue() before the default interval of 90 days")
+	}
+
+	now := created.Add(90 * 24 * time.Hour)
+	if !IsDue(obj, policy, now) {
+		t.Fatalf("IsDue() = false, after 90 days")
+	}
+
+	MarkRotated(obj, policy, now)
+	if got := RequeueAfter(obj, policy, now); got != 30*time.Minute {
+		t.Fatalf("RequeueAfter() = %s, want the grace period", got)
+	}
+
+	// an on-demand request waits for the previous credential to be revoked
+	obj.Annotations[constants.RotateCredentialsKey] = "true"
+	if IsDue(obj, policy, now.Add(time.Minute)) {
+		t.Fatalf("IsDue() during the grace period")
+	}
+
+	MarkRevoked(obj)
+	if !IsDue(obj, policy, now.Add(time.Minute)) {
+		t.Fatalf("IsDue() = false, when requested on demand")
+	}
+	delete(obj.Annotations, constants.RotateCredentialsKey)
+
+	if got, want := NextRotation(obj, policy), now.Add(DefaultInterval); !got.Equal(want) {
+		t.Fatalf("NextRotation() = %s, want %s", got, want)
+	}
+	if got := RequeueAfter(obj, nil, now); got != 0 {
+		t.Fatalf("RequeueAfter() = %s, without a policy, or pending revocation", got)
+	}
+}
+
+func TestAlternateUsername(t *testing.T) {
+	if got := AlternateUsername("db", "db"); got != "db-rotated" {
+		t.Fatalf("AlternateUsername() = %q, want db-rotated", got)
+	}
+	if got := AlternateUsername("db", "db-rotated"); got != "db" {
+		t.Fatalf("AlternateUsername() = %q, want db", got)
+	}
+}
+
+func TestCanRevoke(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db"}}
+	policy := &ct.CredentialRotation{GracePeriod: metav1.Duration{Duration: 30 * time.Minute}}
+
+	MarkRotated(obj, policy, now)
+	if !ConsumersRestartPending(obj) {
+		t.Fatalf("ConsumersRestartPending() = false, right after a rotation")
+	}
+	if CanRevoke(obj, now.Add(time.Hour)) {
+		t.Fatalf("CanRevoke() past the grace period, while consumers are yet to restart")
+	}
+
+	MarkConsumersRestarted(obj)
+	if CanRevoke(obj, now.Add(time.Minute)) {
+		t.Fatalf("CanRevoke() during the grace period")
+	}
+	if !CanRevoke(obj, now.Add(time.Hour)) {
+		t.Fatalf("CanRevoke() = false, past the grace period, with consumers restarted")
+	}
+}
+
+func TestEnsureConsumersRestarted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := crdsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	workloadMeta := metav1.ObjectMeta{Name: "api", Namespace: "env", Labels: map[string]string{constants.AppNameKey: "api"}}
+
+	tests := []struct {
+		name     string
+		workload client.Object
+		template func(obj client.Object) *corev1.PodTemplateSpec
+	}{
+		{
+			name:     "app running as a deployment",
+			workload: &appsv1.Deployment{ObjectMeta: workloadMeta},
+			template: func(obj client.Object) *corev1.PodTemplateSpec { return &obj.(*appsv1.Deployment).Spec.Template },
+		},
+		{
+			name:     "app running as a statefulset, with per replica volumes",
+			workload: &appsv1.StatefulSet{ObjectMeta: workloadMeta},
+			template: func(obj client.Object) *corev1.PodTemplateSpec { return &obj.(*appsv1.StatefulSet).Spec.Template },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &crdsv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "env"},
+				Spec: crdsv1.AppSpec{
+					Containers: []crdsv1.AppContainer{
+						{Name: "main", EnvFrom: []crdsv1.EnvFrom{{Type: crdsv1.SecretType, RefName: "mres-db"}}},
+					},
+				},
+			}
+			obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "env"}}
+
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(app, tt.workload, obj).Build()
+			if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); err != nil {
+				t.Fatal(err)
+			}
+
+			MarkRotated(obj, nil, time.Now())
+			if err := cli.Update(context.TODO(), obj); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := EnsureConsumersRestarted(context.TODO(), cli, obj, "mres-db"); err != nil {
+				t.Fatal(err)
+			}
+
+			got := tt.workload.DeepCopyObject().(client.Object)
+			if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(tt.workload), got); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := tt.template(got).Annotations["kubectl.kubernetes.io/restartedAt"]; !ok {
+				t.Fatalf("EnsureConsumersRestarted() did not restart workload of the consuming app")
+			}
+
+			var stored corev1.Secret
+			if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(obj), &stored); err != nil {
+				t.Fatal(err)
+			}
+			if ConsumersRestartPending(&stored) {
+				t.Fatalf("EnsureConsumersRestarted() did not record the restart")
+			}
+		})
+	}
+}
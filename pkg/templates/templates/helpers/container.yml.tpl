@@ -1,12 +1,17 @@
 {{- define "TemplateContainer" }}
 {{- $containers := get . "containers"}}
 {{- $volumeMounts := get . "volumeMounts"}}
+{{- /* sidecars are rendered as native sidecars, among init containers */}}
+{{- $isInit := get . "init" | default false }}
 
 {{- range $idx, $v := $containers }}
 {{- with $v }}
 - name: {{.Name}}
   image: {{.Image}}
   imagePullPolicy: {{.ImagePullPolicy | default "IfNotPresent"}}
+  {{- if and $isInit .IsSidecar }}
+  restartPolicy: Always
+  {{- end }}
   {{- if .Command }}
   command: {{.Command | toYAML | nindent 4 }}
   {{- end}}